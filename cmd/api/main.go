@@ -7,16 +7,20 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"com.thanos/pkg/api"
 	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/health"
+	"com.thanos/pkg/ingestion"
 	"com.thanos/pkg/logger"
-	"com.thanos/pkg/storage/mongodb"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/storage/backend"
+	"com.thanos/pkg/storage/cache"
+	"com.thanos/pkg/tracing"
+	"com.thanos/pkg/upstream"
 	"com.thanos/pkg/validator"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-	"go.mongodb.org/mongo-driver/x/bsonx"
+	"com.thanos/pkg/webhooks"
 )
 
 var version string
@@ -43,51 +47,89 @@ func main() {
 		l.WithError(err).Fatal("could not register validator translations")
 	}
 
-	mClient, err := mongodb.NewMongoClient(cfg.Mongo)
+	tp, err := tracing.Setup(cfg.Tracing, cfg.APP)
 	if err != nil {
-		l.WithError(err).Fatal("mongoDB connection unavailable")
+		l.WithError(err).Fatal("invalid tracing configuration")
 	}
 
-	// Select a database collection and inject it to repo
-	db := mClient.Database(cfg.Mongo.Database)
-	collection := db.Collection(cfg.Mongo.Collection)
-	repo := mongodb.NewMongoRepo(collection, cfg.Mongo)
+	store, err := backend.Open(cfg, l)
+	if err != nil {
+		l.WithError(err).Fatal("storage unavailable")
+	}
+
+	m := metrics.New()
+	repo := tracing.DBRepo(cfg.Storage.Driver, m.Storage.DBRepo(cfg.Storage.Driver, store.Repo))
+	revisions := tracing.RevisionRepo(cfg.Storage.Driver, m.Storage.RevisionRepo(cfg.Storage.Driver, store.Revisions))
+
+	// Articles synced by the fetcher are watched for their events, or else polled
+	watching := cfg.Watcher.Enabled && store.Watcher != nil
 
-	if err = createIndexes(collection, cfg.Mongo.TTL); err != nil {
-		l.WithError(err).Fatal("could not create db indexes")
+	// Reads of the articles are cached in front of the storage, and invalidated by the writes of the api's own sync
+	// and by the changes of the watcher, or else by the syncs of the fetcher
+	var cached *cache.DBRepo
+	if cfg.Cache.Size > 0 {
+		cached = cache.NewDBRepo(repo, cfg.Cache, m.Cache)
+		if watching {
+			store.Watcher.OnChange(cached.Invalidate)
+		}
+		repo = cached
 	}
 
+	bus := events.NewBus(cfg.Events.BufferSize)
+
 	a := api.NewAPI(
-		api.NewJSONResponder(cfg.APP.Name, v.Translator),
+		api.NewNegotiator(cfg.APP.Name, v.Translator),
 		v,
 		repo,
 		cfg,
 		l,
+		api.WithRevisions(revisions),
+		api.WithEvents(bus),
+		api.WithWebhooks(store.Webhooks),
+		api.WithHealth(readiness(cfg, store)),
 	)
 
-	r := api.NewRouter(a, l)
+	r := api.NewRouter(a, m, l)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	go func() {
-		newNewsArticlesTicker := time.NewTicker(cfg.API.NewNewsArticlesFetchInterval).C
-
-		for range newNewsArticlesTicker {
-			articles := a.GetNewNewsArticles()
-			if len(articles) > 0 {
-				br, err := repo.BulkInsert(articles)
-				if err != nil {
-					l.WithError(err).Error("bulkInsert operation failed")
-				}
-				l.Infof("storing: %d articles, bulkInsert result: %+v", len(articles), br)
-			}
+	// Articles stored by a local driver can't be synced by the fetcher, so the api syncs them itself.
+	// This is the only case in which the api writes articles, otherwise the articles the fetcher writes
+	// are watched, or polled from the storage, for their events
+	switch {
+	case store.Local:
+		client := upstream.NewClient(cfg.Upstream)
+		clubs, err := ingestion.NewClubs(cfg.Clubs, client)
+		if err != nil {
+			l.WithError(err).Fatal("invalid clubs configuration")
 		}
-	}()
+
+		dispatcher := webhooks.NewDispatcher(store.Webhooks, cfg.Webhooks, l)
+		go dispatcher.Run(ctx)
+
+		syncer := ingestion.NewSyncer(repo, revisions, store.SyncStatus, client, m.Ingestion, events.Publishers{bus, dispatcher}, clubs, l)
+		l.Infof("syncing news articles every %s", cfg.API.NewNewsArticlesFetchInterval)
+		go syncer.Run(ctx, cfg.API.NewNewsArticlesFetchInterval)
+	case watching:
+		l.Infof("watching the changes of the news articles as %q", cfg.Watcher.ID)
+		go store.Watcher.Run(ctx, bus)
+	default:
+		l.Infof("polling article events every %s", cfg.Events.PollInterval)
+		go events.NewPoller(repo, cfg.Events.PollInterval, l).Run(ctx, bus)
+
+		// the polls don't notice expired articles and new last update dates, every sync may have changed them
+		if cached != nil {
+			l.Infof("the news articles aren't watched, the cache is invalidated by the syncs checked every %s", cfg.Cache.SyncCheckInterval)
+			go cached.WatchSyncs(ctx, store.SyncStatus, cfg.Cache.SyncCheckInterval)
+		}
+	}
 
 	s := http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler: r,
 	}
+	// Event streams never end by themselves, they're ended for the server to shut down
+	s.RegisterOnShutdown(bus.Close)
 
 	// Start listening to incoming requests
 	go func() {
@@ -108,30 +150,22 @@ func main() {
 	}
 
 	cancel()
+
+	if err := store.Close(context.Background()); err != nil {
+		l.WithError(err).Error("failed to close the storage")
+	}
+
+	if err := tp.Shutdown(context.Background()); err != nil {
+		l.WithError(err).Error("failed to export the pending spans")
+	}
 }
 
-func createIndexes(coll *mongo.Collection, ttl time.Duration) error {
-	indexOpts := options.Index()
-	indexOpts.SetUnique(true)
-
-	indexView := coll.Indexes()
-	expireAfterSeconds := int32(ttl.Seconds())
-
-	_, err := indexView.CreateMany(context.Background(),
-		[]mongo.IndexModel{
-			{
-				Keys: bsonx.Doc{
-					{Key: "articleID", Value: bsonx.Int32(1)},
-				},
-				Options: indexOpts,
-			},
-			{
-				Keys: bsonx.Doc{
-					{Key: "publishedAt", Value: bsonx.Int32(1)},
-				},
-				Options: &options.IndexOptions{ExpireAfterSeconds: &expireAfterSeconds},
-			},
-		})
-
-	return err
+// readiness checks the storage, which is critical to the api, and reports on the latest sync of the news articles
+func readiness(cfg *config.Config, store *backend.Backend) *health.Checker {
+	checker := health.NewChecker(cfg.Health.Timeout)
+	checker.Register("storage", true, health.Storage(store.Ping))
+	checker.Register("sync", false, health.Sync(store.SyncStatus, cfg.Health.MaxSyncAge))
+	checker.Register("feeds", false, health.Feeds(store.SyncStatus))
+
+	return checker
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/ingestion"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/storage/backend"
+	"com.thanos/pkg/tracing"
+	"com.thanos/pkg/upstream"
+	"com.thanos/pkg/webhooks"
+)
+
+var version string
+
+func main() {
+	once := flag.Bool("once", false, "fetch and store the latest news articles once and exit")
+	flag.Parse()
+
+	cfg, err := config.New()
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+
+	if version != "" {
+		cfg.APP.Version = version
+		cfg.Logger.AppVersion = version
+	}
+
+	l := logger.NewLogger(cfg.Logger,
+		logger.EnableReportCaller(),
+		logger.SetLevel(cfg.Logger.LogLevel),
+	)
+
+	tp, err := tracing.Setup(cfg.Tracing, cfg.APP)
+	if err != nil {
+		l.WithError(err).Fatal("invalid tracing configuration")
+	}
+
+	store, err := backend.Open(cfg, l)
+	if err != nil {
+		l.WithError(err).Fatal("storage unavailable")
+	}
+	if store.Local {
+		l.Warnf("the %s storage driver is local to this process, articles synced by the fetcher are not served by the api", cfg.Storage.Driver)
+	}
+
+	client := upstream.NewClient(cfg.Upstream)
+	clubs, err := ingestion.NewClubs(cfg.Clubs, client)
+	if err != nil {
+		l.WithError(err).Fatal("invalid clubs configuration")
+	}
+
+	m := metrics.New()
+	repo := tracing.DBRepo(cfg.Storage.Driver, m.Storage.DBRepo(cfg.Storage.Driver, store.Repo))
+	revisions := tracing.RevisionRepo(cfg.Storage.Driver, m.Storage.RevisionRepo(cfg.Storage.Driver, store.Revisions))
+
+	// Events of the fetcher are delivered to the webhooks subscribed to them
+	dispatcher := webhooks.NewDispatcher(store.Webhooks, cfg.Webhooks, l)
+
+	syncer := ingestion.NewSyncer(repo, revisions, store.SyncStatus, client, m.Ingestion, dispatcher, clubs, l)
+
+	// Cancel any in-flight sync when a termination signal is received
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// A single sync exits before it could be scraped
+	if cfg.Metrics.FetcherPort != 0 && !*once {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+
+		s := http.Server{
+			Addr:    fmt.Sprintf(":%d", cfg.Metrics.FetcherPort),
+			Handler: mux,
+		}
+		go func() {
+			l.Infof("serving metrics at port: %d", cfg.Metrics.FetcherPort)
+			if err := s.ListenAndServe(); err != http.ErrServerClosed {
+				l.WithError(err).Error("metrics server error")
+			}
+		}()
+		defer s.Close()
+	}
+
+	exitCode := 0
+	if *once {
+		// The spans of a failed sync are exported before exiting
+		if _, err := syncer.Sync(ctx); err != nil {
+			l.WithError(err).Error("news articles sync failed")
+			exitCode = 1
+		}
+
+		// Failed deliveries are retried by the next run
+		if _, err := dispatcher.Dispatch(ctx); err != nil {
+			l.WithError(err).Error("webhook deliveries failed")
+		}
+	} else {
+		dispatched := make(chan struct{})
+		go func() {
+			defer close(dispatched)
+			dispatcher.Run(ctx)
+		}()
+
+		l.Infof("syncing news articles every %s", cfg.API.NewNewsArticlesFetchInterval)
+		syncer.Run(ctx, cfg.API.NewNewsArticlesFetchInterval)
+
+		// The dispatcher is stopped before the storage is closed
+		<-dispatched
+	}
+
+	l.Debug("fetcher shutting down")
+	if err := store.Close(context.Background()); err != nil {
+		l.WithError(err).Error("failed to close the storage")
+	}
+
+	if err := tp.Shutdown(context.Background()); err != nil {
+		l.WithError(err).Error("failed to export the pending spans")
+	}
+
+	os.Exit(exitCode)
+}
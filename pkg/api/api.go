@@ -1,163 +1,354 @@
 package api
 
 import (
-	"bytes"
-	"encoding/xml"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"runtime"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/health"
 	"com.thanos/pkg/logger"
 	"com.thanos/pkg/news"
-	"com.thanos/pkg/storage/mongodb"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/tracing"
 	"com.thanos/pkg/validator"
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	"github.com/sirupsen/logrus"
 )
 
 const ISO8601 = "2006-01-02T15:04:05.000Z"
 
+// articleIDPattern matches both numeric incrowd ids and the uuids derived for feed sources
+var articleIDPattern = regexp.MustCompile(`^[0-9A-Za-z-]{1,64}$`)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	maxSearchLength  = 256
+)
+
 // Handler custom handler signature to be able to return errors & handle them centrally
 type Handler func(w http.ResponseWriter, r *http.Request) error
 
 type API struct {
 	Responder
 	validate   *validator.Validator
-	repository mongodb.DBRepo
+	repository storage.DBRepo
+	revisions  storage.RevisionRepo
+	webhooks   storage.WebhookRepo
+	events     *events.Bus
+	health     *health.Checker
 	cfg        *config.Config
 	log        *logger.Logger
 }
 
+// Option configures an optional dependency of the API, the routes of a missing dependency are not registered
+type Option func(*API)
+
+// WithRevisions serves the revisions of articles
+func WithRevisions(revisions storage.RevisionRepo) Option {
+	return func(a *API) {
+		a.revisions = revisions
+	}
+}
+
+// WithEvents streams the events of the stored articles published to bus
+func WithEvents(bus *events.Bus) Option {
+	return func(a *API) {
+		a.events = bus
+	}
+}
+
+// WithWebhooks serves the management of the webhooks
+func WithWebhooks(webhooks storage.WebhookRepo) Option {
+	return func(a *API) {
+		a.webhooks = webhooks
+	}
+}
+
+// WithHealth serves the readiness of the api's dependencies
+func WithHealth(h *health.Checker) Option {
+	return func(a *API) {
+		a.health = h
+	}
+}
+
 // NewAPI creates a new API
 func NewAPI(
 	r Responder,
 	v *validator.Validator,
-	repo mongodb.DBRepo,
+	repo storage.DBRepo,
 	c *config.Config,
 	l *logger.Logger,
+	opts ...Option,
 ) *API {
-	return &API{
+	a := &API{
 		Responder:  r,
 		validate:   v,
 		repository: repo,
 		cfg:        c,
 		log:        l,
 	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
 }
 
-// GetNewNewsArticles this is called periodically by a goroutine and its
-// purpose is to fetch news articles periodically
-// Ideally this functionality would be on a separate module and not on the api
-func (a *API) GetNewNewsArticles() []news.NewsArticle {
-	uri := fmt.Sprintf("%s%d", a.cfg.API.GetLatestNewsArticlesUrl, a.cfg.API.NewsArticlesPerCall)
-	req, err := http.NewRequest(http.MethodGet, uri, nil)
+// GetAllArticles retrieve a page of articles. The page size is controlled by the limit query param
+// and subsequent pages are retrieved by passing the next or prev cursor of a response as the cursor query param.
+// Articles withdrawn upstream are only listed when the includeWithdrawn query param is true.
+// Pages are validated by an ETag and a Last-Modified date, conditional requests of unchanged pages get a 304
+func (a *API) GetAllArticles(w http.ResponseWriter, r *http.Request) error {
+	q, err := a.pageQuery(r)
 	if err != nil {
-		a.log.WithError(err).Error("could not create latest news articles request")
+		return err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		a.log.WithError(err).Error("could not retrieve latest news articles")
+	if FormatFromContext(r.Context()).Streams() {
+		return a.streamArticles(w, r, q)
 	}
-	defer resp.Body.Close()
 
-	var newListInformation news.NewListInformation
-	bytez, err := ioutil.ReadAll(resp.Body)
+	page, err := a.repository.GetNewsPage(r.Context(), q)
 	if err != nil {
-		a.log.WithError(err).Error("could not read response bytes")
+		return storageError(err)
 	}
 
-	err = xml.NewDecoder(bytes.NewReader(bytez)).Decode(&newListInformation)
-	if err != nil {
-		a.log.WithError(err).Error("could not decode response bytes")
+	metadata := news.Metadata{
+		CreatedAt:  time.Now().UTC().Format(ISO8601),
+		Sort:       "-published",
+		TotalItems: int(page.TotalItems),
+	}
+	if page.Next != nil {
+		metadata.NextCursor = page.Next.Encode()
+	}
+	if page.Prev != nil {
+		metadata.PrevCursor = page.Prev.Encode()
 	}
 
-	newsItems := newListInformation.NewsletterNewsItems.NewsletterNewsItem
-	newsArticles := make([]news.NewsArticle, 0, len(newsItems))
+	articles := make([]ArticleResponse, len(page.Results))
+	for i := range page.Results {
+		articles[i] = NewArticleResponse(page.Results[i].Data)
+	}
 
-	for i := range newsItems {
-		ni := newsItems[i]
-		newsArticle := news.NewsArticle{
-			Data: news.Data{
-				Id:          ni.NewsArticleID,
-				Published:   ni.PublishDate,
-				Title:       ni.Title,
-				OptaMatchId: ni.OptaMatchId,
-				Url:         ni.ArticleURL,
-			},
-		}
-		newsArticles = append(newsArticles, newsArticle)
+	return a.RespondConditional(
+		r.Context(),
+		w,
+		r,
+		Response{
+			Status:   "success",
+			Data:     articles,
+			Metadata: metadata,
+		},
+		pageValidators(page),
+	)
+}
+
+// streamArticles exports the articles of q starting at its cursor, all of them unless the limit query param is set
+func (a *API) streamArticles(w http.ResponseWriter, r *http.Request, q storage.PageQuery) error {
+	if r.URL.Query().Get("limit") == "" {
+		q.Limit = 0
 	}
 
-	return newsArticles
+	return a.RespondStream(r.Context(), w, ArticleResponse{}, func(yield func(interface{}) error) error {
+		err := a.repository.StreamNews(r.Context(), q, func(res storage.Result) error {
+			return yield(NewArticleResponse(res.Data))
+		})
+		if err != nil {
+			return storageError(err)
+		}
+		return nil
+	})
 }
 
-// GetAllArticles retrieve all articles
-func (a *API) GetAllArticles(w http.ResponseWriter, r *http.Request) error {
-	newsArticles, err := a.repository.GetNews(r.Context())
+// SearchArticles retrieve a page of the articles matching the full-text search of the q query param,
+// sorted by relevance. Pages are controlled like those of GetAllArticles
+func (a *API) SearchArticles(w http.ResponseWriter, r *http.Request) error {
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" || len(text) > maxSearchLength {
+		return ErrBadRequest
+	}
+
+	pq, err := a.pageQuery(r)
+	if err != nil {
+		return err
+	}
+
+	page, err := a.repository.SearchNews(r.Context(), storage.SearchQuery{PageQuery: pq, Text: text})
 	if err != nil {
-		return a.RespondError(r.Context(), w, err)
+		return storageError(err)
+	}
+
+	terms := news.ParseSearch(text)
+	results := make([]SearchResponse, len(page.Results))
+	for i, res := range page.Results {
+		results[i] = SearchResponse{
+			Data:       NewArticleResponse(res.Data),
+			Withdrawn:  res.Withdrawn,
+			Score:      res.Score,
+			Highlights: terms.Highlights(res.Data),
+		}
+	}
+
+	metadata := news.Metadata{
+		CreatedAt:  time.Now().UTC().Format(ISO8601),
+		Sort:       "-score",
+		TotalItems: int(page.TotalItems),
+	}
+	if page.Next != nil {
+		metadata.NextCursor = page.Next.Encode()
+	}
+	if page.Prev != nil {
+		metadata.PrevCursor = page.Prev.Encode()
 	}
 
 	return a.Respond(
 		r.Context(),
 		w,
 		Response{
-			Status: "success",
-			Data:   newsArticles,
-			Metadata: news.Metadata{
-				CreatedAt:  time.Now().UTC().Format(ISO8601),
-				Sort:       "-published",
-				TotalItems: len(newsArticles),
-			},
+			Status:   "success",
+			Data:     results,
+			Metadata: metadata,
 		},
 		http.StatusOK,
 	)
 }
 
-// GetArticleByID retrieve an article by its unique ID
-// TODO: unimplement
+// GetArticleByID retrieve an article by its unique ID. Its ETag is its content hash and conditional
+// requests of an unchanged article get a 304
 func (a *API) GetArticleByID(w http.ResponseWriter, r *http.Request) error {
-	var id string
-	if sid := chi.URLParam(r, "id"); sid != "" {
-		_, err := strconv.ParseInt(sid, 10, 32)
-		if err != nil {
-			return ErrBadRequest
-		}
-		id = sid
+	club, err := a.club(r)
+	if err != nil {
+		return err
 	}
 
-	newsArticle, err := a.repository.GetArticleByID(r.Context(), id)
+	id := chi.URLParam(r, "id")
+	if !articleIDPattern.MatchString(id) {
+		return ErrBadRequest
+	}
+
+	newsArticle, err := a.repository.GetArticleByID(r.Context(), club, id)
 	if err != nil {
-		return a.RespondError(r.Context(), w, err)
+		return storageError(err)
 	}
 
-	return a.Respond(
+	if newsArticle.Withdrawn != nil {
+		return ErrGone
+	}
+
+	return a.RespondConditional(
 		r.Context(),
 		w,
+		r,
 		Response{
 			Status: "success",
-			Data:   newsArticle,
+			Data:   NewArticleResponse(newsArticle.Data),
 			Metadata: news.Metadata{
 				CreatedAt:  time.Now().UTC().Format(ISO8601),
 				Sort:       "-published",
 				TotalItems: 1,
 			},
 		},
+		articleValidators(newsArticle),
+	)
+}
+
+// GetClubs lists the configured clubs
+func (a *API) GetClubs(w http.ResponseWriter, r *http.Request) error {
+	clubs := make([]ClubResponse, len(a.cfg.Clubs))
+	for i, c := range a.cfg.Clubs {
+		clubs[i] = ClubResponse{Name: c.Name, TeamId: c.TeamId}
+	}
+
+	return a.Respond(
+		r.Context(),
+		w,
+		Response{
+			Status: "success",
+			Data:   clubs,
+			Metadata: news.Metadata{
+				CreatedAt:  time.Now().UTC().Format(ISO8601),
+				TotalItems: len(clubs),
+			},
+		},
 		http.StatusOK,
 	)
 }
 
-// Health check
-// TODO: health response should check whether the db connection is stable.
-// If not, api's health should update to reflect that
-func (a *API) Health(w http.ResponseWriter, r *http.Request) error {
-	_, err := w.Write([]byte{})
-	return err
+// pageQuery parses the club, limit, cursor, includeWithdrawn and filter params of a paginated request
+func (a *API) pageQuery(r *http.Request) (storage.PageQuery, error) {
+	club, err := a.club(r)
+	if err != nil {
+		return storage.PageQuery{}, err
+	}
+
+	q := storage.PageQuery{Club: club, Limit: defaultPageLimit}
+
+	if sl := r.URL.Query().Get("limit"); sl != "" {
+		limit, err := strconv.Atoi(sl)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return q, ErrBadRequest
+		}
+		q.Limit = limit
+	}
+
+	if sc := r.URL.Query().Get("cursor"); sc != "" {
+		cursor, err := storage.DecodeCursor(sc)
+		if err != nil {
+			return q, ErrBadRequest
+		}
+		q.Cursor = &cursor
+	}
+
+	if sw := r.URL.Query().Get("includeWithdrawn"); sw != "" {
+		includeWithdrawn, err := strconv.ParseBool(sw)
+		if err != nil {
+			return q, ErrBadRequest
+		}
+		q.IncludeWithdrawn = includeWithdrawn
+	}
+
+	q.Filter, err = a.filter(r)
+
+	return q, err
+}
+
+// club returns the club of a club scoped route, or an empty string for routes across all clubs
+func (a *API) club(r *http.Request) (string, error) {
+	name := chi.URLParam(r, "club")
+	if name == "" {
+		return "", nil
+	}
+
+	if _, ok := a.cfg.Club(name); !ok {
+		return "", ErrNotFound
+	}
+
+	return name, nil
+}
+
+// Livez reports that the api is alive, it doesn't check any dependency
+func (a *API) Livez(w http.ResponseWriter, r *http.Request) error {
+	return a.Respond(r.Context(), w, health.Report{Status: health.StatusUp}, http.StatusOK)
+}
+
+// Readyz reports the health of every dependency of the api. It responds with 503 when a critical dependency is down
+func (a *API) Readyz(w http.ResponseWriter, r *http.Request) error {
+	report := a.health.Check(r.Context())
+
+	statusCode := http.StatusOK
+	if report.Status == health.StatusDown {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	return a.Respond(r.Context(), w, report, statusCode)
 }
 
 // Version returns build version
@@ -166,49 +357,64 @@ func (a *API) Version(w http.ResponseWriter, r *http.Request) error {
 	return a.Respond(r.Context(), w, resp, http.StatusOK)
 }
 
-// ErrorWrapper wrap custom handler signatures to handle & log their errors
+// ErrorWrapper wrap custom handler signatures to handle & log their errors. Requests themselves are logged
+// by the AccessLog middleware
 func (a *API) ErrorWrapper(h Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logFields := logrus.Fields{
-			"url.path": r.URL.RequestURI(),
+		ctx, span := tracing.StartHandler(r, a.cfg.APP.Name)
+		r = r.WithContext(ctx)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		w = ww
+
+		// the span is ended even when h panics, the panic is then recorded as a 500 and re-raised
+		var err error
+		defer func() {
+			if p := recover(); p != nil {
+				tracing.EndHandler(span, http.StatusInternalServerError, fmt.Errorf("panic: %v", p))
+				panic(p)
+			}
+			tracing.EndHandler(span, ww.Status(), err)
+		}()
+
+		if err = h(w, r); err == nil {
+			return
 		}
 
 		statusCode := http.StatusInternalServerError
 		errorType := "errInternalServerError"
-		errorMessage := ""
 		message := ""
 
-		if err := h(w, r); err != nil {
-			if err, ok := err.(Error); ok {
-				message = err.message
-				errorMessage = err.message
-				statusCode = err.statusCode
-				errorType = err.Code
-			}
+		logFields := logrus.Fields{
+			"http.request.id": RequestIDFromContext(ctx),
+			"url.path":        r.URL.RequestURI(),
+		}
 
-			// Prepare log entry and log
-			logFields["http.response.status_code"] = http.StatusOK
-			logFields["error.code"] = statusCode
-			logFields["error.type"] = errorType
-			if errorMessage != "" {
-				logFields["error.message"] = errorMessage
+		if err, ok := err.(Error); ok {
+			message = err.message
+			statusCode = err.statusCode
+			errorType = err.Code
+			if message != "" {
+				logFields["error.message"] = message
 			}
+		} else {
+			logFields["error.message"] = err.Error()
+		}
 
-			_, file, line, ok := runtime.Caller(1)
-			if ok {
-				logFields["log.origin.file.name"] = file
-				logFields["log.origin.file.line"] = line
-			}
+		logFields["http.response.status_code"] = statusCode
+		logFields["error.code"] = statusCode
+		logFields["error.type"] = errorType
 
-			a.log.WithFields(logFields).Error(message)
+		entry := a.log.WithContext(ctx).WithFields(logFields)
+		entry.Error(message)
 
-			// Respond to caller
-			if err := a.RespondError(r.Context(), w, err); err != nil {
-				a.log.WithFields(logFields).WithError(err).Error("failed to responsd")
-			}
-		} else {
-			logFields["http.response.status_code"] = http.StatusOK
-			a.log.WithFields(logFields).Debug("transaction ended")
+		// A stream that failed midway already responded, its error can only be logged
+		if ww.Status() != 0 {
+			return
+		}
+
+		// Respond to caller
+		if err := a.RespondError(ctx, w, err); err != nil {
+			entry.WithError(err).Error("failed to respond")
 		}
 	}
 }
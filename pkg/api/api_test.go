@@ -5,28 +5,41 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"com.thanos/pkg/api"
 	"com.thanos/pkg/config"
 	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
 	"com.thanos/pkg/news"
-	"com.thanos/pkg/storage/mongodb"
+	"com.thanos/pkg/storage"
 	"com.thanos/pkg/validator"
 	"github.com/golang/mock/gomock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestAPI_GetAllArticles(t *testing.T) {
 	testCases := []struct {
 		description          string
-		newsArticles         []mongodb.Result
+		query                string
+		newsArticles         []storage.Result
+		nextCursor           *storage.Cursor
+		expectedLimit        int
 		expectedStatus       int
 		expectedNewsArticles int
+		expectedNextCursor   bool
 		expectedError        error
 	}{
 		{
 			description: "should respond with 200 and a list of news articles",
-			newsArticles: []mongodb.Result{
+			query:       "?limit=2",
+			newsArticles: []storage.Result{
 				{
 					ID:        "62c3063d04b7e4c1864ff552",
 					ArticleID: "645168",
@@ -64,16 +77,30 @@ func TestAPI_GetAllArticles(t *testing.T) {
 					},
 				},
 			},
+			nextCursor:           &storage.Cursor{PublishedAt: time.Now(), ArticleID: "645150"},
+			expectedLimit:        2,
 			expectedStatus:       http.StatusOK,
 			expectedNewsArticles: 2,
+			expectedNextCursor:   true,
 		},
 		{
 			description:          "should respond with 500 and an error response",
-			newsArticles:         []mongodb.Result{},
+			newsArticles:         []storage.Result{},
+			expectedLimit:        20,
 			expectedStatus:       http.StatusInternalServerError,
 			expectedNewsArticles: 0,
 			expectedError:        errors.New("storage error"),
 		},
+		{
+			description:    "should respond with 400 when the limit is out of range",
+			query:          "?limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 when the cursor is malformed",
+			query:          "?cursor=notacursor",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 
 	cfg, err := config.New("../../")
@@ -94,16 +121,21 @@ func TestAPI_GetAllArticles(t *testing.T) {
 		tc := testCases[i]
 
 		t.Run(tc.description, func(t *testing.T) {
-			request := httptest.NewRequest(http.MethodGet, "/articles", nil)
+			request := httptest.NewRequest(http.MethodGet, "/articles"+tc.query, nil)
 
 			// Mock API dependencies
 			ctrl := gomock.NewController(t)
 
-			dbrepo := mongodb.NewMockDBRepo(ctrl)
-			if tc.expectedError != nil {
-				dbrepo.EXPECT().GetNews().Return(tc.newsArticles, tc.expectedError)
-			} else {
-				dbrepo.EXPECT().GetNews().Return(tc.newsArticles, nil)
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			if tc.expectedLimit > 0 {
+				page := storage.Page{
+					Results:    tc.newsArticles,
+					TotalItems: int64(len(tc.newsArticles)),
+					Next:       tc.nextCursor,
+				}
+				dbrepo.EXPECT().
+					GetNewsPage(gomock.Any(), storage.PageQuery{Limit: tc.expectedLimit}).
+					Return(page, tc.expectedError)
 			}
 
 			a := api.NewAPI(responder, v, dbrepo, cfg, log)
@@ -111,7 +143,10 @@ func TestAPI_GetAllArticles(t *testing.T) {
 			recorder := httptest.NewRecorder()
 			a.ErrorWrapper(a.GetAllArticles).ServeHTTP(recorder, request)
 
-			var resp api.Response
+			var resp struct {
+				Data     []storage.Result `json:"data"`
+				Metadata news.Metadata    `json:"metadata"`
+			}
 			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
 				t.Fatal(err)
 			}
@@ -119,6 +154,322 @@ func TestAPI_GetAllArticles(t *testing.T) {
 			if recorder.Code != tc.expectedStatus {
 				t.Fatalf("expected to get status %d, got %d", tc.expectedStatus, recorder.Code)
 			}
+
+			if len(resp.Data) != tc.expectedNewsArticles {
+				t.Fatalf("expected to get %d news articles, got %d", tc.expectedNewsArticles, len(resp.Data))
+			}
+
+			if tc.expectedNextCursor {
+				if _, err := storage.DecodeCursor(resp.Metadata.NextCursor); err != nil {
+					t.Fatalf("expected a valid next cursor, got %q", resp.Metadata.NextCursor)
+				}
+			}
 		})
 	}
 }
+
+func TestAPI_ClubRoutes(t *testing.T) {
+	testCases := []struct {
+		description    string
+		path           string
+		expectedQuery  *storage.PageQuery
+		expectedStatus int
+	}{
+		{
+			description:    "should list the articles of a configured club",
+			path:           "/v1/clubs/brentford/articles",
+			expectedQuery:  &storage.PageQuery{Club: "brentford", Limit: 20},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "should list withdrawn articles when asked to",
+			path:           "/v1/articles?includeWithdrawn=true",
+			expectedQuery:  &storage.PageQuery{Limit: 20, IncludeWithdrawn: true},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "should respond with 404 for an unknown club",
+			path:           "/v1/clubs/unknown/articles",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description:    "should list the configured clubs",
+			path:           "/v1/clubs",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Clubs = []config.Club{{Name: "brentford", TeamId: "t94"}}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			if tc.expectedQuery != nil {
+				dbrepo.EXPECT().GetNewsPage(gomock.Any(), *tc.expectedQuery).Return(storage.Page{}, nil)
+			}
+
+			router := api.NewRouter(api.NewAPI(responder, v, dbrepo, cfg, log), metrics.New(), log)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestAPI_GetArticleByID_ResponseShape(t *testing.T) {
+	bytez, err := os.ReadFile("../../expected_news_item_response.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(bytez, &expected); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stored data as the fetcher writes it: publish date in the upstream format and empty optional values
+	var stored news.Data
+	if err := json.Unmarshal(expected.Data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	stored.Published = "2022-07-03 15:00:00"
+	stored.OptaMatchId = ""
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	ctrl := gomock.NewController(t)
+
+	dbrepo := storage.NewMockDBRepo(ctrl)
+	dbrepo.EXPECT().GetArticleByID(gomock.Any(), "", "645067").Return(storage.Result{
+		ID:        "62c3063d04b7e4c1864ff551",
+		ArticleID: "645067",
+		Data:      stored,
+	}, nil)
+
+	a := api.NewAPI(responder, v, dbrepo, cfg, log)
+
+	router := api.NewRouter(a, metrics.New(), log)
+	request := httptest.NewRequest(http.MethodGet, "/v1/article/645067", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected to get status %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	var resp struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Status != expected.Status {
+		t.Fatalf("expected status %q, got %q", expected.Status, resp.Status)
+	}
+
+	var want, got map[string]interface{}
+	if err := json.Unmarshal(expected.Data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(resp.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("response data does not match expected_news_item_response.json\nexpected: %v\ngot: %v", want, got)
+	}
+}
+
+func TestAPI_GetArticleByID(t *testing.T) {
+	storageErr := func(kind error) error {
+		return storage.NewError(kind, "getArticleByID", errors.New("backend error"))
+	}
+
+	testCases := []struct {
+		description    string
+		newsArticle    storage.Result
+		repoErr        error
+		expectedStatus int
+		expectedType   string
+	}{
+		{
+			description:    "should respond with 200 and the news article",
+			newsArticle:    storage.Result{ArticleID: "645168", Data: news.Data{Id: "645168"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description: "should respond with 410 when the news article was withdrawn",
+			newsArticle: storage.Result{
+				ArticleID: "645168",
+				Data:      news.Data{Id: "645168"},
+				Withdrawn: &news.Withdrawal{At: time.Now(), Reason: news.WithdrawnDeleted},
+			},
+			expectedStatus: http.StatusGone,
+			expectedType:   "errGone",
+		},
+		{
+			description:    "should respond with 404 when the news article doesn't exist",
+			repoErr:        storageErr(storage.ErrNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "errNotFound",
+		},
+		{
+			description:    "should respond with 409 on a storage conflict",
+			repoErr:        storageErr(storage.ErrConflict),
+			expectedStatus: http.StatusConflict,
+			expectedType:   "errConflict",
+		},
+		{
+			description:    "should respond with 503 when the storage is unavailable",
+			repoErr:        storageErr(storage.ErrUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedType:   "errServiceUnavailable",
+		},
+		{
+			description:    "should respond with 504 when the storage times out",
+			repoErr:        storageErr(storage.ErrTimeout),
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedType:   "errGatewayTimeout",
+		},
+		{
+			description:    "should respond with 400 on an invalid storage query",
+			repoErr:        storageErr(storage.ErrInvalidQuery),
+			expectedStatus: http.StatusBadRequest,
+			expectedType:   "errBadRequest",
+		},
+		{
+			description:    "should respond with 500 on an unknown storage error",
+			repoErr:        errors.New("storage error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			dbrepo.EXPECT().GetArticleByID(gomock.Any(), "", "645168").Return(tc.newsArticle, tc.repoErr)
+
+			router := api.NewRouter(api.NewAPI(responder, v, dbrepo, cfg, log), metrics.New(), log)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/article/645168", nil))
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			if tc.expectedType == "" {
+				return
+			}
+
+			var resp api.ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Type != tc.expectedType {
+				t.Fatalf("expected error type %s, got %s", tc.expectedType, resp.Type)
+			}
+		})
+	}
+}
+
+func TestAPI_ErrorWrapper_Panic(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := api.NewAPI(api.NewJSONResponder(cfg.APP.Name, v.Translator), v, storage.NewMockDBRepo(gomock.NewController(t)), cfg, log)
+	h := a.ErrorWrapper(func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	})
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("expected the panic to be re-raised, got %v", p)
+			}
+		}()
+		h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/articles", nil))
+	}()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected the span of the request to be ended, got %d ended spans", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Fatalf("expected the span to be failed, got %v", spans[0].Status())
+	}
+	if len(spans[0].Events()) == 0 {
+		t.Fatal("expected the panic to be recorded on the span")
+	}
+}
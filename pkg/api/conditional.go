@@ -0,0 +1,118 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+)
+
+// Validators identify the version of a response, they answer conditional requests (RFC 7232)
+type Validators struct {
+	// ETag is a weak entity tag without its W/ prefix and quotes, empty when the version is unknown.
+	// It's weak since it's derived from the stored content rather than from the bytes of the response
+	ETag         string
+	LastModified time.Time
+}
+
+// articleValidators returns the validators of a single article, its content hash and its latest update
+func articleValidators(res storage.Result) Validators {
+	return Validators{
+		ETag:         res.ContentHash,
+		LastModified: lastUpdate(res),
+	}
+}
+
+// pageValidators returns the validators of a page of articles. Its ETag is derived from the content hashes
+// and withdrawals of its articles and from its metadata, but for createdAt, so that it changes along with
+// any of them. It's last modified when its latest article was updated
+func pageValidators(page storage.Page) Validators {
+	var v Validators
+
+	h := sha256.New()
+	for _, res := range page.Results {
+		if res.ContentHash == "" {
+			// articles stored before content hashes were don't have a version
+			return Validators{}
+		}
+		fmt.Fprintf(h, "%s:%s;", res.ArticleID, res.ContentHash)
+		if res.Withdrawn != nil {
+			fmt.Fprintf(h, "withdrawn:%d:%s;", res.Withdrawn.At.UnixNano(), res.Withdrawn.Reason)
+		}
+
+		if lu := lastUpdate(res); lu.After(v.LastModified) {
+			v.LastModified = lu
+		}
+	}
+	fmt.Fprintf(h, "total:%d;", page.TotalItems)
+	if page.Next != nil {
+		fmt.Fprintf(h, "next:%s;", page.Next.Encode())
+	}
+	if page.Prev != nil {
+		fmt.Fprintf(h, "prev:%s;", page.Prev.Encode())
+	}
+
+	v.ETag = hex.EncodeToString(h.Sum(nil)[:16])
+
+	return v
+}
+
+// lastUpdate returns when an article was last updated upstream, or withdrawn
+func lastUpdate(res storage.Result) time.Time {
+	lu, err := time.Parse(news.DateTimeFormat, res.LastUpdateDate)
+	if err != nil {
+		lu = time.Time{}
+	}
+	if res.Withdrawn != nil && res.Withdrawn.At.After(lu) {
+		lu = res.Withdrawn.At
+	}
+
+	return lu
+}
+
+// notModified reports whether the conditional headers of a request match the validators of the response.
+// If-Modified-Since is only evaluated when the request has no If-None-Match
+func notModified(r *http.Request, v Validators) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return v.ETag != "" && etagMatch(inm, v.ETag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || v.LastModified.IsZero() {
+		return false
+	}
+
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	// Last-Modified has a precision of a second
+	return !v.LastModified.Truncate(time.Second).After(t)
+}
+
+// etagMatch reports whether the If-None-Match header lists the etag, entity tags are compared weakly
+func etagMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == `"`+etag+`"` {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatETag formats the etag as a weak entity tag
+func formatETag(etag string) string {
+	return `W/"` + etag + `"`
+}
@@ -0,0 +1,148 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPI_ConditionalGet(t *testing.T) {
+	lastUpdate := time.Date(2022, 7, 4, 7, 24, 35, 0, time.UTC)
+	results := []storage.Result{
+		{ArticleID: "645168", LastUpdateDate: "2022-07-03 14:58:01", ContentHash: "a1", Data: news.Data{Id: "645168"}},
+		{ArticleID: "645169", LastUpdateDate: lastUpdate.Format(news.DateTimeFormat), ContentHash: "b2", Data: news.Data{Id: "645169"}},
+	}
+
+	testCases := []struct {
+		description    string
+		path           string
+		header         func(etag, lastModified string) http.Header
+		changed        bool
+		expectedStatus int
+	}{
+		{
+			description:    "should respond with 304 to a list request matching the etag",
+			path:           "/v1/articles",
+			header:         func(etag, _ string) http.Header { return http.Header{"If-None-Match": {`"other", ` + etag}} },
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			description:    "should respond with 200 to a list request not matching the etag",
+			path:           "/v1/articles",
+			header:         func(etag, _ string) http.Header { return http.Header{"If-None-Match": {etag}} },
+			changed:        true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "should respond with 304 to a list request not modified since",
+			path:           "/v1/articles",
+			header:         func(_, lastModified string) http.Header { return http.Header{"If-Modified-Since": {lastModified}} },
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			description: "should ignore If-Modified-Since when If-None-Match is set",
+			path:        "/v1/articles",
+			header: func(_, lastModified string) http.Header {
+				return http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified}}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description: "should respond with 200 to a list request modified since",
+			path:        "/v1/articles",
+			header: func(_, _ string) http.Header {
+				return http.Header{"If-Modified-Since": {lastUpdate.Add(-time.Second).Format(http.TimeFormat)}}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description: "should respond with 304 to an article request matching the etag without its weak prefix",
+			path:        "/v1/clubs/brentford/article/645169",
+			header: func(etag, _ string) http.Header {
+				return http.Header{"If-None-Match": {strings.TrimPrefix(etag, "W/")}}
+			},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			description:    "should respond with 200 to a changed article",
+			path:           "/v1/article/645169",
+			header:         func(etag, _ string) http.Header { return http.Header{"If-None-Match": {etag}} },
+			changed:        true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Clubs = []config.Club{{Name: "brentford"}}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			stored := append([]storage.Result{}, results...)
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			dbrepo.EXPECT().
+				GetNewsPage(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _ interface{}) (storage.Page, error) {
+					return storage.Page{Results: stored, TotalItems: int64(len(stored))}, nil
+				}).
+				AnyTimes()
+			dbrepo.EXPECT().
+				GetArticleByID(gomock.Any(), gomock.Any(), "645169").
+				DoAndReturn(func(_, _, _ interface{}) (storage.Result, error) { return stored[1], nil }).
+				AnyTimes()
+
+			router := api.NewRouter(api.NewAPI(responder, v, dbrepo, cfg, log), metrics.New(), log)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			etag, lastModified := recorder.Header().Get("ETag"), recorder.Header().Get("Last-Modified")
+			if !strings.HasPrefix(etag, `W/"`) || lastModified != lastUpdate.Format(http.TimeFormat) {
+				t.Fatalf("expected a weak etag and last modified date %s, got %q and %q", lastUpdate.Format(http.TimeFormat), etag, lastModified)
+			}
+
+			if tc.changed {
+				stored[1].ContentHash = "c3"
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header = tc.header(etag, lastModified)
+			recorder = httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			if tc.expectedStatus == http.StatusNotModified && (recorder.Body.Len() != 0 || recorder.Header().Get("ETag") != etag) {
+				t.Fatalf("expected an empty 304 response with etag %s, got %q and %s", etag, recorder.Body.String(), recorder.Header().Get("ETag"))
+			}
+		})
+	}
+}
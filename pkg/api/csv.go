@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/tracing"
+)
+
+// flushEvery is the number of streamed items after which the response is flushed to the caller
+const flushEvery = 100
+
+// CSVResponder serves responses as CSV with a header row. The rows are the items of the data of a Response,
+// or the single item of any other response. Nested objects are flattened into dotted columns, e.g. data.title,
+// lists of values are joined with | and other values, e.g. maps, are encoded as JSON
+type CSVResponder struct {
+	serviceName string
+}
+
+// NewCSVResponder creates a new CSVResponder
+func NewCSVResponder(sn string) Responder {
+	return &CSVResponder{
+		serviceName: sn,
+	}
+}
+
+// Respond converts a Go value to CSV and serves it as a response back to the caller
+func (r *CSVResponder) Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) (err error) {
+	_, span := tracing.Start(ctx, "encode")
+	defer func() { tracing.End(span, err) }()
+
+	if resp, ok := data.(Response); ok {
+		data = resp.Data
+	}
+
+	v := reflect.ValueOf(data)
+	rows := []reflect.Value{v}
+	t := reflect.TypeOf(data)
+	if !v.IsValid() {
+		rows = nil
+	} else if v.Kind() == reflect.Slice {
+		rows = rows[:0]
+		for i := 0; i < v.Len(); i++ {
+			rows = append(rows, v.Index(i))
+		}
+		t = t.Elem()
+	}
+
+	columns := csvColumns(t, "", nil)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+	}
+
+	cw := csv.NewWriter(w)
+	if err = cw.Write(csvHeader(columns)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err = cw.Write(csvRecord(columns, row)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// RespondConditional serves data like Respond, unless it was not modified
+func (r *CSVResponder) RespondConditional(ctx context.Context, w http.ResponseWriter, req *http.Request, data interface{}, v Validators) error {
+	if respondNotModified(w, req, v) {
+		return nil
+	}
+
+	return r.Respond(ctx, w, data, http.StatusOK)
+}
+
+// RespondStream writes a row for every item as it is yielded. The header row is only written along with the
+// first item, or once stream ends without any, so that an error before any item still gets an error response
+func (r *CSVResponder) RespondStream(ctx context.Context, w http.ResponseWriter, zero interface{}, stream Stream) error {
+	columns := csvColumns(reflect.TypeOf(zero), "", nil)
+	cw := csv.NewWriter(w)
+	flusher, _ := w.(http.Flusher)
+
+	rows := 0
+	writeHeader := func() error {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		return cw.Write(csvHeader(columns))
+	}
+
+	err := stream(func(item interface{}) error {
+		if rows == 0 {
+			if err := writeHeader(); err != nil {
+				return err
+			}
+		}
+		if err := cw.Write(csvRecord(columns, reflect.ValueOf(item))); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%flushEvery == 0 {
+			cw.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		return cw.Error()
+	})
+	if err != nil {
+		cw.Flush()
+		return err
+	}
+
+	if rows == 0 {
+		if err = writeHeader(); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// RespondError serves the error as a single row
+func (r *CSVResponder) RespondError(ctx context.Context, w http.ResponseWriter, err error) error {
+	errResp, statusCode := errorResponse(ctx, r.serviceName, err)
+
+	return r.Respond(ctx, w, errResp, statusCode)
+}
+
+// csvColumn is a column of the values of a type, the path of struct field indexes leading to its value
+type csvColumn struct {
+	name string
+	path []int
+}
+
+// csvColumns returns the columns of the values of type t, named after their JSON fields. The fields of
+// nested structs are flattened into dotted columns and those of embedded structs are promoted
+func csvColumns(t reflect.Type, prefix string, path []int) []csvColumn {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		name := strings.TrimSuffix(prefix, ".")
+		if name == "" {
+			name = "value"
+		}
+		return []csvColumn{{name: name, path: path}}
+	}
+
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		fieldPath := append(append([]int{}, path...), i)
+		if f.Anonymous && name == "" {
+			columns = append(columns, csvColumns(f.Type, prefix, fieldPath)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		columns = append(columns, csvColumns(f.Type, prefix+name+".", fieldPath)...)
+	}
+
+	return columns
+}
+
+func csvHeader(columns []csvColumn) []string {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+
+	return header
+}
+
+// csvRecord returns the cells of a value, those of nil structs are empty
+func csvRecord(columns []csvColumn, v reflect.Value) []string {
+	record := make([]string, len(columns))
+
+	for i, c := range columns {
+		fv := v
+		for _, index := range c.path {
+			fv = indirect(fv)
+			if !fv.IsValid() {
+				break
+			}
+			fv = fv.Field(index)
+		}
+		record[i] = csvCell(fv)
+	}
+
+	return record
+}
+
+// csvCell formats a value as a single cell
+func csvCell(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time:
+		return value.UTC().Format(time.RFC3339)
+	case string:
+		return value
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface())
+	case reflect.Slice, reflect.Array:
+		values := make([]string, v.Len())
+		for i := range values {
+			item := indirect(v.Index(i))
+			if item.IsValid() && (item.Kind() == reflect.Struct || item.Kind() == reflect.Map || item.Kind() == reflect.Slice) {
+				return jsonCell(v)
+			}
+			values[i] = csvCell(item)
+		}
+		return strings.Join(values, "|")
+	default:
+		return jsonCell(v)
+	}
+}
+
+func jsonCell(v reflect.Value) string {
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+// indirect dereferences pointers and interfaces, nil ones yield an invalid value
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
@@ -1,14 +1,41 @@
 package api
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+
+	"com.thanos/pkg/storage"
+)
 
 // ErrBadRequest represents an error message for bad requests
 var ErrBadRequest = NewError(http.StatusText(http.StatusBadRequest), "errBadRequest", http.StatusBadRequest)
 
+// ErrNotFound represents an error message for resources that do not exist
+var ErrNotFound = NewError(http.StatusText(http.StatusNotFound), "errNotFound", http.StatusNotFound)
+
+// ErrConflict represents an error message for requests conflicting with the stored resources
+var ErrConflict = NewError(http.StatusText(http.StatusConflict), "errConflict", http.StatusConflict)
+
+// ErrServiceUnavailable represents an error message for when the storage can not be reached
+var ErrServiceUnavailable = NewError(http.StatusText(http.StatusServiceUnavailable), "errServiceUnavailable", http.StatusServiceUnavailable)
+
+// ErrGatewayTimeout represents an error message for when the storage doesn't respond in time
+var ErrGatewayTimeout = NewError(http.StatusText(http.StatusGatewayTimeout), "errGatewayTimeout", http.StatusGatewayTimeout)
+
+// ErrGone represents an error message for articles that were withdrawn upstream
+var ErrGone = NewError(http.StatusText(http.StatusGone), "errGone", http.StatusGone)
+
+// ErrUnauthorized represents an error message for requests to admin endpoints without a valid token
+var ErrUnauthorized = NewError(http.StatusText(http.StatusUnauthorized), "errUnauthorized", http.StatusUnauthorized)
+
+// ErrNotAcceptable represents an error message for requests accepting none of the supported formats
+var ErrNotAcceptable = NewError(http.StatusText(http.StatusNotAcceptable), "errNotAcceptable", http.StatusNotAcceptable)
+
 type Error struct {
 	message    string
 	Code       string
 	statusCode int
+	reasons    []ErrorReason
 }
 
 func NewError(m, c string, s int) Error {
@@ -22,3 +49,28 @@ func NewError(m, c string, s int) Error {
 func (e Error) Error() string {
 	return e.message
 }
+
+// WithReasons returns a copy of the error carrying the specific reasons of the error
+func (e Error) WithReasons(reasons []ErrorReason) Error {
+	e.reasons = reasons
+	return e
+}
+
+// storageError maps storage errors to their api error. Errors of an unknown kind are returned
+// as they are and end up as internal server errors
+func storageError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, storage.ErrConflict):
+		return ErrConflict
+	case errors.Is(err, storage.ErrUnavailable):
+		return ErrServiceUnavailable
+	case errors.Is(err, storage.ErrTimeout):
+		return ErrGatewayTimeout
+	case errors.Is(err, storage.ErrInvalidQuery):
+		return ErrBadRequest
+	default:
+		return err
+	}
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/tracing"
+)
+
+// Feed content types
+const (
+	rssContentType  = "application/rss+xml; charset=utf-8"
+	atomContentType = "application/atom+xml; charset=utf-8"
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link,omitempty"`
+	Guid        rssGuid        `xml:"guid"`
+	Description string         `xml:"description,omitempty"`
+	PubDate     string         `xml:"pubDate,omitempty"`
+	Categories  []string       `xml:"category"`
+	Enclosures  []rssEnclosure `xml:"enclosure"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssEnclosure is a media file of an item, its length is unknown so it's 0 as commonly done
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    *atomContent   `xml:"content"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int    `xml:"length,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// GetRSSFeed serves the latest articles as an RSS 2.0 feed. The articles are filtered and limited like
+// those of GetAllArticles
+func (a *API) GetRSSFeed(w http.ResponseWriter, r *http.Request) error {
+	page, err := a.feedPage(r)
+	if err != nil {
+		return err
+	}
+
+	self := selfURL(r)
+	feed := rssFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       a.cfg.APP.Name,
+			Link:        self,
+			Description: "The latest news articles of " + a.cfg.APP.Name,
+			Self:        atomLink{Href: self, Rel: "self", Type: "application/rss+xml"},
+			Items:       make([]rssItem, len(page.Results)),
+		},
+	}
+
+	v := feedValidators(page, "rss")
+	if !v.LastModified.IsZero() {
+		feed.Channel.LastBuildDate = v.LastModified.Format(time.RFC1123Z)
+	}
+
+	for i, res := range page.Results {
+		item := rssItem{
+			Title:       res.Data.Title,
+			Link:        res.Data.Url,
+			Guid:        rssGuid{Value: res.Data.Id},
+			Description: teaser(res.Data),
+			Categories:  res.Data.Type,
+		}
+		if published, ok := publishedAt(res); ok {
+			item.PubDate = published.Format(time.RFC1123Z)
+		}
+		for _, e := range enclosures(res.Data) {
+			item.Enclosures = append(item.Enclosures, rssEnclosure{URL: e.Href, Type: e.Type})
+		}
+
+		feed.Channel.Items[i] = item
+	}
+
+	return a.respondFeed(w, r, rssContentType, feed, v)
+}
+
+// GetAtomFeed serves the latest articles as an Atom feed. The articles are filtered and limited like
+// those of GetAllArticles
+func (a *API) GetAtomFeed(w http.ResponseWriter, r *http.Request) error {
+	page, err := a.feedPage(r)
+	if err != nil {
+		return err
+	}
+
+	v := feedValidators(page, "atom")
+
+	self := selfURL(r)
+	feed := atomFeed{
+		ID:      self,
+		Title:   a.cfg.APP.Name,
+		Updated: atomDate(v.LastModified),
+		Links:   []atomLink{{Href: self, Rel: "self", Type: "application/atom+xml"}},
+		Entries: make([]atomEntry, len(page.Results)),
+	}
+
+	for i, res := range page.Results {
+		entry := atomEntry{
+			ID:      "urn:" + a.cfg.APP.Name + ":article:" + res.Data.Id,
+			Title:   res.Data.Title,
+			Summary: teaser(res.Data),
+		}
+
+		published, ok := publishedAt(res)
+		if ok {
+			entry.Published = atomDate(published)
+		}
+		// updated is required, articles never updated upstream were last updated when published
+		updated := lastUpdate(res)
+		if updated.IsZero() {
+			updated = published
+		}
+		entry.Updated = atomDate(updated)
+
+		if res.Data.Url != "" {
+			entry.Links = append(entry.Links, atomLink{Href: res.Data.Url, Rel: "alternate", Type: "text/html"})
+		}
+		entry.Links = append(entry.Links, enclosures(res.Data)...)
+		for _, t := range res.Data.Type {
+			entry.Categories = append(entry.Categories, atomCategory{Term: t})
+		}
+		if res.Data.Content != "" {
+			entry.Content = &atomContent{Type: "html", Value: res.Data.Content}
+		}
+
+		feed.Entries[i] = entry
+	}
+
+	return a.respondFeed(w, r, atomContentType, feed, v)
+}
+
+// feedPage returns the latest articles of a feed, withdrawn ones are never included
+func (a *API) feedPage(r *http.Request) (storage.Page, error) {
+	q, err := a.pageQuery(r)
+	if err != nil {
+		return storage.Page{}, err
+	}
+	q.IncludeWithdrawn = false
+
+	page, err := a.repository.GetNewsPage(r.Context(), q)
+	if err != nil {
+		return page, storageError(err)
+	}
+
+	return page, nil
+}
+
+// respondFeed serves a feed, unless it was not modified
+func (a *API) respondFeed(w http.ResponseWriter, r *http.Request, contentType string, feed interface{}, v Validators) (err error) {
+	if respondNotModified(w, r, v) {
+		return nil
+	}
+
+	_, span := tracing.Start(r.Context(), "encode")
+	defer func() { tracing.End(span, err) }()
+
+	w.Header().Set("Content-Type", contentType)
+	if _, err = w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(feed)
+}
+
+// feedValidators returns the validators of the page of a feed, its ETag is suffixed with the feed format since
+// the feed is another representation of the page
+func feedValidators(page storage.Page, format string) Validators {
+	v := pageValidators(page)
+	if v.ETag != "" {
+		v.ETag += "-" + format
+	}
+
+	return v
+}
+
+// enclosures returns the image and video of an article as enclosure links
+func enclosures(d news.Data) []atomLink {
+	var links []atomLink
+
+	if d.ImageUrl != "" {
+		links = append(links, atomLink{Href: d.ImageUrl, Rel: "enclosure", Type: mediaType(d.ImageUrl, "image/jpeg")})
+	}
+	if video, ok := d.VideoUrl.(string); ok && video != "" {
+		links = append(links, atomLink{Href: video, Rel: "enclosure", Type: mediaType(video, "video/mp4")})
+	}
+
+	return links
+}
+
+// mediaType guesses the media type of a file by the extension of its url
+func mediaType(rawURL, fallback string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fallback
+	}
+	if t := mime.TypeByExtension(path.Ext(u.Path)); t != "" {
+		return t
+	}
+
+	return fallback
+}
+
+func teaser(d news.Data) string {
+	if t, ok := d.Teaser.(string); ok {
+		return t
+	}
+	return ""
+}
+
+func publishedAt(res storage.Result) (time.Time, bool) {
+	published, err := time.Parse(news.DateTimeFormat, res.Data.Published)
+	return published, err == nil
+}
+
+// atomDate formats a date as an RFC 3339 date time, the zero date when unknown
+func atomDate(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// selfURL returns the absolute url of a request
+func selfURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
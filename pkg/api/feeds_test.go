@@ -0,0 +1,176 @@
+package api_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+type feedLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssFeed struct {
+	Channel struct {
+		LastBuildDate string `xml:"lastBuildDate"`
+		Items         []struct {
+			Guid       string   `xml:"guid"`
+			PubDate    string   `xml:"pubDate"`
+			Categories []string `xml:"category"`
+			Enclosures []struct {
+				URL  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomFeed struct {
+	Updated string `xml:"updated"`
+	Entries []struct {
+		Updated   string     `xml:"updated"`
+		Published string     `xml:"published"`
+		Links     []feedLink `xml:"link"`
+	} `xml:"entry"`
+}
+
+func TestAPI_Feeds(t *testing.T) {
+	results := []storage.Result{
+		{
+			ArticleID:      "645169",
+			LastUpdateDate: "2022-07-04 07:24:35",
+			ContentHash:    "b2",
+			Data: news.Data{
+				Id:        "645169",
+				Title:     "Second",
+				Type:      []string{"Club News"},
+				Url:       "https://www.brentfordfc.com/news/645169",
+				ImageUrl:  "https://www.brentfordfc.com/images/645169.png",
+				VideoUrl:  "https://www.brentfordfc.com/videos/645169.mp4",
+				Published: "2022-07-03 14:58:01",
+			},
+		},
+		{ArticleID: "645168", ContentHash: "a1", Data: news.Data{Id: "645168", Title: "First", Published: "2022-07-02 10:00:00"}},
+	}
+
+	testCases := []struct {
+		description         string
+		path                string
+		expectedQuery       storage.PageQuery
+		expectedContentType string
+		check               func(t *testing.T, body []byte)
+	}{
+		{
+			description:         "should serve the articles as an RSS feed",
+			path:                "/v1/feeds/rss.xml",
+			expectedQuery:       storage.PageQuery{Limit: 20},
+			expectedContentType: "application/rss+xml; charset=utf-8",
+			check: func(t *testing.T, body []byte) {
+				var feed rssFeed
+				if err := xml.Unmarshal(body, &feed); err != nil {
+					t.Fatal(err)
+				}
+				if feed.Channel.LastBuildDate != "Mon, 04 Jul 2022 07:24:35 +0000" || len(feed.Channel.Items) != 2 {
+					t.Fatalf("unexpected RSS feed: %s", body)
+				}
+				item := feed.Channel.Items[0]
+				if item.Guid != "645169" || item.PubDate != "Sun, 03 Jul 2022 14:58:01 +0000" || len(item.Categories) != 1 {
+					t.Fatalf("unexpected RSS item: %+v", item)
+				}
+				if len(item.Enclosures) != 2 || item.Enclosures[0].Type != "image/png" || item.Enclosures[1].Type != "video/mp4" {
+					t.Fatalf("expected an image and a video enclosure, got %+v", item.Enclosures)
+				}
+				if len(feed.Channel.Items[1].Enclosures) != 0 {
+					t.Fatalf("expected no enclosures, got %+v", feed.Channel.Items[1].Enclosures)
+				}
+			},
+		},
+		{
+			description: "should serve the filtered articles as an Atom feed",
+			path:        "/v1/feeds/atom.xml?type=Club+News&optaMatchId=g2300001",
+			expectedQuery: storage.PageQuery{
+				Limit:  20,
+				Filter: storage.Filter{Types: []string{"Club News"}, OptaMatchId: "g2300001"},
+			},
+			expectedContentType: "application/atom+xml; charset=utf-8",
+			check: func(t *testing.T, body []byte) {
+				var feed atomFeed
+				if err := xml.Unmarshal(body, &feed); err != nil {
+					t.Fatal(err)
+				}
+				if feed.Updated != "2022-07-04T07:24:35Z" || len(feed.Entries) != 2 {
+					t.Fatalf("unexpected Atom feed: %s", body)
+				}
+				if entry := feed.Entries[0]; entry.Updated != "2022-07-04T07:24:35Z" || entry.Published != "2022-07-03T14:58:01Z" || len(entry.Links) != 3 {
+					t.Fatalf("unexpected Atom entry: %+v", entry)
+				}
+				if entry := feed.Entries[1]; entry.Updated != "2022-07-02T10:00:00Z" {
+					t.Fatalf("expected an entry never updated to be updated when published, got %+v", entry)
+				}
+			},
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewNegotiator(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			dbrepo.EXPECT().
+				GetNewsPage(gomock.Any(), tc.expectedQuery).
+				Return(storage.Page{Results: results, TotalItems: int64(len(results))}, nil).
+				Times(2)
+
+			router := api.NewRouter(api.NewAPI(responder, v, dbrepo, cfg, log), metrics.New(), log)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected to get status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body)
+			}
+			if ct := recorder.Header().Get("Content-Type"); ct != tc.expectedContentType {
+				t.Fatalf("expected content type %q, got %q", tc.expectedContentType, ct)
+			}
+
+			tc.check(t, recorder.Body.Bytes())
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("If-None-Match", recorder.Header().Get("ETag"))
+			recorder = httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusNotModified {
+				t.Fatalf("expected to get status %d, got %d", http.StatusNotModified, recorder.Code)
+			}
+		})
+	}
+}
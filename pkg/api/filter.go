@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/storage"
+	"github.com/go-playground/validator/v10"
+)
+
+const dateLayout = "2006-01-02"
+
+// filterParams are the query params that filter the article list, validated before being translated to a filter.
+// Dates are either a day or an RFC 3339 date time, a day as the to date includes the whole day
+type filterParams struct {
+	Type        []string `json:"type" validate:"max=10,dive,required,max=64"`
+	From        string   `json:"from" validate:"omitempty,date"`
+	To          string   `json:"to" validate:"omitempty,date"`
+	OptaMatchId string   `json:"optaMatchId" validate:"omitempty,alphanum,max=32"`
+	HasVideo    string   `json:"hasVideo" validate:"omitempty,oneof=true false"`
+	HasGallery  string   `json:"hasGallery" validate:"omitempty,oneof=true false"`
+	TeamId      string   `json:"teamId" validate:"omitempty,alphanum,max=16"`
+}
+
+// filter parses and validates the filter query params of a request. Invalid params are reported
+// as the reasons of a bad request error
+func (a *API) filter(r *http.Request) (storage.Filter, error) {
+	query := r.URL.Query()
+
+	params := filterParams{
+		From:        query.Get("from"),
+		To:          query.Get("to"),
+		OptaMatchId: query.Get("optaMatchId"),
+		HasVideo:    query.Get("hasVideo"),
+		HasGallery:  query.Get("hasGallery"),
+		TeamId:      query.Get("teamId"),
+	}
+	for _, t := range query["type"] {
+		for _, tt := range strings.Split(t, ",") {
+			params.Type = append(params.Type, strings.TrimSpace(tt))
+		}
+	}
+
+	if err := a.validate.Struct(params); err != nil {
+		return storage.Filter{}, a.validationError(err)
+	}
+
+	f := storage.Filter{
+		Types:       params.Type,
+		From:        parseDate(params.From, false),
+		To:          parseDate(params.To, true),
+		OptaMatchId: params.OptaMatchId,
+		HasVideo:    parseBool(params.HasVideo),
+		HasGallery:  parseBool(params.HasGallery),
+		TeamId:      params.TeamId,
+	}
+
+	if f.From != nil && f.To != nil && !f.To.After(*f.From) {
+		return f, ErrBadRequest.WithReasons([]ErrorReason{{
+			Field: "to",
+			Error: "to must be after from",
+			Value: params.To,
+			Type:  "gtfield",
+		}})
+	}
+
+	return f, nil
+}
+
+// validationError turns validation errors into a bad request error listing the reason of every invalid field
+func (a *API) validationError(err error) error {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return ErrBadRequest
+	}
+
+	reasons := make([]ErrorReason, len(fieldErrors))
+	for i, fe := range fieldErrors {
+		reasons[i] = ErrorReason{
+			Field: fe.Field(),
+			Error: fe.Translate(a.validate.Translator),
+			Value: fe.Value(),
+			Type:  fe.Tag(),
+		}
+	}
+
+	return ErrBadRequest.WithReasons(reasons)
+}
+
+// parseDate parses a validated date param. A day given as the end of a range is moved to the
+// start of the next day so that the whole day is included
+func parseDate(s string, end bool) *time.Time {
+	if s == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if t, err = time.Parse(dateLayout, s); err != nil {
+			return nil
+		}
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+
+	return &t
+}
+
+// parseBool parses a validated boolean param
+func parseBool(s string) *bool {
+	if s == "" {
+		return nil
+	}
+
+	b, _ := strconv.ParseBool(s)
+	return &b
+}
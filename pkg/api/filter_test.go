@@ -0,0 +1,122 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPI_FilterArticles(t *testing.T) {
+	from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 7, 4, 0, 0, 0, 0, time.UTC)
+	yes, no := true, false
+
+	testCases := []struct {
+		description     string
+		query           string
+		expectedFilter  *storage.Filter
+		expectedStatus  int
+		expectedReasons []string
+	}{
+		{
+			description: "should filter the articles",
+			query: "?type=Club+News,Players&type=Community&from=2022-07-01&to=2022-07-03" +
+				"&optaMatchId=g2300001&hasVideo=true&hasGallery=false&teamId=t94",
+			expectedFilter: &storage.Filter{
+				Types:       []string{"Club News", "Players", "Community"},
+				From:        &from,
+				To:          &to,
+				OptaMatchId: "g2300001",
+				HasVideo:    &yes,
+				HasGallery:  &no,
+				TeamId:      "t94",
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "should accept date times",
+			query:          "?from=2022-07-01T00:00:00Z",
+			expectedFilter: &storage.Filter{From: &from},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:     "should respond with 400 and the reason of every invalid filter",
+			query:           "?from=yesterday&hasVideo=maybe&teamId=t-94&type=",
+			expectedStatus:  http.StatusBadRequest,
+			expectedReasons: []string{"type[0]", "from", "hasVideo", "teamId"},
+		},
+		{
+			description:     "should respond with 400 when the date range is empty",
+			query:           "?from=2022-07-04&to=2022-07-01",
+			expectedStatus:  http.StatusBadRequest,
+			expectedReasons: []string{"to"},
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			if tc.expectedFilter != nil {
+				dbrepo.EXPECT().
+					GetNewsPage(gomock.Any(), storage.PageQuery{Limit: 20, Filter: *tc.expectedFilter}).
+					Return(storage.Page{}, nil)
+			}
+
+			router := api.NewRouter(api.NewAPI(responder, v, dbrepo, cfg, log), metrics.New(), log)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/articles"+tc.query, nil))
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			if tc.expectedReasons == nil {
+				return
+			}
+
+			var resp api.ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(resp.Reason) != len(tc.expectedReasons) {
+				t.Fatalf("expected %d error reasons, got %+v", len(tc.expectedReasons), resp.Reason)
+			}
+
+			for i, field := range tc.expectedReasons {
+				if resp.Reason[i].Field != field || resp.Reason[i].Error == "" {
+					t.Fatalf("expected an error reason for %s, got %+v", field, resp.Reason[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Format of the responses, negotiated for every request
+type Format string
+
+// Formats
+const (
+	FormatJSON   Format = "json"
+	FormatXML    Format = "xml"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// mediaTypes maps the media types of the Accept header to their format, in order of preference
+var mediaTypes = []struct {
+	mediaType string
+	format    Format
+}{
+	{"application/json", FormatJSON},
+	{"application/xml", FormatXML},
+	{"text/xml", FormatXML},
+	{"text/csv", FormatCSV},
+	{"application/x-ndjson", FormatNDJSON},
+	{"application/ndjson", FormatNDJSON},
+}
+
+// Streams reports whether lists are exported in the format by streaming every item instead of paginating them
+func (f Format) Streams() bool {
+	return f == FormatCSV || f == FormatNDJSON
+}
+
+type formatKey struct{}
+
+// FormatFromContext returns the format negotiated for the request of the context, JSON when none was
+func FormatFromContext(ctx context.Context) Format {
+	if f, ok := ctx.Value(formatKey{}).(Format); ok {
+		return f
+	}
+	return FormatJSON
+}
+
+// Negotiate picks the format of the response to a request, by its format query param or else its Accept header,
+// and responds with 406 to requests accepting none of the supported formats
+func (a *API) Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		f, ok := negotiate(r)
+		if !ok {
+			if err := a.RespondError(r.Context(), w, ErrNotAcceptable); err != nil {
+				a.log.WithContext(r.Context()).WithError(err).Error("failed to respond")
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), formatKey{}, f)))
+	})
+}
+
+// negotiate returns the format of the format query param, or else the preferred format of the Accept header
+func negotiate(r *http.Request) (Format, bool) {
+	if f := r.URL.Query().Get("format"); f != "" {
+		for _, mt := range mediaTypes {
+			if mt.format == Format(f) {
+				return mt.format, true
+			}
+		}
+		return "", false
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return FormatJSON, true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mr := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+		if mr.q > 0 {
+			ranges = append(ranges, mr)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, mr := range ranges {
+		for _, mt := range mediaTypes {
+			if mediaTypeMatch(mr.mediaType, mt.mediaType) {
+				return mt.format, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// mediaTypeMatch reports whether the media range of an Accept header, e.g. text/*, matches the media type
+func mediaTypeMatch(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	return strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+}
+
+// Negotiator is a Responder answering in the format negotiated for the request by API.Negotiate
+type Negotiator struct {
+	responders map[Format]Responder
+}
+
+// NewNegotiator creates a new Negotiator responding in every supported format
+func NewNegotiator(sn string, tr ut.Translator) Responder {
+	return &Negotiator{
+		responders: map[Format]Responder{
+			FormatJSON:   NewJSONResponder(sn, tr),
+			FormatXML:    NewXMLResponder(sn),
+			FormatCSV:    NewCSVResponder(sn),
+			FormatNDJSON: NewNDJSONResponder(sn),
+		},
+	}
+}
+
+func (n *Negotiator) responder(ctx context.Context) Responder {
+	if r, ok := n.responders[FormatFromContext(ctx)]; ok {
+		return r
+	}
+	return n.responders[FormatJSON]
+}
+
+// Respond serves data in the negotiated format
+func (n *Negotiator) Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) error {
+	return n.responder(ctx).Respond(ctx, w, data, statusCode)
+}
+
+// RespondError serves the error in the negotiated format
+func (n *Negotiator) RespondError(ctx context.Context, w http.ResponseWriter, err error) error {
+	return n.responder(ctx).RespondError(ctx, w, err)
+}
+
+// RespondConditional serves data in the negotiated format, unless it was not modified
+func (n *Negotiator) RespondConditional(ctx context.Context, w http.ResponseWriter, r *http.Request, data interface{}, v Validators) error {
+	return n.responder(ctx).RespondConditional(ctx, w, r, data, v)
+}
+
+// RespondStream serves the items of a list in the negotiated format
+func (n *Negotiator) RespondStream(ctx context.Context, w http.ResponseWriter, zero interface{}, stream Stream) error {
+	return n.responder(ctx).RespondStream(ctx, w, zero, stream)
+}
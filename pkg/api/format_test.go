@@ -0,0 +1,260 @@
+package api_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPI_Formats(t *testing.T) {
+	results := []storage.Result{
+		{ArticleID: "645168", ContentHash: "a1", Data: news.Data{Id: "645168", Title: "First", Type: []string{"Club News", "Players"}}},
+		{ArticleID: "645169", ContentHash: "b2", Data: news.Data{Id: "645169", Title: "Second"}},
+	}
+
+	testCases := []struct {
+		description         string
+		path                string
+		accept              string
+		streamErr           error
+		expectedStatus      int
+		expectedContentType string
+		check               func(t *testing.T, body string)
+	}{
+		{
+			description:         "should respond with JSON by default",
+			path:                "/v1/article/645168",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			check: func(t *testing.T, body string) {
+				var resp api.Response
+				if err := json.Unmarshal([]byte(body), &resp); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			description:         "should respond with XML to requests accepting it",
+			path:                "/v1/article/645168",
+			accept:              "text/html;q=0.9, application/xml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml; charset=utf-8",
+			check: func(t *testing.T, body string) {
+				var resp struct {
+					XMLName xml.Name `xml:"Response"`
+					Status  string   `xml:"Status"`
+					Data    struct {
+						Id    string   `xml:"Id"`
+						Title string   `xml:"Title"`
+						Types []string `xml:"Type>Item"`
+					} `xml:"Data"`
+				}
+				if err := xml.Unmarshal([]byte(body), &resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Status != "success" || resp.Data.Id != "645168" || resp.Data.Title != "First" || len(resp.Data.Types) != 2 {
+					t.Fatalf("unexpected XML response: %s", body)
+				}
+			},
+		},
+		{
+			description:         "should prefer the format query param over the Accept header",
+			path:                "/v1/article/645168?format=xml",
+			accept:              "application/json",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml; charset=utf-8",
+		},
+		{
+			description:         "should respond with errors in the negotiated format",
+			path:                "/v1/article/unknown?format=xml",
+			expectedStatus:      http.StatusNotFound,
+			expectedContentType: "application/xml; charset=utf-8",
+			check: func(t *testing.T, body string) {
+				var resp struct {
+					XMLName xml.Name `xml:"ErrorResponse"`
+					Type    string   `xml:"Type"`
+				}
+				if err := xml.Unmarshal([]byte(body), &resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Type != "errNotFound" {
+					t.Fatalf("unexpected XML error: %s", body)
+				}
+			},
+		},
+		{
+			description:         "should stream every article as CSV",
+			path:                "/v1/articles",
+			accept:              "text/csv",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			check: func(t *testing.T, body string) {
+				records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(records) != 3 || records[0][0] != "id" || records[1][0] != "645168" || records[2][0] != "645169" {
+					t.Fatalf("unexpected CSV response: %s", body)
+				}
+				for i, column := range records[0] {
+					if column == "type" && records[1][i] != "Club News|Players" {
+						t.Fatalf("expected the types to be joined, got %q", records[1][i])
+					}
+				}
+			},
+		},
+		{
+			description:         "should stream every article as NDJSON",
+			path:                "/v1/articles",
+			accept:              "application/x-ndjson",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			check: func(t *testing.T, body string) {
+				lines := strings.Split(strings.TrimSpace(body), "\n")
+				if len(lines) != 2 {
+					t.Fatalf("expected a line per article, got %q", body)
+				}
+				var res api.ArticleResponse
+				if err := json.Unmarshal([]byte(lines[1]), &res); err != nil || res.Id != "645169" {
+					t.Fatalf("unexpected NDJSON line %q: %v", lines[1], err)
+				}
+			},
+		},
+		{
+			description:         "should respond with an error when a stream fails before any article",
+			path:                "/v1/articles?format=ndjson",
+			streamErr:           storage.ErrUnavailable,
+			expectedStatus:      http.StatusServiceUnavailable,
+			expectedContentType: "application/x-ndjson",
+			check: func(t *testing.T, body string) {
+				var resp api.ErrorResponse
+				if err := json.Unmarshal([]byte(body), &resp); err != nil || resp.Type == "" {
+					t.Fatalf("unexpected NDJSON error %q: %v", body, err)
+				}
+			},
+		},
+		{
+			description:         "should respond with 406 to requests accepting no supported format",
+			path:                "/v1/articles",
+			accept:              "text/html, application/json;q=0",
+			expectedStatus:      http.StatusNotAcceptable,
+			expectedContentType: "application/json",
+		},
+		{
+			description:         "should respond with 406 to an unknown format query param",
+			path:                "/v1/articles?format=yaml",
+			expectedStatus:      http.StatusNotAcceptable,
+			expectedContentType: "application/json",
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewNegotiator(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			dbrepo.EXPECT().GetArticleByID(gomock.Any(), gomock.Any(), "645168").Return(results[0], nil).AnyTimes()
+			dbrepo.EXPECT().GetArticleByID(gomock.Any(), gomock.Any(), "unknown").Return(storage.Result{}, storage.ErrNotFound).AnyTimes()
+			dbrepo.EXPECT().
+				StreamNews(gomock.Any(), storage.PageQuery{}, gomock.Any()).
+				DoAndReturn(func(_, _ interface{}, fn func(storage.Result) error) error {
+					if tc.streamErr != nil {
+						return tc.streamErr
+					}
+					for _, res := range results {
+						if err := fn(res); err != nil {
+							return err
+						}
+					}
+					return nil
+				}).
+				AnyTimes()
+
+			router := api.NewRouter(api.NewAPI(responder, v, dbrepo, cfg, log), metrics.New(), log)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body)
+			}
+
+			if ct := recorder.Header().Get("Content-Type"); ct != tc.expectedContentType {
+				t.Fatalf("expected content type %q, got %q", tc.expectedContentType, ct)
+			}
+
+			if tc.check != nil {
+				tc.check(t, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPI_FormatsStreamFailure(t *testing.T) {
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	dbrepo := storage.NewMockDBRepo(ctrl)
+	dbrepo.EXPECT().
+		StreamNews(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_, _ interface{}, fn func(storage.Result) error) error {
+			if err := fn(storage.Result{Data: news.Data{Id: "645168"}}); err != nil {
+				return err
+			}
+			return errors.New("cursor killed")
+		})
+
+	a := api.NewAPI(api.NewNegotiator(cfg.APP.Name, v.Translator), v, dbrepo, cfg, log)
+
+	recorder := httptest.NewRecorder()
+	api.NewRouter(a, metrics.New(), log).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/articles?format=csv", nil))
+
+	// The stream already started, so its failure can't change the response
+	if recorder.Code != http.StatusOK || strings.Contains(recorder.Body.String(), "cursor killed") {
+		t.Fatalf("expected the started stream to be left as is, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/health"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPI_Readyz(t *testing.T) {
+	testCases := []struct {
+		description    string
+		pingErr        error
+		expectedStatus int
+		expectedHealth health.Status
+	}{
+		{
+			description:    "should respond with 200 when the storage is up",
+			expectedStatus: http.StatusOK,
+			expectedHealth: health.StatusUp,
+		},
+		{
+			description:    "should respond with 503 when the storage is down",
+			pingErr:        errors.New("connection refused"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedHealth: health.StatusDown,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			syncStatus := storage.NewMockSyncStatusRepo(ctrl)
+			now := time.Now()
+			syncStatus.EXPECT().GetSyncStatus(gomock.Any()).Return(storage.SyncStatus{
+				LastSyncAt:    now,
+				LastSuccessAt: now,
+				Feeds:         map[string]string{"brentford": "closed"},
+			}, nil).Times(2)
+
+			checker := health.NewChecker(time.Second)
+			checker.Register("storage", true, health.Storage(func(context.Context) error { return tc.pingErr }))
+			checker.Register("sync", false, health.Sync(syncStatus, time.Minute))
+			checker.Register("feeds", false, health.Feeds(syncStatus))
+
+			a := api.NewAPI(responder, v, storage.NewMockDBRepo(ctrl), cfg, log, api.WithHealth(checker))
+
+			recorder := httptest.NewRecorder()
+			api.NewRouter(a, metrics.New(), log).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			var report health.Report
+			if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Status != tc.expectedHealth || len(report.Components) != 3 || report.Components["feeds"].Status != health.StatusUp {
+				t.Fatalf("unexpected report: %+v", report)
+			}
+		})
+	}
+}
+
+func TestAPI_Livez(t *testing.T) {
+	testCases := []struct {
+		description string
+		path        string
+	}{
+		{
+			description: "should respond with 200 on /livez",
+			path:        "/livez",
+		},
+		{
+			description: "should respond with 200 on the /health alias",
+			path:        "/health",
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			a := api.NewAPI(responder, v, storage.NewMockDBRepo(ctrl), cfg, log)
+
+			recorder := httptest.NewRecorder()
+			api.NewRouter(a, metrics.New(), log).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected to get status %d, got %d", http.StatusOK, recorder.Code)
+			}
+
+			var report health.Report
+			if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Status != health.StatusUp {
+				t.Fatalf("unexpected report: %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/logger"
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries the id of a request, both in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern matches the request ids propagated from callers, others are replaced so that they can't
+// inject anything in logs or response headers
+var requestIDPattern = regexp.MustCompile(`^[0-9A-Za-z._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// RequestID propagates the X-Request-ID header of a request, or assigns a new uuid when it's missing or invalid.
+// The id is echoed in the response headers and available to handlers through RequestIDFromContext
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext returns the id of the request of the context, set by RequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns a random uuid (v4)
+func newRequestID() string {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// AccessLog logs a single ECS access log entry per request, with the status code and the size of its response.
+// Server errors are logged at the error level, other responses at the info level
+func AccessLog(l *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			// a handler that writes nothing responds with 200
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			entry := l.WithContext(r.Context()).WithFields(logrus.Fields{
+				"event.kind":                "event",
+				"event.category":            "web",
+				"event.duration":            time.Since(start).Nanoseconds(),
+				"http.request.id":           RequestIDFromContext(r.Context()),
+				"http.request.method":       r.Method,
+				"http.response.status_code": status,
+				"http.response.body.bytes":  ww.BytesWritten(),
+				"url.path":                  r.URL.Path,
+				"url.query":                 r.URL.RawQuery,
+				"client.address":            r.RemoteAddr,
+				"user_agent.original":       r.UserAgent(),
+				"http.version":              fmt.Sprintf("%d.%d", r.ProtoMajor, r.ProtoMinor),
+			})
+
+			msg := fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, status)
+			if status >= http.StatusInternalServerError {
+				entry.Error(msg)
+				return
+			}
+			entry.Info(msg)
+		})
+	}
+}
+
+// AdminAuth responds with 401 to requests whose Authorization header isn't the bearer admin token. Every request
+// is rejected when no admin token is configured
+func (a *API) AdminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := a.cfg.Webhooks.AdminToken
+		given := r.Header.Get("Authorization")
+
+		if token == "" || !strings.HasPrefix(given, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(given, "Bearer ")), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			if err := a.RespondError(r.Context(), w, ErrUnauthorized); err != nil {
+				a.log.WithContext(r.Context()).WithError(err).Error("failed to respond")
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
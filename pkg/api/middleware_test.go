@@ -0,0 +1,201 @@
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRouter_RequestIDAndAccessLog(t *testing.T) {
+	testCases := []struct {
+		description       string
+		requestID         string
+		expectedRequestID string
+	}{
+		{
+			description:       "should propagate the request id of the caller",
+			requestID:         "d4f1c2a0-7e7b-4c55-9d0e-8b6f3ad1e2c4",
+			expectedRequestID: "d4f1c2a0-7e7b-4c55-9d0e-8b6f3ad1e2c4",
+		},
+		{
+			description: "should assign a request id when missing",
+		},
+		{
+			description: "should replace an invalid request id",
+			requestID:   "id\n{\"injected\":true}",
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var logs bytes.Buffer
+			log := logger.NewLogger(cfg.Logger)
+			log.Logger.SetOutput(&logs)
+
+			ctrl := gomock.NewController(t)
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			dbrepo.EXPECT().
+				GetArticleByID(gomock.Any(), "", "645168").
+				Return(storage.Result{}, storage.NewError(storage.ErrNotFound, "getArticleByID", nil))
+
+			router := api.NewRouter(api.NewAPI(responder, v, dbrepo, cfg, log), metrics.New(), log)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/article/645168", nil)
+			if tc.requestID != "" {
+				req.Header.Set(api.RequestIDHeader, tc.requestID)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			requestID := recorder.Header().Get(api.RequestIDHeader)
+			if tc.expectedRequestID != "" && requestID != tc.expectedRequestID {
+				t.Fatalf("expected request id %s, got %s", tc.expectedRequestID, requestID)
+			}
+			if requestID == "" || requestID == tc.requestID && tc.expectedRequestID == "" {
+				t.Fatalf("expected a new request id, got %q", requestID)
+			}
+
+			var resp api.ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.RequestID != requestID {
+				t.Fatalf("expected the error response to echo request id %s, got %s", requestID, resp.RequestID)
+			}
+
+			// the error log is followed by the access log
+			var entries []map[string]interface{}
+			scanner := bufio.NewScanner(&logs)
+			for scanner.Scan() {
+				var entry map[string]interface{}
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					t.Fatal(err)
+				}
+				entries = append(entries, entry)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("expected an error log and an access log, got %d entries", len(entries))
+			}
+
+			for _, entry := range entries {
+				if entry["http.request.id"] != requestID || entry["http.response.status_code"] != float64(http.StatusNotFound) {
+					t.Fatalf("unexpected log entry: %v", entry)
+				}
+			}
+
+			access := entries[1]
+			if access["url.path"] != "/v1/article/645168" || access["http.response.body.bytes"] != float64(recorder.Body.Len()) {
+				t.Fatalf("unexpected access log: %v", access)
+			}
+		})
+	}
+}
+
+func TestAPI_AdminAuth(t *testing.T) {
+	testCases := []struct {
+		description    string
+		adminToken     string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			description:    "should let the requests with the admin token through",
+			adminToken:     "admin-token",
+			authorization:  "Bearer admin-token",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "should respond with 401 without a token",
+			adminToken:     "admin-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description:    "should respond with 401 for another token",
+			adminToken:     "admin-token",
+			authorization:  "Bearer other-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description:    "should respond with 401 for a token that isn't a bearer token",
+			adminToken:     "admin-token",
+			authorization:  "admin-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			description:    "should respond with 401 when no admin token is configured",
+			authorization:  "Bearer ",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			c := *cfg
+			c.Webhooks.AdminToken = tc.adminToken
+
+			ctrl := gomock.NewController(t)
+			webhooks := storage.NewMockWebhookRepo(ctrl)
+			if tc.expectedStatus == http.StatusOK {
+				webhooks.EXPECT().GetWebhooks(gomock.Any()).Return(nil, nil)
+			}
+
+			a := api.NewAPI(responder, v, storage.NewMockDBRepo(ctrl), &c, log, api.WithWebhooks(webhooks))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/webhooks", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+
+			recorder := httptest.NewRecorder()
+			api.NewRouter(a, metrics.New(), log).ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body)
+			}
+
+			if tc.expectedStatus == http.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Fatalf("expected a bearer challenge, got %q", recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
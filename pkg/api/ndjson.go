@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"com.thanos/pkg/tracing"
+)
+
+// NDJSONResponder serves responses as newline delimited JSON, a line per item of the data of a Response,
+// or a single line for any other response
+type NDJSONResponder struct {
+	serviceName string
+}
+
+// NewNDJSONResponder creates a new NDJSONResponder
+func NewNDJSONResponder(sn string) Responder {
+	return &NDJSONResponder{
+		serviceName: sn,
+	}
+}
+
+// Respond converts a Go value to NDJSON and serves it as a response back to the caller
+func (r *NDJSONResponder) Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) (err error) {
+	_, span := tracing.Start(ctx, "encode")
+	defer func() { tracing.End(span, err) }()
+
+	if resp, ok := data.(Response); ok {
+		data = resp.Data
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+	}
+
+	enc := json.NewEncoder(w)
+
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return enc.Encode(data)
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err = enc.Encode(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RespondConditional serves data like Respond, unless it was not modified
+func (r *NDJSONResponder) RespondConditional(ctx context.Context, w http.ResponseWriter, req *http.Request, data interface{}, v Validators) error {
+	if respondNotModified(w, req, v) {
+		return nil
+	}
+
+	return r.Respond(ctx, w, data, http.StatusOK)
+}
+
+// RespondStream writes a line for every item as it is yielded
+func (r *NDJSONResponder) RespondStream(ctx context.Context, w http.ResponseWriter, _ interface{}, stream Stream) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	lines := 0
+	err := stream(func(item interface{}) error {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+
+		lines++
+		if lines%flushEvery == 0 && flusher != nil {
+			flusher.Flush()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if lines == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return nil
+}
+
+// RespondError serves the error as a single line
+func (r *NDJSONResponder) RespondError(ctx context.Context, w http.ResponseWriter, err error) error {
+	errResp, statusCode := errorResponse(ctx, r.serviceName, err)
+
+	return r.Respond(ctx, w, errResp, statusCode)
+}
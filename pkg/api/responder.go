@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"com.thanos/pkg/tracing"
 	ut "github.com/go-playground/universal-translator"
 )
 
@@ -12,8 +13,15 @@ import (
 type Responder interface {
 	Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) error
 	RespondError(ctx context.Context, w http.ResponseWriter, err error) error
+	RespondConditional(ctx context.Context, w http.ResponseWriter, r *http.Request, data interface{}, v Validators) error
+	// RespondStream serves the items of a list as they are yielded by stream. zero is a zero value of the type
+	// of the items, it describes the list when it's empty
+	RespondStream(ctx context.Context, w http.ResponseWriter, zero interface{}, stream Stream) error
 }
 
+// Stream yields the items of a list one by one, it stops at the first error of yield
+type Stream func(yield func(item interface{}) error) error
+
 type JSONResponder struct {
 	serviceName string
 	translator  ut.Translator
@@ -28,7 +36,11 @@ func NewJSONResponder(sn string, tr ut.Translator) Responder {
 }
 
 // Respond converts a Go value to JSON and serves it as a response back to the caller
-func (r *JSONResponder) Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) error {
+func (r *JSONResponder) Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) (err error) {
+	_, span := tracing.Start(ctx, "encode")
+	defer func() { tracing.End(span, err) }()
+
+	w.Header().Set("Content-Type", "application/json")
 	if statusCode != http.StatusOK {
 		w.WriteHeader(statusCode)
 	}
@@ -36,21 +48,84 @@ func (r *JSONResponder) Respond(ctx context.Context, w http.ResponseWriter, data
 	return json.NewEncoder(w).Encode(&data)
 }
 
+// RespondConditional serves data like Respond with its validators set as the ETag and Last-Modified headers.
+// Requests whose conditional headers match the validators get an empty 304 response instead
+func (r *JSONResponder) RespondConditional(ctx context.Context, w http.ResponseWriter, req *http.Request, data interface{}, v Validators) error {
+	if respondNotModified(w, req, v) {
+		return nil
+	}
+
+	return r.Respond(ctx, w, data, http.StatusOK)
+}
+
+// RespondStream serves the items like a single page of a list, they are collected first
+func (r *JSONResponder) RespondStream(ctx context.Context, w http.ResponseWriter, _ interface{}, stream Stream) error {
+	items, err := collect(stream)
+	if err != nil {
+		return err
+	}
+
+	return r.Respond(ctx, w, Response{Status: "success", Data: items}, http.StatusOK)
+}
+
 // RespondError creates an error struct and serves it as a response back to the client. Extra data can be passed for more detailed logging of errors
 func (r *JSONResponder) RespondError(ctx context.Context, w http.ResponseWriter, err error) error {
+	errResp, statusCode := errorResponse(ctx, r.serviceName, err)
+
+	return r.Respond(ctx, w, errResp, statusCode)
+}
+
+// errorResponse returns the body and status code of the response to an error, the same in every format
+func errorResponse(ctx context.Context, serviceName string, err error) (ErrorResponse, int) {
 	statusCode := http.StatusInternalServerError
 	errResp := ErrorResponse{
-		Message: err.Error(),
-		Details: err.Error(),
-		Service: r.serviceName,
+		Message:   err.Error(),
+		Details:   err.Error(),
+		Service:   serviceName,
+		RequestID: RequestIDFromContext(ctx),
 	}
 
 	if err, ok := err.(Error); ok {
 		errResp.Message = err.Error()
 		errResp.Details = err.Error()
 		errResp.Type = err.Code
+		errResp.Reason = err.reasons
 		statusCode = err.statusCode
 	}
 
-	return r.Respond(ctx, w, errResp, statusCode)
+	return errResp, statusCode
+}
+
+// respondNotModified sets the validators as the ETag and Last-Modified headers. When the conditional headers
+// of the request match them it responds with an empty 304 and reports true. The ETag of every format but JSON
+// is suffixed with the format, since representations of different formats are never equivalent
+func respondNotModified(w http.ResponseWriter, r *http.Request, v Validators) bool {
+	if f := FormatFromContext(r.Context()); f != FormatJSON && v.ETag != "" {
+		v.ETag += "-" + string(f)
+	}
+
+	if v.ETag != "" {
+		w.Header().Set("ETag", formatETag(v.ETag))
+	}
+	if !v.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", v.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if !notModified(r, v) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// collect returns every item yielded by stream
+func collect(stream Stream) ([]interface{}, error) {
+	items := []interface{}{}
+	err := stream(func(item interface{}) error {
+		items = append(items, item)
+		return nil
+	})
+
+	return items, err
 }
@@ -1,5 +1,11 @@
 package api
 
+import (
+	"time"
+
+	"com.thanos/pkg/news"
+)
+
 type Response struct {
 	Status   string      `json:"status"`
 	Data     interface{} `json:"data"`
@@ -8,7 +14,61 @@ type Response struct {
 
 // VersionResponse used by version handler
 type VersionResponse struct {
-	Version string `json:"version" example:"12345`
+	Version string `json:"version" example:"12345"`
+}
+
+// ClubResponse used by clubs handler
+type ClubResponse struct {
+	Name   string `json:"name"`
+	TeamId string `json:"teamId"`
+}
+
+// WebhookResponse used by the webhook handlers. The secret is only shown once, in the response to the creation
+type WebhookResponse struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Clubs     []string `json:"clubs"`
+	Secret    string   `json:"secret,omitempty"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// DeliveryResponse used by the webhook deliveries handler
+type DeliveryResponse struct {
+	ID             string `json:"id"`
+	EventType      string `json:"eventType"`
+	ArticleID      string `json:"articleId"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	CreatedAt      string `json:"createdAt"`
+	NextAttemptAt  string `json:"nextAttemptAt,omitempty"`
+	ResponseStatus int    `json:"responseStatus,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// RevisionResponse used by the article revisions handler
+type RevisionResponse struct {
+	Revision       int      `json:"revision"`
+	CreatedAt      string   `json:"createdAt"`
+	LastUpdateDate string   `json:"lastUpdateDate"`
+	ContentHash    string   `json:"contentHash"`
+	ChangedFields  []string `json:"changedFields"`
+}
+
+// RevisionDiffResponse used by the article revision diff handler
+type RevisionDiffResponse struct {
+	ArticleId        string        `json:"articleId"`
+	Revision         int           `json:"revision"`
+	PreviousRevision int           `json:"previousRevision,omitempty"`
+	Changes          []news.Change `json:"changes"`
+}
+
+// SearchResponse used by the article search handler
+type SearchResponse struct {
+	Data       ArticleResponse   `json:"data"`
+	Withdrawn  *news.Withdrawal  `json:"withdrawn,omitempty"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
 }
 
 // ErrorResponse general error response
@@ -18,6 +78,8 @@ type ErrorResponse struct {
 	Service string        `json:"service"`
 	Type    string        `json:"type"`
 	Reason  []ErrorReason `json:"reason"`
+	// RequestID is the X-Request-ID of the failed request
+	RequestID string `json:"requestId,omitempty"`
 }
 
 // ErrorReason used by ErrorResponse to map specific error reasons
@@ -27,3 +89,51 @@ type ErrorReason struct {
 	Value interface{} `json:"value"`
 	Type  string      `json:"type"`
 }
+
+// ArticleResponse is the representation of a news article served by the api
+type ArticleResponse struct {
+	Id          string      `json:"id"`
+	TeamId      string      `json:"teamId"`
+	OptaMatchId interface{} `json:"optaMatchId"`
+	Title       string      `json:"title"`
+	Type        []string    `json:"type"`
+	Teaser      interface{} `json:"teaser"`
+	Content     string      `json:"content"`
+	Url         string      `json:"url"`
+	ImageUrl    string      `json:"imageUrl"`
+	GalleryUrls interface{} `json:"galleryUrls"`
+	VideoUrl    interface{} `json:"videoUrl"`
+	Published   string      `json:"published"`
+}
+
+// NewArticleResponse maps stored article data to its response representation.
+// Empty optional values are returned as null and the publish date as ISO8601
+func NewArticleResponse(d news.Data) ArticleResponse {
+	ar := ArticleResponse{
+		Id:          d.Id,
+		TeamId:      d.TeamId,
+		OptaMatchId: nullable(d.OptaMatchId),
+		Title:       d.Title,
+		Type:        d.Type,
+		Teaser:      nullable(d.Teaser),
+		Content:     d.Content,
+		Url:         d.Url,
+		ImageUrl:    d.ImageUrl,
+		GalleryUrls: d.GalleryUrls,
+		VideoUrl:    nullable(d.VideoUrl),
+		Published:   d.Published,
+	}
+
+	if published, err := time.Parse(news.DateTimeFormat, d.Published); err == nil {
+		ar.Published = published.UTC().Format(ISO8601)
+	}
+
+	return ar
+}
+
+func nullable(v interface{}) interface{} {
+	if s, ok := v.(string); ok && s == "" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"github.com/go-chi/chi"
+)
+
+// GetArticleRevisions lists the revisions of an article, oldest first
+func (a *API) GetArticleRevisions(w http.ResponseWriter, r *http.Request) error {
+	revisions, err := a.articleRevisions(r)
+	if err != nil {
+		return err
+	}
+
+	resp := make([]RevisionResponse, len(revisions))
+	for i, rev := range revisions {
+		var previous news.Data
+		if i > 0 {
+			previous = revisions[i-1].Data
+		}
+
+		changes := news.Diff(previous, rev.Data)
+		changedFields := make([]string, len(changes))
+		for j := range changes {
+			changedFields[j] = changes[j].Field
+		}
+
+		resp[i] = RevisionResponse{
+			Revision:       rev.Revision,
+			CreatedAt:      rev.CreatedAt.UTC().Format(ISO8601),
+			LastUpdateDate: rev.LastUpdateDate,
+			ContentHash:    rev.ContentHash,
+			ChangedFields:  changedFields,
+		}
+	}
+
+	return a.Respond(
+		r.Context(),
+		w,
+		Response{
+			Status: "success",
+			Data:   resp,
+			Metadata: news.Metadata{
+				CreatedAt:  time.Now().UTC().Format(ISO8601),
+				Sort:       "revision",
+				TotalItems: len(resp),
+			},
+		},
+		http.StatusOK,
+	)
+}
+
+// GetArticleRevisionDiff returns the changes a revision made to the previous revision of an article.
+// The first revision is diffed against an empty article
+func (a *API) GetArticleRevisionDiff(w http.ResponseWriter, r *http.Request) error {
+	rev, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil || rev < 1 {
+		return ErrBadRequest
+	}
+
+	revisions, err := a.articleRevisions(r)
+	if err != nil {
+		return err
+	}
+
+	var previous, current *storage.Revision
+	for i := range revisions {
+		switch revisions[i].Revision {
+		case rev - 1:
+			previous = &revisions[i]
+		case rev:
+			current = &revisions[i]
+		}
+	}
+
+	if current == nil {
+		return ErrNotFound
+	}
+
+	diff := RevisionDiffResponse{
+		ArticleId: current.ArticleID,
+		Revision:  current.Revision,
+	}
+
+	var previousData news.Data
+	if previous != nil {
+		previousData = previous.Data
+		diff.PreviousRevision = previous.Revision
+	}
+	diff.Changes = news.Diff(previousData, current.Data)
+
+	return a.Respond(
+		r.Context(),
+		w,
+		Response{
+			Status: "success",
+			Data:   diff,
+			Metadata: news.Metadata{
+				CreatedAt: time.Now().UTC().Format(ISO8601),
+			},
+		},
+		http.StatusOK,
+	)
+}
+
+// articleRevisions returns the revisions of the article of the request, responding with
+// ErrNotFound when the article has none
+func (a *API) articleRevisions(r *http.Request) ([]storage.Revision, error) {
+	club, err := a.club(r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := chi.URLParam(r, "id")
+	if !articleIDPattern.MatchString(id) {
+		return nil, ErrBadRequest
+	}
+
+	revisions, err := a.revisions.GetRevisions(r.Context(), club, id)
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	if len(revisions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return revisions, nil
+}
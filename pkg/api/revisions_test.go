@@ -0,0 +1,117 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPI_GetArticleRevisionDiff(t *testing.T) {
+	revisions := []storage.Revision{
+		{
+			ArticleID: "645150",
+			Revision:  1,
+			Data:      news.Data{Id: "645150", Title: "Club supports fans", Content: "Bees fans will be joining in."},
+		},
+		{
+			ArticleID: "645150",
+			Revision:  2,
+			Data:      news.Data{Id: "645150", Title: "Club supports fans", Content: "Bees fans will not be joining in."},
+		},
+	}
+
+	testCases := []struct {
+		description     string
+		path            string
+		expectedStatus  int
+		expectedChanges []string
+	}{
+		{
+			description:     "should diff a revision against the previous one",
+			path:            "/v1/article/645150/revisions/2/diff",
+			expectedStatus:  http.StatusOK,
+			expectedChanges: []string{"content"},
+		},
+		{
+			description:     "should diff the first revision against an empty article",
+			path:            "/v1/article/645150/revisions/1/diff",
+			expectedStatus:  http.StatusOK,
+			expectedChanges: []string{"title", "content"},
+		},
+		{
+			description:    "should respond with 404 for an unknown revision",
+			path:           "/v1/article/645150/revisions/3/diff",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description:    "should respond with 400 for an invalid revision",
+			path:           "/v1/article/645150/revisions/first/diff",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			revisionRepo := storage.NewMockRevisionRepo(ctrl)
+			revisionRepo.EXPECT().GetRevisions(gomock.Any(), "", "645150").Return(revisions, nil).AnyTimes()
+
+			a := api.NewAPI(responder, v, storage.NewMockDBRepo(ctrl), cfg, log, api.WithRevisions(revisionRepo))
+
+			recorder := httptest.NewRecorder()
+			api.NewRouter(a, metrics.New(), log).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data api.RevisionDiffResponse `json:"data"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(resp.Data.Changes) != len(tc.expectedChanges) {
+				t.Fatalf("expected changes of %v, got %+v", tc.expectedChanges, resp.Data.Changes)
+			}
+
+			for i, field := range tc.expectedChanges {
+				if resp.Data.Changes[i].Field != field {
+					t.Fatalf("expected changes of %v, got %+v", tc.expectedChanges, resp.Data.Changes)
+				}
+			}
+		})
+	}
+}
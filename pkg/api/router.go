@@ -2,6 +2,7 @@ package api
 
 import (
 	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
 	"github.com/go-chi/chi"
 )
 
@@ -9,17 +10,75 @@ type Router struct {
 	*chi.Mux
 }
 
-// NewRouter returns a new handler with all registered routes
-func NewRouter(api *API, log *logger.Logger) *Router {
+// NewRouter returns a new handler with all registered routes. Every request is assigned a request id,
+// access logged and observed by m, and responded to in its negotiated format
+func NewRouter(api *API, m *metrics.Metrics, log *logger.Logger) *Router {
 	rt := Router{Mux: chi.NewRouter()}
+	rt.Use(RequestID, AccessLog(log), m.HTTP.Middleware)
 
-	rt.Route("/v1", func(r chi.Router) {
-		r.Get("/articles", api.ErrorWrapper(api.GetAllArticles))
-		r.Get("/article/{id}", api.ErrorWrapper(api.GetArticleByID))
+	// Metrics are scraped in the prometheus format whatever the negotiated format
+	rt.Group(func(rt chi.Router) {
+		rt.Use(api.Negotiate)
+
+		rt.Route("/v1", func(r chi.Router) {
+			r.Get("/articles", api.ErrorWrapper(api.GetAllArticles))
+			r.Get("/articles/search", api.ErrorWrapper(api.SearchArticles))
+			r.Get("/article/{id}", api.ErrorWrapper(api.GetArticleByID))
+			api.revisionRoutes(r)
+
+			r.Get("/clubs", api.ErrorWrapper(api.GetClubs))
+			api.webhookRoutes(r)
+
+			r.Route("/clubs/{club}", func(r chi.Router) {
+				r.Get("/articles", api.ErrorWrapper(api.GetAllArticles))
+				r.Get("/articles/search", api.ErrorWrapper(api.SearchArticles))
+				r.Get("/article/{id}", api.ErrorWrapper(api.GetArticleByID))
+				api.revisionRoutes(r)
+			})
+		})
+
+		rt.Get("/version", api.ErrorWrapper(api.Version))
+		rt.Get("/livez", api.ErrorWrapper(api.Livez))
+		// kept for the probes that still point at the old health endpoint
+		rt.Get("/health", api.ErrorWrapper(api.Livez))
+		if api.health != nil {
+			rt.Get("/readyz", api.ErrorWrapper(api.Readyz))
+		}
 	})
 
-	rt.Get("/version", api.ErrorWrapper(api.Version))
-	rt.Get("/health", api.ErrorWrapper(api.Health))
+	// Feeds and event streams have a format of their own
+	rt.Get("/v1/feeds/rss.xml", api.ErrorWrapper(api.GetRSSFeed))
+	rt.Get("/v1/feeds/atom.xml", api.ErrorWrapper(api.GetAtomFeed))
+	if api.events != nil {
+		rt.Get("/v1/articles/stream", api.ErrorWrapper(api.StreamArticles))
+		rt.Get("/v1/clubs/{club}/articles/stream", api.ErrorWrapper(api.StreamArticles))
+	}
+	rt.Method("GET", "/metrics", m.Handler())
 
 	return &rt
 }
+
+// revisionRoutes registers the revision routes of articles when the API serves revisions
+func (a *API) revisionRoutes(r chi.Router) {
+	if a.revisions == nil {
+		return
+	}
+
+	r.Get("/article/{id}/revisions", a.ErrorWrapper(a.GetArticleRevisions))
+	r.Get("/article/{id}/revisions/{rev}/diff", a.ErrorWrapper(a.GetArticleRevisionDiff))
+}
+
+// webhookRoutes registers the webhook routes when the API serves webhooks, they're managed by the admins only
+func (a *API) webhookRoutes(r chi.Router) {
+	if a.webhooks == nil {
+		return
+	}
+
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Use(a.AdminAuth)
+		r.Post("/", a.ErrorWrapper(a.CreateWebhook))
+		r.Get("/", a.ErrorWrapper(a.GetWebhooks))
+		r.Delete("/{id}", a.ErrorWrapper(a.DeleteWebhook))
+		r.Get("/{id}/deliveries", a.ErrorWrapper(a.GetWebhookDeliveries))
+	})
+}
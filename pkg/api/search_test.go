@@ -0,0 +1,138 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPI_SearchArticles(t *testing.T) {
+	testCases := []struct {
+		description        string
+		path               string
+		expectedQuery      *storage.SearchQuery
+		expectedStatus     int
+		expectedHighlight  string
+		expectedNextCursor bool
+	}{
+		{
+			description: "should respond with 200 and the highlighted matching articles",
+			path:        "/v1/articles/search?q=tournament+-tickets&limit=1",
+			expectedQuery: &storage.SearchQuery{
+				PageQuery: storage.PageQuery{Limit: 1},
+				Text:      "tournament -tickets",
+			},
+			expectedStatus:     http.StatusOK,
+			expectedHighlight:  "Club supports fans heading to <em>tournament</em>",
+			expectedNextCursor: true,
+		},
+		{
+			description: "should search the articles of a club",
+			path:        `/v1/clubs/brentford/articles/search?q="premier+league"`,
+			expectedQuery: &storage.SearchQuery{
+				PageQuery: storage.PageQuery{Club: "brentford", Limit: 20},
+				Text:      `"premier league"`,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "should respond with 400 without a search",
+			path:           "/v1/articles/search?q=+",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 when the cursor is malformed",
+			path:           "/v1/articles/search?q=tournament&cursor=notacursor",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Clubs = []config.Club{{Name: "brentford", TeamId: "t94"}}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			if tc.expectedQuery != nil {
+				dbrepo.EXPECT().
+					SearchNews(gomock.Any(), *tc.expectedQuery).
+					Return(storage.SearchPage{
+						Results: []storage.SearchResult{{
+							Result: storage.Result{
+								ArticleID: "645150",
+								Data:      news.Data{Id: "645150", Title: "Club supports fans heading to tournament"},
+							},
+							Score: 10.5,
+						}},
+						TotalItems: 2,
+						Next:       &storage.Cursor{Score: 10.5, PublishedAt: time.Now(), ArticleID: "645150"},
+					}, nil)
+			}
+
+			router := api.NewRouter(api.NewAPI(responder, v, dbrepo, cfg, log), metrics.New(), log)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data []struct {
+					Score      float64           `json:"score"`
+					Highlights map[string]string `json:"highlights"`
+				} `json:"data"`
+				Metadata news.Metadata `json:"metadata"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(resp.Data) != 1 || resp.Data[0].Score != 10.5 {
+				t.Fatalf("expected a single scored search result, got %+v", resp.Data)
+			}
+
+			if tc.expectedHighlight != "" && resp.Data[0].Highlights["title"] != tc.expectedHighlight {
+				t.Fatalf("unexpected title highlight: %q", resp.Data[0].Highlights["title"])
+			}
+
+			if tc.expectedNextCursor {
+				if c, err := storage.DecodeCursor(resp.Metadata.NextCursor); err != nil || c.Score != 10.5 {
+					t.Fatalf("expected a valid next search cursor, got %q", resp.Metadata.NextCursor)
+				}
+			}
+		})
+	}
+}
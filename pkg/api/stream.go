@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"com.thanos/pkg/events"
+)
+
+// LastEventIDHeader is the header of the id of the last event a reconnecting stream subscriber received
+const LastEventIDHeader = "Last-Event-ID"
+
+// StreamArticles pushes the events of the stored articles as server-sent events, as the sync stores them.
+// Subscribers resuming with a Last-Event-ID header first get the retained events that followed it.
+// The stream of a subscriber that lags too far behind is ended, it's expected to reconnect and resume
+func (a *API) StreamArticles(w http.ResponseWriter, r *http.Request) error {
+	club, err := a.club(r)
+	if err != nil {
+		return err
+	}
+
+	var lastID uint64
+	if id := r.Header.Get(LastEventIDHeader); id != "" {
+		if lastID, err = strconv.ParseUint(id, 10, 64); err != nil {
+			return ErrBadRequest
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("streaming is not supported by the connection")
+	}
+
+	sub, replay := a.events.Subscribe(lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// keeps reverse proxies from buffering the events
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(e events.Event) error {
+		if club != "" && e.Club != club {
+			return nil
+		}
+
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+
+		return err
+	}
+
+	for _, e := range replay {
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+	flusher.Flush()
+
+	var keepAlive <-chan time.Time
+	if a.cfg.Events.KeepAlive > 0 {
+		ticker := time.NewTicker(a.cfg.Events.KeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case e, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+			if err := send(e); err != nil {
+				return err
+			}
+		case <-keepAlive:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return err
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,125 @@
+package api_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPI_StreamArticles(t *testing.T) {
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Clubs = []config.Club{{Name: "brentford"}, {Name: "fulham"}}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	bus := events.NewBus(10)
+	a := api.NewAPI(api.NewNegotiator(cfg.APP.Name, v.Translator), v, storage.NewMockDBRepo(ctrl), cfg, log, api.WithEvents(bus))
+
+	srv := httptest.NewServer(api.NewRouter(a, metrics.New(), log))
+	defer srv.Close()
+	defer bus.Close()
+
+	// subscribe before anything is published, the response headers are only sent once subscribed
+	resp, err := http.Get(srv.URL + "/v1/clubs/brentford/articles/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	bus.Publish(
+		events.Event{Type: events.ArticleCreated, ArticleID: "645169", Club: "brentford", Data: &news.Data{Id: "645169"}},
+		events.Event{Type: events.ArticleCreated, ArticleID: "700000", Club: "fulham"},
+		events.Event{Type: events.ArticleWithdrawn, ArticleID: "645168", Club: "brentford", Withdrawn: &news.Withdrawal{Reason: news.WithdrawnDeleted}},
+	)
+
+	received := readEvents(t, bufio.NewReader(resp.Body), 2)
+	if received[0]["event"] != string(events.ArticleCreated) || !strings.Contains(received[0]["data"], `"articleId":"645169"`) {
+		t.Fatalf("unexpected first event: %+v", received[0])
+	}
+	if received[1]["event"] != string(events.ArticleWithdrawn) || !strings.Contains(received[1]["data"], `"reason":"deleted"`) {
+		t.Fatalf("expected the event of the other club to be skipped, got %+v", received[1])
+	}
+
+	// resuming after the first event replays the retained events that followed it
+	firstID, err := strconv.ParseUint(received[0]["id"], 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/articles/stream", nil)
+	req.Header.Set(api.LastEventIDHeader, strconv.FormatUint(firstID, 10))
+	resumed, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Body.Close()
+
+	replayed := readEvents(t, bufio.NewReader(resumed.Body), 2)
+	if replayed[0]["id"] != strconv.FormatUint(firstID+1, 10) || !strings.Contains(replayed[0]["data"], `"club":"fulham"`) {
+		t.Fatalf("unexpected replayed events: %+v", replayed)
+	}
+
+	req.Header.Set(api.LastEventIDHeader, "yesterday")
+	invalid, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid.Body.Close()
+	if invalid.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected to get status %d, got %d", http.StatusBadRequest, invalid.StatusCode)
+	}
+}
+
+// readEvents reads n server-sent events as maps of their fields
+func readEvents(t *testing.T, r *bufio.Reader, n int) []map[string]string {
+	t.Helper()
+
+	var received []map[string]string
+	e := make(map[string]string)
+	for len(received) < n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if len(e) > 0 {
+				received = append(received, e)
+				e = make(map[string]string)
+			}
+		case strings.HasPrefix(line, ":"):
+		default:
+			parts := strings.SplitN(line, ": ", 2)
+			e[parts[0]] = parts[1]
+		}
+	}
+
+	return received
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/webhooks"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// maxWebhookBody bounds the body of a webhook subscription
+const maxWebhookBody = 64 << 10
+
+// webhookRequest is the body of a webhook subscription. No events or clubs subscribe to all of them,
+// and a secret is generated when none is given
+type webhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events" validate:"max=3,unique,dive,oneof=article.created article.updated article.withdrawn"`
+	Clubs  []string `json:"clubs" validate:"max=100,unique,dive,required,max=64"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+// CreateWebhook subscribes a webhook to the events of the stored articles. The secret the payloads are signed with
+// is only part of this response
+func (a *API) CreateWebhook(w http.ResponseWriter, r *http.Request) error {
+	var req webhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBody)).Decode(&req); err != nil {
+		return ErrBadRequest
+	}
+
+	if err := a.validate.Struct(req); err != nil {
+		return a.validationError(err)
+	}
+
+	u, _ := url.Parse(req.URL)
+	if u == nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrBadRequest.WithReasons([]ErrorReason{{
+			Field: "url",
+			Error: "url must be an http or https url",
+			Value: req.URL,
+			Type:  "url",
+		}})
+	}
+
+	if !a.cfg.Webhooks.AllowPrivateNetworks && forbiddenHost(u.Hostname()) {
+		return ErrBadRequest.WithReasons([]ErrorReason{{
+			Field: "url",
+			Error: "url must not be a loopback, private, link-local or unspecified address",
+			Value: req.URL,
+			Type:  "url",
+		}})
+	}
+
+	for _, club := range req.Clubs {
+		if _, ok := a.cfg.Club(club); !ok {
+			return ErrBadRequest.WithReasons([]ErrorReason{{
+				Field: "clubs",
+				Error: "clubs must be configured clubs",
+				Value: club,
+				Type:  "oneof",
+			}})
+		}
+	}
+
+	if req.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return err
+		}
+		req.Secret = secret
+	}
+
+	webhook := storage.Webhook{
+		ID:        uuid.NewString(),
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    req.Events,
+		Clubs:     req.Clubs,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := a.webhooks.CreateWebhook(r.Context(), webhook); err != nil {
+		return storageError(err)
+	}
+
+	resp := webhookResponse(webhook)
+	resp.Secret = webhook.Secret
+
+	w.Header().Set("Location", "/v1/webhooks/"+webhook.ID)
+
+	return a.Respond(r.Context(), w, Response{Status: "success", Data: resp}, http.StatusCreated)
+}
+
+// GetWebhooks lists the webhooks, oldest first
+func (a *API) GetWebhooks(w http.ResponseWriter, r *http.Request) error {
+	webhooks, err := a.webhooks.GetWebhooks(r.Context())
+	if err != nil {
+		return storageError(err)
+	}
+
+	resp := make([]WebhookResponse, len(webhooks))
+	for i := range webhooks {
+		resp[i] = webhookResponse(webhooks[i])
+	}
+
+	return a.Respond(
+		r.Context(),
+		w,
+		Response{
+			Status: "success",
+			Data:   resp,
+			Metadata: news.Metadata{
+				CreatedAt:  time.Now().UTC().Format(ISO8601),
+				Sort:       "createdAt",
+				TotalItems: len(resp),
+			},
+		},
+		http.StatusOK,
+	)
+}
+
+// DeleteWebhook unsubscribes a webhook, its queued deliveries are dropped
+func (a *API) DeleteWebhook(w http.ResponseWriter, r *http.Request) error {
+	if err := a.webhooks.DeleteWebhook(r.Context(), chi.URLParam(r, "id")); err != nil {
+		return storageError(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+// GetWebhookDeliveries lists the latest deliveries of a webhook, newest first. The number of deliveries
+// is controlled by the limit query param
+func (a *API) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) error {
+	limit := defaultPageLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit < 1 || limit > maxPageLimit {
+			return ErrBadRequest
+		}
+	}
+
+	id := chi.URLParam(r, "id")
+	if _, err := a.webhooks.GetWebhook(r.Context(), id); err != nil {
+		return storageError(err)
+	}
+
+	deliveries, err := a.webhooks.GetDeliveries(r.Context(), id, limit)
+	if err != nil {
+		return storageError(err)
+	}
+
+	resp := make([]DeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		resp[i] = DeliveryResponse{
+			ID:             d.ID,
+			EventType:      d.EventType,
+			ArticleID:      d.ArticleID,
+			Status:         string(d.Status),
+			Attempts:       d.Attempts,
+			CreatedAt:      d.CreatedAt.UTC().Format(ISO8601),
+			ResponseStatus: d.ResponseStatus,
+			Error:          d.Error,
+		}
+		if d.Status == storage.DeliveryPending {
+			resp[i].NextAttemptAt = d.NextAttemptAt.UTC().Format(ISO8601)
+		}
+	}
+
+	return a.Respond(
+		r.Context(),
+		w,
+		Response{
+			Status: "success",
+			Data:   resp,
+			Metadata: news.Metadata{
+				CreatedAt:  time.Now().UTC().Format(ISO8601),
+				Sort:       "-createdAt",
+				TotalItems: len(resp),
+			},
+		},
+		http.StatusOK,
+	)
+}
+
+func webhookResponse(w storage.Webhook) WebhookResponse {
+	resp := WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    w.Events,
+		Clubs:     w.Clubs,
+		CreatedAt: w.CreatedAt.UTC().Format(ISO8601),
+	}
+	if resp.Events == nil {
+		resp.Events = []string{}
+	}
+	if resp.Clubs == nil {
+		resp.Clubs = []string{}
+	}
+
+	return resp
+}
+
+// forbiddenHost reports whether a host is obviously one that webhooks aren't delivered to. Hosts resolving to
+// such an address are only rejected by the dispatcher, as they can be resolved differently later on
+func forbiddenHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return webhooks.Forbidden(ip)
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	return host == "localhost" || strings.HasSuffix(host, ".localhost")
+}
+
+// newSecret generates a random secret to sign the payloads delivered to a webhook
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,225 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/api"
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/validator"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPI_Webhooks(t *testing.T) {
+	webhook := storage.Webhook{
+		ID:        "webhook",
+		URL:       "https://partner.example.com/hooks",
+		Secret:    "secret",
+		Events:    []string{"article.created"},
+		CreatedAt: time.Now(),
+	}
+
+	testCases := []struct {
+		description    string
+		method         string
+		path           string
+		body           string
+		setup          func(repo *storage.MockWebhookRepo)
+		expectedStatus int
+		expectedSecret bool
+	}{
+		{
+			description: "should create a webhook with a generated secret",
+			method:      http.MethodPost,
+			path:        "/v1/webhooks",
+			body:        `{"url": "https://partner.example.com/hooks", "events": ["article.created"], "clubs": ["brentford"]}`,
+			setup: func(repo *storage.MockWebhookRepo) {
+				repo.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, w storage.Webhook) error {
+						if w.ID == "" || len(w.Secret) != 64 || w.Clubs[0] != "brentford" {
+							return fmt.Errorf("unexpected webhook: %+v", w)
+						}
+						return nil
+					})
+			},
+			expectedStatus: http.StatusCreated,
+			expectedSecret: true,
+		},
+		{
+			description:    "should respond with 400 for an unknown event type",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			body:           `{"url": "https://partner.example.com/hooks", "events": ["article.deleted"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 for an unknown club",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			body:           `{"url": "https://partner.example.com/hooks", "clubs": ["arsenal"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 for a url that isn't http",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			body:           `{"url": "ftp://partner.example.com/hooks"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 for a loopback url",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			body:           `{"url": "http://127.0.0.1:8080/v1/webhooks"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 for a private url",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			body:           `{"url": "http://10.0.12.4/hooks"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 for a link-local url",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			body:           `{"url": "http://169.254.169.254/latest/meta-data"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 for a localhost url",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			body:           `{"url": "http://localhost:9090/metrics"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description:    "should respond with 400 for a malformed body",
+			method:         http.MethodPost,
+			path:           "/v1/webhooks",
+			body:           `{"url": `,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			description: "should list the webhooks without their secret",
+			method:      http.MethodGet,
+			path:        "/v1/webhooks",
+			setup: func(repo *storage.MockWebhookRepo) {
+				repo.EXPECT().GetWebhooks(gomock.Any()).Return([]storage.Webhook{webhook}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description: "should delete a webhook",
+			method:      http.MethodDelete,
+			path:        "/v1/webhooks/webhook",
+			setup: func(repo *storage.MockWebhookRepo) {
+				repo.EXPECT().DeleteWebhook(gomock.Any(), "webhook").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			description: "should respond with 404 when deleting an unknown webhook",
+			method:      http.MethodDelete,
+			path:        "/v1/webhooks/unknown",
+			setup: func(repo *storage.MockWebhookRepo) {
+				repo.EXPECT().DeleteWebhook(gomock.Any(), "unknown").Return(storage.NewError(storage.ErrNotFound, "DeleteWebhook", nil))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description: "should list the deliveries of a webhook",
+			method:      http.MethodGet,
+			path:        "/v1/webhooks/webhook/deliveries?limit=5",
+			setup: func(repo *storage.MockWebhookRepo) {
+				repo.EXPECT().GetWebhook(gomock.Any(), "webhook").Return(webhook, nil)
+				repo.EXPECT().GetDeliveries(gomock.Any(), "webhook", 5).Return([]storage.Delivery{
+					{ID: "delivery", WebhookID: "webhook", Status: storage.DeliveryDead, Attempts: 8, ResponseStatus: 500},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description: "should respond with 404 for the deliveries of an unknown webhook",
+			method:      http.MethodGet,
+			path:        "/v1/webhooks/unknown/deliveries",
+			setup: func(repo *storage.MockWebhookRepo) {
+				repo.EXPECT().GetWebhook(gomock.Any(), "unknown").Return(storage.Webhook{}, storage.NewError(storage.ErrNotFound, "GetWebhook", nil))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			description:    "should respond with 400 for an invalid deliveries limit",
+			method:         http.MethodGet,
+			path:           "/v1/webhooks/webhook/deliveries?limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Webhooks.AdminToken = "admin-token"
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	v, err := validator.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder := api.NewJSONResponder(cfg.APP.Name, v.Translator)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			repo := storage.NewMockWebhookRepo(ctrl)
+			if tc.setup != nil {
+				tc.setup(repo)
+			}
+
+			a := api.NewAPI(responder, v, storage.NewMockDBRepo(ctrl), cfg, log, api.WithWebhooks(repo))
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer admin-token")
+
+			recorder := httptest.NewRecorder()
+			api.NewRouter(a, metrics.New(), log).ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected to get status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body)
+			}
+
+			if secret := strings.Contains(recorder.Body.String(), `"secret"`); secret != tc.expectedSecret {
+				t.Fatalf("expected the secret in the response to be %t, got: %s", tc.expectedSecret, recorder.Body)
+			}
+
+			if tc.expectedStatus == http.StatusCreated {
+				var resp struct {
+					Data api.WebhookResponse `json:"data"`
+				}
+				if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				if recorder.Header().Get("Location") != "/v1/webhooks/"+resp.Data.ID {
+					t.Fatalf("unexpected location %q", recorder.Header().Get("Location"))
+				}
+			}
+		})
+	}
+}
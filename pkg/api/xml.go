@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"com.thanos/pkg/tracing"
+)
+
+// XMLResponder serves responses as XML in the style of the upstream incrowd feed: elements are named after the
+// JSON fields in PascalCase and the items of a list after its singular, e.g. <Changes><Change>, or else <Item>
+type XMLResponder struct {
+	serviceName string
+}
+
+// NewXMLResponder creates a new XMLResponder
+func NewXMLResponder(sn string) Responder {
+	return &XMLResponder{
+		serviceName: sn,
+	}
+}
+
+// Respond converts a Go value to XML and serves it as a response back to the caller. The root element is
+// named after the type of the value
+func (r *XMLResponder) Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) (err error) {
+	_, span := tracing.Start(ctx, "encode")
+	defer func() { tracing.End(span, err) }()
+
+	// The value is converted through its JSON encoding so that both formats have the same fields
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	if statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	enc := xml.NewEncoder(w)
+	if err = encodeXMLElement(dec, enc, rootName(data)); err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
+
+// RespondConditional serves data like Respond, unless it was not modified
+func (r *XMLResponder) RespondConditional(ctx context.Context, w http.ResponseWriter, req *http.Request, data interface{}, v Validators) error {
+	if respondNotModified(w, req, v) {
+		return nil
+	}
+
+	return r.Respond(ctx, w, data, http.StatusOK)
+}
+
+// RespondStream serves the items like a single page of a list, they are collected first
+func (r *XMLResponder) RespondStream(ctx context.Context, w http.ResponseWriter, _ interface{}, stream Stream) error {
+	items, err := collect(stream)
+	if err != nil {
+		return err
+	}
+
+	return r.Respond(ctx, w, Response{Status: "success", Data: items}, http.StatusOK)
+}
+
+// RespondError serves the error as an <ErrorResponse>
+func (r *XMLResponder) RespondError(ctx context.Context, w http.ResponseWriter, err error) error {
+	errResp, statusCode := errorResponse(ctx, r.serviceName, err)
+
+	return r.Respond(ctx, w, errResp, statusCode)
+}
+
+// encodeXMLElement encodes the next JSON value of dec as an element named name. Objects are encoded as
+// nested elements in the order of their fields, and null as an empty element
+func encodeXMLElement(dec *json.Decoder, enc *xml.Encoder, name string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err = enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err = encodeXMLElement(dec, enc, xmlName(key.(string))); err != nil {
+					return err
+				}
+			}
+		} else {
+			item := itemName(name)
+			for dec.More() {
+				if err = encodeXMLElement(dec, enc, item); err != nil {
+					return err
+				}
+			}
+		}
+		// the closing delimiter
+		if _, err = dec.Token(); err != nil {
+			return err
+		}
+	case string:
+		err = enc.EncodeToken(xml.CharData(t))
+	case json.Number:
+		err = enc.EncodeToken(xml.CharData(t.String()))
+	case bool:
+		err = enc.EncodeToken(xml.CharData(fmt.Sprint(t)))
+	}
+	if err != nil {
+		return err
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// rootName names the root element after the type of data
+func rootName(data interface{}) string {
+	t := reflect.TypeOf(data)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		return "Response"
+	}
+
+	return t.Name()
+}
+
+// xmlName converts a JSON field name, or a map key, to a PascalCase element name. Characters that are not
+// allowed in element names are replaced with underscores
+func xmlName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case i == 0 && unicode.IsLetter(r):
+			b.WriteRune(unicode.ToUpper(r))
+		case i == 0:
+			b.WriteRune('_')
+			if unicode.IsDigit(r) {
+				b.WriteRune(r)
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+
+	return b.String()
+}
+
+// itemName names the items of a list after its singular
+func itemName(list string) string {
+	if len(list) > 1 && strings.HasSuffix(list, "s") {
+		return strings.TrimSuffix(list, "s")
+	}
+
+	return "Item"
+}
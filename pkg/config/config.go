@@ -3,18 +3,32 @@ package config
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
+	"com.thanos/pkg/validator"
+	playground "github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	APP    APP
-	Server Server
-	API    API
-	Mongo  Mongo
-	Logger Logger
+	APP      APP
+	Server   Server
+	API      API
+	Upstream Upstream
+	Clubs    []Club
+	Storage  Storage
+	Mongo    Mongo
+	SQLite   SQLite
+	Health   Health
+	Metrics  Metrics
+	Tracing  Tracing
+	Events   Events
+	Webhooks Webhooks
+	Watcher  Watcher
+	Cache    Cache
+	Logger   Logger
 }
 
 type APP struct {
@@ -37,6 +51,51 @@ type API struct {
 	NewNewsArticlesFetchInterval time.Duration
 }
 
+// Upstream configures the client used to call the upstream news feeds
+type Upstream struct {
+	Timeout               time.Duration
+	DialTimeout           time.Duration
+	ResponseHeaderTimeout time.Duration
+	MaxRetries            int
+	RetryBaseDelay        time.Duration
+	RetryMaxDelay         time.Duration
+	// BreakerFailureThreshold consecutive failed requests open a feed's circuit for BreakerOpenDuration
+	BreakerFailureThreshold int
+	BreakerOpenDuration     time.Duration
+	// RateLimit is the number of requests per second allowed per feed, 0 disables rate limiting
+	RateLimit float64
+	RateBurst int
+}
+
+// Club configures a club whose news articles are stored
+type Club struct {
+	// Name identifies the club in routes and stored articles
+	Name    string
+	TeamId  string
+	Sources []Source
+}
+
+// Source configures a single upstream news feed
+type Source struct {
+	Name string
+	// Type is one of incrowd, rss, atom or jsonfeed
+	Type string
+	Url  string
+	// DetailsUrl and ArticlesPerCall are only used by incrowd sources
+	DetailsUrl      string
+	ArticlesPerCall int
+}
+
+// Storage selects the backend the news articles are stored in
+type Storage struct {
+	// Driver is one of mongo, sqlite or memory. The memory driver keeps the articles in the process, so the api
+	// then syncs them itself instead of relying on the fetcher. That's the only case in which the api writes
+	// articles, with the mongo and sqlite drivers it only serves reads
+	Driver string `validate:"oneof=mongo sqlite memory"`
+	// TTL is how long articles are kept after their publish time, unless the driver sets its own
+	TTL time.Duration
+}
+
 type Mongo struct {
 	Host       string
 	Port       int16
@@ -44,7 +103,106 @@ type Mongo struct {
 	Password   string
 	Database   string
 	Collection string
-	TTL        time.Duration
+	// RevisionsCollection stores the revision history of the articles of Collection
+	RevisionsCollection string
+	// SyncStatusCollection stores the status of the latest sync, reported by the api's readiness probe
+	SyncStatusCollection string
+	// WebhooksCollection stores the webhooks and WebhookDeliveriesCollection the queue of their deliveries
+	WebhooksCollection          string
+	WebhookDeliveriesCollection string
+	// WatchersCollection stores the position of the watchers of Collection, so that they resume where they stopped
+	WatchersCollection string
+	TTL                time.Duration
+}
+
+// SQLite configures the embedded database of the sqlite storage driver
+type SQLite struct {
+	// Path of the database file, it is created along with its schema when missing
+	Path string
+	// JanitorInterval is how often the articles and revisions past storage.ttl are deleted
+	JanitorInterval time.Duration
+}
+
+// Health configures the readiness probe of the api
+type Health struct {
+	// Timeout bounds the checks of every dependency
+	Timeout time.Duration
+	// MaxSyncAge is how old the last successful sync may be before the sync is reported down
+	MaxSyncAge time.Duration
+}
+
+// Metrics configures the prometheus metrics. The api serves them on its own port, at /metrics
+type Metrics struct {
+	// FetcherPort is the port the fetcher serves its /metrics on, 0 disables them
+	FetcherPort int16
+}
+
+// Events configures the bus of the article events the sync, or the poll of the storage, publishes to the stream subscribers
+type Events struct {
+	// PollInterval is the interval of the polls of the articles written by another process, e.g. the fetcher,
+	// the events of the writes it finds are published to the bus
+	PollInterval time.Duration
+	// BufferSize is the number of latest events retained for subscribers resuming after their Last-Event-ID
+	BufferSize int
+	// KeepAlive is the interval of the comments sent to idle subscribers, so that proxies keep their stream open
+	KeepAlive time.Duration
+}
+
+// Webhooks configures the delivery of the article events to the webhooks
+type Webhooks struct {
+	// PollInterval is how often the delivery queue is polled for due deliveries
+	PollInterval time.Duration
+	// Timeout bounds every delivery attempt
+	Timeout time.Duration
+	// MaxAttempts of a delivery before it's dead
+	MaxAttempts int
+	// RetryBaseDelay is the delay before the second attempt, it doubles on every attempt up to RetryMaxDelay
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// AdminToken is the bearer token of the webhook endpoints of the api, they're disabled when it's empty
+	AdminToken string
+	// AllowPrivateNetworks allows deliveries to loopback, private and link-local addresses, for local development only
+	AllowPrivateNetworks bool
+}
+
+// Watcher configures the watcher of the articles written by the fetcher, which publishes their changes to the
+// stream subscribers of the api. Only the mongo driver is watched, the articles of the others are polled
+type Watcher struct {
+	// Enabled is on by default, without the watcher the articles are polled every Events.PollInterval from
+	// the start of the api on, missing the changes made while it was down
+	Enabled bool
+	// ID identifies the position the watcher resumes from, it defaults to the hostname so that every replica of
+	// the api has a position of its own
+	ID string
+	// PollInterval is how often the articles are polled when the deployment doesn't support change streams
+	PollInterval time.Duration
+	// RetryDelay is the delay before watching again after a failure
+	RetryDelay time.Duration
+}
+
+// Cache configures the cache of the article reads of the api. Cached reads are invalidated by the writes of the
+// api's own sync and by the changes of the watcher, or by the syncs of the fetcher when the watcher is disabled.
+// TTL bounds how stale they get otherwise
+type Cache struct {
+	// Size is the maximum number of cached reads, 0 disables the cache
+	Size int
+	TTL  time.Duration
+	// SyncCheckInterval is how often the latest sync of the fetcher is checked when the watcher is disabled
+	SyncCheckInterval time.Duration `validate:"gt=0"`
+}
+
+// Tracing configures the export of the opentelemetry spans
+type Tracing struct {
+	// Exporter is one of none, stdout, file or otlp. Spans are not recorded without an exporter
+	Exporter string `validate:"oneof=none stdout file otlp"`
+	// FilePath is the file the file exporter appends the spans to
+	FilePath string
+	// Endpoint is the host:port of the collector the otlp exporter sends the spans to over http
+	Endpoint string
+	Insecure bool
+	// SampleRatio of the traces started by the service that are sampled, traces of incoming requests are
+	// sampled when their caller sampled them
+	SampleRatio float64
 }
 
 type Logger struct {
@@ -58,6 +216,13 @@ func New(path ...string) (*Config, error) {
 	v := viper.New()
 	setDefaults(v)
 
+	v.SetConfigName("config")
+	v.SetConfigType("yml")
+	for _, p := range path {
+		v.AddConfigPath(p)
+	}
+	v.AddConfigPath(".")
+
 	v.SetEnvPrefix("app")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
@@ -73,9 +238,68 @@ func New(path ...string) (*Config, error) {
 	c.Logger.AppName = c.APP.Name
 	c.Logger.AppEnvironment = c.APP.Environment
 
+	if c.Mongo.TTL == 0 {
+		c.Mongo.TTL = c.Storage.TTL
+	}
+
+	// Without any configured clubs fall back to a single club reading the incrowd feed of the legacy api settings
+	if len(c.Clubs) == 0 {
+		c.Clubs = []Club{
+			{
+				Name: "default",
+				Sources: []Source{
+					{
+						Name:            "incrowd",
+						Type:            "incrowd",
+						Url:             c.API.GetLatestNewsArticlesUrl,
+						DetailsUrl:      c.API.GetArticleDetailsUrl,
+						ArticlesPerCall: c.API.NewsArticlesPerCall,
+					},
+				},
+			},
+		}
+	}
+
+	if err := validate(&c); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
 
+// validate checks the config against the validate tags of its fields, listing every invalid field
+func validate(c *Config) error {
+	v, err := validator.New()
+	if err != nil {
+		return err
+	}
+
+	err = v.Struct(c)
+
+	var fieldErrors playground.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	reasons := make([]string, len(fieldErrors))
+	for i, fe := range fieldErrors {
+		reasons[i] = fmt.Sprintf("%s: %s", strings.TrimPrefix(fe.Namespace(), "Config."), fe.Translate(v.Translator))
+	}
+
+	return fmt.Errorf("invalid config, %s", strings.Join(reasons, "; "))
+}
+
+// Club returns the configured club with the given name
+func (c *Config) Club(name string) (Club, bool) {
+	for _, club := range c.Clubs {
+		if club.Name == name {
+			return club, true
+		}
+	}
+
+	return Club{}, false
+}
+
 func setDefaults(v *viper.Viper) {
 	// App defaults
 	v.SetDefault("app.name", "sports-news-storage")
@@ -100,6 +324,61 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("api.getArticleDetailsUrl", "https://www.brentfordfc.com/api/incrowd/getnewsarticleinformation?id=")
 	v.SetDefault("api.newNewsArticlesFetchInterval", "15s")
 
+	// Upstream defaults
+	v.SetDefault("upstream.timeout", "10s")
+	v.SetDefault("upstream.dialTimeout", "5s")
+	v.SetDefault("upstream.responseHeaderTimeout", "5s")
+	v.SetDefault("upstream.maxRetries", 3)
+	v.SetDefault("upstream.retryBaseDelay", "200ms")
+	v.SetDefault("upstream.retryMaxDelay", "5s")
+	v.SetDefault("upstream.breakerFailureThreshold", 5)
+	v.SetDefault("upstream.breakerOpenDuration", "30s")
+	v.SetDefault("upstream.rateLimit", 5)
+	v.SetDefault("upstream.rateBurst", 10)
+
+	// Storage defaults
+	v.SetDefault("storage.driver", "mongo")
+	v.SetDefault("storage.ttl", "168h")
+
+	// Health defaults
+	v.SetDefault("health.timeout", "2s")
+	v.SetDefault("health.maxSyncAge", "5m")
+
+	// Metrics defaults
+	v.SetDefault("metrics.fetcherPort", 9090)
+
+	// Events defaults
+	v.SetDefault("events.bufferSize", 1000)
+	v.SetDefault("events.keepAlive", "15s")
+	v.SetDefault("events.pollInterval", "2s")
+
+	// Webhooks defaults
+	v.SetDefault("webhooks.pollInterval", "5s")
+	v.SetDefault("webhooks.timeout", "10s")
+	v.SetDefault("webhooks.maxAttempts", 8)
+	v.SetDefault("webhooks.retryBaseDelay", "30s")
+	v.SetDefault("webhooks.retryMaxDelay", "1h")
+	v.SetDefault("webhooks.adminToken", "")
+	v.SetDefault("webhooks.allowPrivateNetworks", false)
+
+	// Watcher defaults
+	v.SetDefault("watcher.enabled", true)
+	v.SetDefault("watcher.id", hostname())
+	v.SetDefault("watcher.pollInterval", "5s")
+	v.SetDefault("watcher.retryDelay", "5s")
+
+	// Cache defaults
+	v.SetDefault("cache.size", 500)
+	v.SetDefault("cache.ttl", "1m")
+	v.SetDefault("cache.syncCheckInterval", "5s")
+
+	// Tracing defaults
+	v.SetDefault("tracing.exporter", "none")
+	v.SetDefault("tracing.filePath", "traces.json")
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", false)
+	v.SetDefault("tracing.sampleRatio", 1)
+
 	// Mongo defaults
 	v.SetDefault("mongo.host", "localhost")
 	v.SetDefault("mongo.port", 27100)
@@ -107,5 +386,25 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("mongo.password", "toor")
 	v.SetDefault("mongo.database", "news")
 	v.SetDefault("mongo.collection", "articles")
-	v.SetDefault("mongo.ttl", "168h")
+	v.SetDefault("mongo.revisionsCollection", "articleRevisions")
+	v.SetDefault("mongo.syncStatusCollection", "syncStatus")
+	v.SetDefault("mongo.webhooksCollection", "webhooks")
+	v.SetDefault("mongo.webhookDeliveriesCollection", "webhookDeliveries")
+	v.SetDefault("mongo.watchersCollection", "watchers")
+	// 0 falls back to storage.ttl
+	v.SetDefault("mongo.ttl", 0)
+
+	// SQLite defaults
+	v.SetDefault("sqlite.path", "news.db")
+	v.SetDefault("sqlite.janitorInterval", "1m")
+}
+
+// hostname returns the hostname of the machine, "api" when it's unknown
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "api"
+	}
+
+	return name
 }
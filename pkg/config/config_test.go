@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"com.thanos/pkg/config"
+)
+
+func TestNew_Validate(t *testing.T) {
+	testCases := []struct {
+		description string
+		env         map[string]string
+		expectedErr string
+	}{
+		{
+			description: "should load the defaults",
+		},
+		{
+			description: "should reject an unknown storage driver",
+			env:         map[string]string{"APP_STORAGE_DRIVER": "postgres"},
+			expectedErr: "Storage.Driver",
+		},
+		{
+			description: "should reject a sync check interval that isn't positive",
+			env:         map[string]string{"APP_CACHE_SYNCCHECKINTERVAL": "0s"},
+			expectedErr: "Cache.SyncCheckInterval",
+		},
+		{
+			description: "should reject a server port below 80",
+			env:         map[string]string{"APP_SERVER_PORT": "79"},
+			expectedErr: "Server.Port",
+		},
+		{
+			description: "should reject an unknown tracing exporter",
+			env:         map[string]string{"APP_TRACING_EXPORTER": "jaeger"},
+			expectedErr: "Tracing.Exporter",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := config.New()
+
+			switch {
+			case tc.expectedErr == "" && err != nil:
+				t.Fatalf("expected a valid config, got: %v", err)
+			case tc.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedErr)):
+				t.Fatalf("expected an invalid %s, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestNew_WatcherID(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Watcher.ID != hostname {
+		t.Fatalf("expected the watcher id to default to the hostname %q, got %q", hostname, cfg.Watcher.ID)
+	}
+}
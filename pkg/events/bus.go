@@ -0,0 +1,166 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"com.thanos/pkg/news"
+)
+
+// Type of an article event
+type Type string
+
+// Event types
+const (
+	ArticleCreated   Type = "article.created"
+	ArticleUpdated   Type = "article.updated"
+	ArticleWithdrawn Type = "article.withdrawn"
+)
+
+// subscriptionBuffer is the number of events a subscriber may lag behind before it's dropped
+const subscriptionBuffer = 64
+
+// Event is a change of a stored article
+type Event struct {
+	// ID orders the events of a bus, it's assigned when the event is published
+	ID        uint64           `json:"-"`
+	Type      Type             `json:"-"`
+	At        time.Time        `json:"at"`
+	ArticleID string           `json:"articleId"`
+	Club      string           `json:"club"`
+	Data      *news.Data       `json:"data,omitempty"`
+	Withdrawn *news.Withdrawal `json:"withdrawn,omitempty"`
+}
+
+// Publisher is notified of every stored change of an article, e.g. to push them to subscribers
+type Publisher interface {
+	Publish(...Event)
+}
+
+// Publishers publishes the events to every publisher in order
+type Publishers []Publisher
+
+// Publish publishes the events to every publisher
+func (p Publishers) Publish(events ...Event) {
+	for _, publisher := range p {
+		publisher.Publish(events...)
+	}
+}
+
+// Bus fans the published events out to its subscribers. It retains the latest events so that subscribers
+// can resume after the last event they received
+type Bus struct {
+	mu          sync.Mutex
+	seq         uint64
+	history     []Event
+	size        int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBus creates a new Bus retaining the latest size events. Event ids start at the creation time of the bus
+// in nanoseconds, so that ids keep increasing across restarts
+func NewBus(size int) *Bus {
+	return &Bus{
+		seq:         uint64(time.Now().UnixNano()),
+		size:        size,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the events their ids and sends them to every subscriber. Subscribers that lag too far
+// behind are dropped rather than blocking the publisher, they can resume from the last event they received
+func (b *Bus) Publish(events ...Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	for _, e := range events {
+		b.seq++
+		e.ID = b.seq
+		if e.At.IsZero() {
+			e.At = time.Now().UTC()
+		}
+
+		if b.size > 0 {
+			if len(b.history) == b.size {
+				b.history = append(b.history[:0], b.history[1:]...)
+			}
+			b.history = append(b.history, e)
+		}
+
+		for s := range b.subscribers {
+			select {
+			case s.events <- e:
+			default:
+				b.drop(s)
+			}
+		}
+	}
+}
+
+// Subscribe subscribes to the events published from now on. The retained events published after lastID are
+// returned to be replayed first, a lastID of 0 replays none
+func (b *Bus) Subscribe(lastID uint64) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := &Subscription{bus: b, events: make(chan Event, subscriptionBuffer)}
+	if b.closed {
+		close(s.events)
+		return s, nil
+	}
+	b.subscribers[s] = struct{}{}
+
+	var replay []Event
+	if lastID != 0 {
+		for _, e := range b.history {
+			if e.ID > lastID {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	return s, replay
+}
+
+// Close ends every subscription, events published afterwards are discarded
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscribers {
+		b.drop(s)
+	}
+}
+
+// drop ends a subscription, the bus must be locked
+func (b *Bus) drop(s *Subscription) {
+	delete(b.subscribers, s)
+	close(s.events)
+}
+
+// Subscription receives the events of a bus
+type Subscription struct {
+	bus    *Bus
+	events chan Event
+}
+
+// Events returns the channel of the events, it's closed when the subscription ends
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subscribers[s]; ok {
+		s.bus.drop(s)
+	}
+}
@@ -0,0 +1,68 @@
+package events_test
+
+import (
+	"testing"
+
+	"com.thanos/pkg/events"
+)
+
+func TestBus_Subscribe(t *testing.T) {
+	bus := events.NewBus(2)
+
+	first, _ := bus.Subscribe(0)
+	bus.Publish(
+		events.Event{Type: events.ArticleCreated, ArticleID: "1"},
+		events.Event{Type: events.ArticleUpdated, ArticleID: "2"},
+		events.Event{Type: events.ArticleWithdrawn, ArticleID: "3"},
+	)
+
+	var received []events.Event
+	for i := 0; i < 3; i++ {
+		received = append(received, <-first.Events())
+	}
+	if received[0].ArticleID != "1" || received[2].Type != events.ArticleWithdrawn || received[0].At.IsZero() {
+		t.Fatalf("unexpected events: %+v", received)
+	}
+	if received[0].ID >= received[1].ID || received[1].ID >= received[2].ID {
+		t.Fatalf("expected increasing event ids, got %+v", received)
+	}
+
+	// only the latest 2 events are retained
+	_, replay := bus.Subscribe(received[0].ID - 1)
+	if len(replay) != 2 || replay[0].ArticleID != "2" {
+		t.Fatalf("expected the retained events to be replayed, got %+v", replay)
+	}
+
+	_, replay = bus.Subscribe(received[1].ID)
+	if len(replay) != 1 || replay[0].ArticleID != "3" {
+		t.Fatalf("expected the events after the last one to be replayed, got %+v", replay)
+	}
+
+	first.Close()
+	if _, ok := <-first.Events(); ok {
+		t.Fatal("expected a closed subscription to receive no events")
+	}
+}
+
+func TestBus_DropsLaggingSubscribers(t *testing.T) {
+	bus := events.NewBus(0)
+
+	s, _ := bus.Subscribe(0)
+	for i := 0; i < 100; i++ {
+		bus.Publish(events.Event{Type: events.ArticleCreated})
+	}
+
+	received := 0
+	for range s.Events() {
+		received++
+	}
+	if received == 0 || received == 100 {
+		t.Fatalf("expected the lagging subscriber to be dropped after some events, got %d", received)
+	}
+
+	bus.Close()
+	s, _ = bus.Subscribe(0)
+	if _, ok := <-s.Events(); ok {
+		t.Fatal("expected subscriptions of a closed bus to be closed")
+	}
+}
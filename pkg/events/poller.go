@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/storage"
+)
+
+// pollOverlap is how far back each poll looks before the latest write it found, so that writes
+// committed out of order by concurrent writers are not missed
+const pollOverlap = time.Second
+
+// UpdatedArticlesRepo lists the articles written since a point in time
+type UpdatedArticlesRepo interface {
+	GetUpdatedArticles(ctx context.Context, since time.Time) ([]storage.UpdatedArticle, error)
+}
+
+// Poller publishes the events of the articles written by another process, e.g. the fetcher,
+// by polling the latest writes of the storage
+type Poller struct {
+	repo     UpdatedArticlesRepo
+	interval time.Duration
+	log      *logger.Logger
+	// start is when the poller was created or the mark it resumed from, writes up to it are not published
+	start time.Time
+	// mark is the time of the latest write found, writes are polled from it on
+	mark time.Time
+	// seen are the writes found within the overlap before mark, they're not published twice
+	seen map[write]struct{}
+}
+
+// write identifies a write of an article
+type write struct {
+	id string
+	at int64
+}
+
+// NewPoller creates a new Poller of the writes to repo every interval. Only writes made after its creation
+// are published
+func NewPoller(repo UpdatedArticlesRepo, interval time.Duration, l *logger.Logger) *Poller {
+	return NewPollerFrom(repo, interval, time.Now().UTC(), l)
+}
+
+// NewPollerFrom creates a new Poller of the writes to repo every interval publishing the writes made after mark,
+// e.g. the Mark of a previous poller
+func NewPollerFrom(repo UpdatedArticlesRepo, interval time.Duration, mark time.Time, l *logger.Logger) *Poller {
+	return &Poller{
+		repo:     repo,
+		interval: interval,
+		log:      l,
+		start:    mark,
+		mark:     mark,
+		seen:     make(map[write]struct{}),
+	}
+}
+
+// Mark returns the time of the latest write found, a poller created from it resumes after that write
+func (p *Poller) Mark() time.Time {
+	return p.mark
+}
+
+// Run polls the writes every interval until ctx is done and publishes their events to publisher
+func (p *Poller) Run(ctx context.Context, publisher Publisher) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := p.Poll(ctx, publisher); err != nil {
+			p.log.WithError(err).Error("article events poll failed")
+		}
+	}
+}
+
+// Poll publishes the events of the writes made since the previous poll to publisher
+func (p *Poller) Poll(ctx context.Context, publisher Publisher) error {
+	since := p.mark.Add(-pollOverlap)
+	updated, err := p.repo.GetUpdatedArticles(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	var changes []Event
+	seen := make(map[write]struct{}, len(updated))
+	for _, u := range updated {
+		w := write{id: u.ID, at: u.UpdatedAt.UnixNano()}
+		seen[w] = struct{}{}
+		if _, ok := p.seen[w]; ok || !u.UpdatedAt.After(p.start) {
+			continue
+		}
+		if u.UpdatedAt.After(p.mark) {
+			p.mark = u.UpdatedAt
+		}
+
+		changes = append(changes, change(u))
+	}
+	p.seen = seen
+
+	if len(changes) > 0 {
+		publisher.Publish(changes...)
+	}
+
+	return nil
+}
+
+// change is the event of the latest write of an article
+func change(u storage.UpdatedArticle) Event {
+	e := Event{At: u.UpdatedAt, ArticleID: u.Data.Id, Club: u.Club}
+	switch {
+	case u.Withdrawn != nil:
+		e.Type = ArticleWithdrawn
+		e.Withdrawn = u.Withdrawn
+	case u.Revision <= 1:
+		e.Type = ArticleCreated
+		e.Data = &u.Data
+	default:
+		e.Type = ArticleUpdated
+		e.Data = &u.Data
+	}
+
+	return e
+}
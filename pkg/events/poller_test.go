@@ -0,0 +1,132 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage/memory"
+	"com.thanos/pkg/storage/storagetest"
+)
+
+type recorder struct {
+	events []events.Event
+}
+
+func (r *recorder) Publish(e ...events.Event) {
+	r.events = append(r.events, e...)
+}
+
+func TestPoller_Poll(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository(time.Hour)
+
+	before := storagetest.Article("1", "brentford", time.Now())
+	before.Revision = 1
+	untouched := storagetest.Article("3", "brentford", time.Now())
+	untouched.Revision = 1
+	if _, err := repo.BulkInsert(ctx, []news.NewsArticle{before, untouched}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the writes made before the poller was created are within the overlap of its first poll, but not published
+	poller := events.NewPoller(repo, time.Second, logger.NewLogger(config.Logger{}, logger.DisableOutput()))
+	time.Sleep(time.Millisecond)
+
+	created := storagetest.Article("2", "fulham", time.Now())
+	created.Revision = 1
+	updated := before
+	updated.Revision = 2
+	if _, err := repo.BulkInsert(ctx, []news.NewsArticle{created, updated}); err != nil {
+		t.Fatal(err)
+	}
+
+	var r recorder
+	if err := poller.Poll(ctx, &r); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		description string
+		articleID   string
+		club        string
+		expected    events.Type
+	}{
+		{"should publish the first write of an article as created", "2", "fulham", events.ArticleCreated},
+		{"should publish the later writes of an article as updated", "1", "brentford", events.ArticleUpdated},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.description, func(t *testing.T) {
+			for _, e := range r.events {
+				if e.ArticleID == tc.articleID && e.Club == tc.club {
+					if e.Type != tc.expected || e.Data == nil {
+						t.Fatalf("expected a %s event with the article, got %+v", tc.expected, e)
+					}
+					return
+				}
+			}
+			t.Fatalf("expected an event of article %s, got %+v", tc.articleID, r.events)
+		})
+	}
+
+	if len(r.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", r.events)
+	}
+
+	// the writes found are not published again
+	r.events = nil
+	if err := poller.Poll(ctx, &r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 0 {
+		t.Fatalf("expected no new events, got %+v", r.events)
+	}
+
+	if _, err := repo.WithdrawArticles(ctx, "fulham", []string{"2"}, news.WithdrawnDeleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := poller.Poll(ctx, &r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 1 || r.events[0].Type != events.ArticleWithdrawn || r.events[0].Withdrawn == nil {
+		t.Fatalf("expected the article to be withdrawn, got %+v", r.events)
+	}
+}
+
+func TestPoller_Mark(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository(time.Hour)
+	log := logger.NewLogger(config.Logger{}, logger.DisableOutput())
+
+	poller := events.NewPoller(repo, time.Second, log)
+	time.Sleep(time.Millisecond)
+
+	published := storagetest.Article("1", "brentford", time.Now())
+	if _, err := repo.BulkInsert(ctx, []news.NewsArticle{published}); err != nil {
+		t.Fatal(err)
+	}
+	if err := poller.Poll(ctx, &recorder{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	missed := storagetest.Article("2", "brentford", time.Now())
+	if _, err := repo.BulkInsert(ctx, []news.NewsArticle{missed}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a poller resuming from the mark of another one only publishes the writes that one didn't
+	var r recorder
+	if err := events.NewPollerFrom(repo, time.Second, poller.Mark(), log).Poll(ctx, &r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 1 || r.events[0].ArticleID != missed.Data.Id {
+		t.Fatalf("expected the event of the write after the mark, got %+v", r.events)
+	}
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/upstream"
+)
+
+// SyncDetails are the details of the sync component
+type SyncDetails struct {
+	LastSyncAt    *time.Time `json:"lastSyncAt,omitempty"`
+	LastSuccessAt *time.Time `json:"lastSuccessAt,omitempty"`
+	// Staleness is how long ago the last successful sync was, e.g. 1m30s
+	Staleness string `json:"staleness,omitempty"`
+}
+
+// Storage checks that the storage can be reached
+func Storage(ping func(ctx context.Context) error) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		return nil, ping(ctx)
+	}
+}
+
+// Sync checks that the last successful sync of the news articles is at most maxAge old
+func Sync(repo storage.SyncStatusRepo, maxAge time.Duration) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		status, err := repo.GetSyncStatus(ctx)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, errors.New("no sync yet")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		details := SyncDetails{LastSyncAt: &status.LastSyncAt}
+		if status.LastSuccessAt.IsZero() {
+			return details, errors.New("no successful sync yet")
+		}
+
+		staleness := time.Since(status.LastSuccessAt).Truncate(time.Second)
+		details.LastSuccessAt, details.Staleness = &status.LastSuccessAt, staleness.String()
+		if staleness > maxAge {
+			return details, fmt.Errorf("last successful sync is older than %s", maxAge)
+		}
+
+		return details, nil
+	}
+}
+
+// Feeds reports the circuit state of every upstream feed as of the latest sync. They are down unless every circuit is closed
+func Feeds(repo storage.SyncStatusRepo) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		status, err := repo.GetSyncStatus(ctx)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, errors.New("no sync yet")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var open []string
+		for feed, state := range status.Feeds {
+			if state != string(upstream.StateClosed) {
+				open = append(open, feed)
+			}
+		}
+		if len(open) > 0 {
+			sort.Strings(open)
+			return status.Feeds, fmt.Errorf("circuit not closed for feeds: %s", strings.Join(open, ", "))
+		}
+
+		return status.Feeds, nil
+	}
+}
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Status of a component or of a whole report
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+	// StatusDegraded reports that only components that are not critical are down
+	StatusDegraded Status = "degraded"
+)
+
+var errTimeout = errors.New("health check timed out")
+
+// Component is the health of a single dependency
+type Component struct {
+	Status   Status      `json:"status"`
+	Critical bool        `json:"critical"`
+	Error    string      `json:"error,omitempty"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+// Report is the health of every registered component. It is down when any critical component is down
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// CheckFunc checks a dependency. It returns details about the dependency and an error when it is down
+type CheckFunc func(ctx context.Context) (interface{}, error)
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker checks the health of the registered components
+type Checker struct {
+	checks  []check
+	timeout time.Duration
+}
+
+// NewChecker creates a new Checker. Components that don't respond within timeout are down
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+	}
+}
+
+// Register adds a component to check, the report is down when a critical component is down
+func (c *Checker) Register(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Check checks every component concurrently
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type result struct {
+		check   check
+		details interface{}
+		err     error
+	}
+
+	// buffered so that checks that time out don't leak
+	results := make(chan result, len(c.checks))
+	for _, ch := range c.checks {
+		go func(ch check) {
+			details, err := ch.fn(ctx)
+			results <- result{check: ch, details: details, err: err}
+		}(ch)
+	}
+
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(c.checks))}
+	for _, ch := range c.checks {
+		report.Components[ch.name] = Component{Status: StatusDown, Critical: ch.critical, Error: errTimeout.Error()}
+	}
+
+collect:
+	for range c.checks {
+		select {
+		case res := <-results:
+			component := Component{Status: StatusUp, Critical: res.check.critical, Details: res.details}
+			if res.err != nil {
+				component.Status, component.Error = StatusDown, res.err.Error()
+			}
+			report.Components[res.check.name] = component
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	for _, component := range report.Components {
+		switch {
+		case component.Status == StatusUp:
+		case component.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,98 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/health"
+	"com.thanos/pkg/storage"
+	"github.com/golang/mock/gomock"
+)
+
+func TestChecker_Check(t *testing.T) {
+	up := func(context.Context) (interface{}, error) { return nil, nil }
+	down := func(context.Context) (interface{}, error) { return nil, errors.New("down") }
+	hanging := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil, nil
+	}
+
+	testCases := []struct {
+		description    string
+		critical       health.CheckFunc
+		other          health.CheckFunc
+		expectedStatus health.Status
+	}{
+		{"should be up when every component is up", up, up, health.StatusUp},
+		{"should be degraded when a component that is not critical is down", up, down, health.StatusDegraded},
+		{"should be down when a critical component is down", down, up, health.StatusDown},
+		{"should be down when a critical component times out", hanging, up, health.StatusDown},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			checker := health.NewChecker(50 * time.Millisecond)
+			checker.Register("critical", true, tc.critical)
+			checker.Register("other", false, tc.other)
+
+			report := checker.Check(context.Background())
+
+			if report.Status != tc.expectedStatus {
+				t.Fatalf("expected status %s, got %+v", tc.expectedStatus, report)
+			}
+			if len(report.Components) != 2 {
+				t.Fatalf("expected both components to be reported, got %+v", report.Components)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	now := time.Now().UTC()
+
+	testCases := []struct {
+		description string
+		status      storage.SyncStatus
+		err         error
+		expectedErr bool
+	}{
+		{"should be up after a recent successful sync", storage.SyncStatus{LastSyncAt: now, LastSuccessAt: now.Add(-time.Minute)}, nil, false},
+		{"should be down when the last successful sync is stale", storage.SyncStatus{LastSyncAt: now, LastSuccessAt: now.Add(-time.Hour)}, nil, true},
+		{"should be down without a successful sync", storage.SyncStatus{LastSyncAt: now}, nil, true},
+		{"should be down without any sync", storage.SyncStatus{}, storage.NewError(storage.ErrNotFound, "getSyncStatus", nil), true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := storage.NewMockSyncStatusRepo(ctrl)
+			repo.EXPECT().GetSyncStatus(gomock.Any()).Return(tc.status, tc.err)
+
+			_, err := health.Sync(repo, 5*time.Minute)(context.Background())
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected an error: %v, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestFeeds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := storage.NewMockSyncStatusRepo(ctrl)
+	repo.EXPECT().GetSyncStatus(gomock.Any()).Return(storage.SyncStatus{Feeds: map[string]string{"a": "closed", "b": "open"}}, nil)
+
+	details, err := health.Feeds(repo)(context.Background())
+	if err == nil || err.Error() != "circuit not closed for feeds: b" {
+		t.Fatalf("expected the open feed to be reported, got: %v", err)
+	}
+	if feeds, ok := details.(map[string]string); !ok || feeds["a"] != "closed" {
+		t.Fatalf("expected the state of every feed, got %+v", details)
+	}
+}
@@ -0,0 +1,110 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/upstream"
+)
+
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+// Atom reads an Atom feed
+type Atom struct {
+	client *upstream.Client
+	cfg    config.Source
+}
+
+// NewAtom creates a new Atom Source
+func NewAtom(cfg config.Source, client *upstream.Client) *Atom {
+	return &Atom{
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// Name returns the configured source name
+func (s *Atom) Name() string {
+	return s.cfg.Name
+}
+
+// Fetch fetches and maps the entries of the feed. Entries without an id or a valid date are skipped
+func (s *Atom) Fetch(ctx context.Context) ([]news.NewsArticle, error) {
+	var feed atomFeed
+	if err := s.client.Get(ctx, s.cfg.Name, s.cfg.Url, decodeXML(&feed)); err != nil {
+		return nil, fmt.Errorf("could not retrieve atom feed: %w", err)
+	}
+
+	newsArticles := make([]news.NewsArticle, 0, len(feed.Entries))
+
+	for _, entry := range feed.Entries {
+		updated, ok := formatDate(strings.TrimSpace(entry.Updated), time.RFC3339)
+		if strings.TrimSpace(entry.ID) == "" || !ok {
+			continue
+		}
+
+		// published is optional in Atom, updated is not
+		published, ok := formatDate(strings.TrimSpace(entry.Published), time.RFC3339)
+		if !ok {
+			published = updated
+		}
+
+		na := news.NewsArticle{
+			Data: news.Data{
+				Id:        articleID(s.cfg.Name, strings.TrimSpace(entry.ID)),
+				Title:     entry.Title,
+				Content:   entry.Content,
+				Published: published,
+			},
+			LastUpdateDate: updated,
+		}
+
+		if entry.Content == "" {
+			na.Data.Content = entry.Summary
+		} else if entry.Summary != "" {
+			na.Data.Teaser = entry.Summary
+		}
+
+		for _, c := range entry.Categories {
+			na.Data.Type = append(na.Data.Type, c.Term)
+		}
+
+		for _, l := range entry.Links {
+			switch l.Rel {
+			case "", "alternate":
+				if na.Data.Url == "" {
+					na.Data.Url = l.Href
+				}
+			case "enclosure":
+				setMedia(&na.Data, l.Href, l.Type)
+			}
+		}
+
+		newsArticles = append(newsArticles, na)
+	}
+
+	return newsArticles, nil
+}
@@ -0,0 +1,82 @@
+package source
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/upstream"
+)
+
+// Incrowd reads the incrowd XML news list feed and enriches its articles from the article information endpoint
+type Incrowd struct {
+	client *upstream.Client
+	cfg    config.Source
+}
+
+// NewIncrowd creates a new incrowd Source
+func NewIncrowd(cfg config.Source, client *upstream.Client) *Incrowd {
+	return &Incrowd{
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// Name returns the configured source name
+func (s *Incrowd) Name() string {
+	return s.cfg.Name
+}
+
+// Fetch fetches and maps the latest news articles of the list feed
+func (s *Incrowd) Fetch(ctx context.Context) ([]news.NewsArticle, error) {
+	var newListInformation news.NewListInformation
+
+	uri := fmt.Sprintf("%s%d", s.cfg.Url, s.cfg.ArticlesPerCall)
+	if err := s.client.Get(ctx, s.cfg.Name, uri, decodeXML(&newListInformation)); err != nil {
+		return nil, fmt.Errorf("could not retrieve latest news articles: %w", err)
+	}
+
+	newsItems := newListInformation.NewsletterNewsItems.NewsletterNewsItem
+	newsArticles := make([]news.NewsArticle, 0, len(newsItems))
+
+	for i := range newsItems {
+		ni := newsItems[i]
+		newsArticle := news.NewsArticle{
+			Data: news.Data{
+				Id:          ni.NewsArticleID,
+				Published:   ni.PublishDate,
+				Title:       ni.Title,
+				Type:        news.Taxonomies(ni.Taxonomies),
+				OptaMatchId: ni.OptaMatchId,
+				Url:         ni.ArticleURL,
+			},
+			LastUpdateDate: ni.LastUpdateDate,
+			Unpublished:    strings.EqualFold(ni.IsPublished, "false"),
+		}
+		newsArticles = append(newsArticles, newsArticle)
+	}
+
+	return newsArticles, nil
+}
+
+// Enrich fetches the article information of a news article and merges it into the article's data
+func (s *Incrowd) Enrich(ctx context.Context, na news.NewsArticle) (news.NewsArticle, error) {
+	var articleInformation news.NewsArticleInformation
+
+	uri := fmt.Sprintf("%s%s", s.cfg.DetailsUrl, na.Data.Id)
+	if err := s.client.Get(ctx, s.cfg.Name, uri, decodeXML(&articleInformation)); err != nil {
+		return na, fmt.Errorf("could not retrieve news article details: %w", err)
+	}
+
+	return news.Enrich(na, articleInformation), nil
+}
+
+func decodeXML(v interface{}) func(io.Reader) error {
+	return func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(v)
+	}
+}
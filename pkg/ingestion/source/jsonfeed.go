@@ -0,0 +1,107 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/upstream"
+)
+
+type jsonFeed struct {
+	Items []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentHTML   string   `json:"content_html"`
+	ContentText   string   `json:"content_text"`
+	Summary       string   `json:"summary"`
+	Image         string   `json:"image"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified"`
+	Tags          []string `json:"tags"`
+	Attachments   []struct {
+		URL      string `json:"url"`
+		MimeType string `json:"mime_type"`
+	} `json:"attachments"`
+}
+
+// JSONFeed reads a JSON Feed (version 1 or 1.1)
+type JSONFeed struct {
+	client *upstream.Client
+	cfg    config.Source
+}
+
+// NewJSONFeed creates a new JSON Feed Source
+func NewJSONFeed(cfg config.Source, client *upstream.Client) *JSONFeed {
+	return &JSONFeed{
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// Name returns the configured source name
+func (s *JSONFeed) Name() string {
+	return s.cfg.Name
+}
+
+// Fetch fetches and maps the items of the feed. Items without a valid date_published are skipped
+func (s *JSONFeed) Fetch(ctx context.Context) ([]news.NewsArticle, error) {
+	var feed jsonFeed
+	decode := func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&feed)
+	}
+	if err := s.client.Get(ctx, s.cfg.Name, s.cfg.Url, decode); err != nil {
+		return nil, fmt.Errorf("could not retrieve json feed: %w", err)
+	}
+
+	newsArticles := make([]news.NewsArticle, 0, len(feed.Items))
+
+	for _, item := range feed.Items {
+		published, ok := formatDate(item.DatePublished, time.RFC3339)
+		if item.ID == "" || !ok {
+			continue
+		}
+
+		lastUpdateDate, ok := formatDate(item.DateModified, time.RFC3339)
+		if !ok {
+			lastUpdateDate = published
+		}
+
+		na := news.NewsArticle{
+			Data: news.Data{
+				Id:        articleID(s.cfg.Name, item.ID),
+				Title:     item.Title,
+				Type:      item.Tags,
+				Content:   item.ContentHTML,
+				Url:       item.URL,
+				ImageUrl:  item.Image,
+				Published: published,
+			},
+			LastUpdateDate: lastUpdateDate,
+		}
+
+		if na.Data.Content == "" {
+			na.Data.Content = item.ContentText
+		}
+
+		if item.Summary != "" {
+			na.Data.Teaser = item.Summary
+		}
+
+		for _, a := range item.Attachments {
+			setMedia(&na.Data, a.URL, a.MimeType)
+		}
+
+		newsArticles = append(newsArticles, na)
+	}
+
+	return newsArticles, nil
+}
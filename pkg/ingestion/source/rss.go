@@ -0,0 +1,121 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/upstream"
+)
+
+type rssFeed struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title          string   `xml:"title"`
+	Link           string   `xml:"link"`
+	Guid           string   `xml:"guid"`
+	Description    string   `xml:"description"`
+	ContentEncoded string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate        string   `xml:"pubDate"`
+	Categories     []string `xml:"category"`
+	Enclosures     []struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+}
+
+// rssDateLayouts lists the RFC 822 date variants found in the wild
+var rssDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC3339,
+}
+
+// RSS reads an RSS 2.0 feed
+type RSS struct {
+	client *upstream.Client
+	cfg    config.Source
+}
+
+// NewRSS creates a new RSS 2.0 Source
+func NewRSS(cfg config.Source, client *upstream.Client) *RSS {
+	return &RSS{
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// Name returns the configured source name
+func (s *RSS) Name() string {
+	return s.cfg.Name
+}
+
+// Fetch fetches and maps the items of the feed. Items without an identifier or a valid pubDate are skipped
+func (s *RSS) Fetch(ctx context.Context) ([]news.NewsArticle, error) {
+	var feed rssFeed
+	if err := s.client.Get(ctx, s.cfg.Name, s.cfg.Url, decodeXML(&feed)); err != nil {
+		return nil, fmt.Errorf("could not retrieve rss feed: %w", err)
+	}
+
+	newsArticles := make([]news.NewsArticle, 0, len(feed.Channel.Items))
+
+	for _, item := range feed.Channel.Items {
+		externalID := strings.TrimSpace(item.Guid)
+		if externalID == "" {
+			externalID = strings.TrimSpace(item.Link)
+		}
+
+		published, ok := formatDate(strings.TrimSpace(item.PubDate), rssDateLayouts...)
+		if externalID == "" || !ok {
+			continue
+		}
+
+		na := news.NewsArticle{
+			Data: news.Data{
+				Id:        articleID(s.cfg.Name, externalID),
+				Title:     item.Title,
+				Type:      item.Categories,
+				Content:   item.Description,
+				Url:       item.Link,
+				Published: published,
+			},
+			// RSS has no notion of updates so the publish date doubles as the last update date,
+			// edits are detected by the content hash of the article
+			LastUpdateDate: published,
+		}
+
+		if item.ContentEncoded != "" {
+			na.Data.Content = item.ContentEncoded
+			if item.Description != "" {
+				na.Data.Teaser = item.Description
+			}
+		}
+
+		for _, e := range item.Enclosures {
+			setMedia(&na.Data, e.URL, e.Type)
+		}
+
+		newsArticles = append(newsArticles, na)
+	}
+
+	return newsArticles, nil
+}
+
+// setMedia sets the first image as the article image and the first video as the article video
+func setMedia(d *news.Data, url, mimeType string) {
+	switch {
+	case strings.HasPrefix(mimeType, "image/") && d.ImageUrl == "":
+		d.ImageUrl = url
+	case strings.HasPrefix(mimeType, "video/") && d.VideoUrl == nil:
+		d.VideoUrl = url
+	}
+}
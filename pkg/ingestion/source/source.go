@@ -0,0 +1,73 @@
+package source
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/upstream"
+)
+
+// Source yields the latest news articles of an upstream feed, normalized to news.NewsArticle
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) ([]news.NewsArticle, error)
+}
+
+// Enricher is implemented by sources whose feed doesn't carry the full article. Enrich is only
+// called for new or changed articles
+type Enricher interface {
+	Enrich(ctx context.Context, na news.NewsArticle) (news.NewsArticle, error)
+}
+
+// New creates the Source described by the config. The source name identifies the feed
+// in the upstream client and must be unique
+func New(cfg config.Source, client *upstream.Client) (Source, error) {
+	if cfg.Url == "" {
+		return nil, fmt.Errorf("source %s: missing url", cfg.Name)
+	}
+
+	switch cfg.Type {
+	case "incrowd":
+		return NewIncrowd(cfg, client), nil
+	case "rss":
+		return NewRSS(cfg, client), nil
+	case "atom":
+		return NewAtom(cfg, client), nil
+	case "jsonfeed":
+		return NewJSONFeed(cfg, client), nil
+	default:
+		return nil, fmt.Errorf("source %s: unsupported type %q", cfg.Name, cfg.Type)
+	}
+}
+
+// urlNamespace is the RFC 4122 namespace for URLs
+var urlNamespace = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// articleID derives a stable uuid (v5) for an entry of a feed that has no numeric article ids.
+// The source name is part of the id so that entries of different sources never collide
+func articleID(source, externalID string) string {
+	h := sha1.New()
+	h.Write(urlNamespace[:])
+	h.Write([]byte(source + ":" + externalID))
+	u := h.Sum(nil)[:16]
+
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// formatDate parses a feed date with the first matching layout and formats it as news.DateTimeFormat in UTC
+func formatDate(value string, layouts ...string) (string, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format(news.DateTimeFormat), true
+		}
+	}
+
+	return "", false
+}
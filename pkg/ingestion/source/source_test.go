@@ -0,0 +1,163 @@
+package source_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/ingestion/source"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/upstream"
+)
+
+const rssFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <item>
+      <title>Squad numbers confirmed</title>
+      <link>https://club.example/news/squad-numbers</link>
+      <guid>https://club.example/news/squad-numbers</guid>
+      <description>Teaser text</description>
+      <content:encoded><![CDATA[<p>Body</p>]]></content:encoded>
+      <pubDate>Mon, 04 Jul 2022 13:00:00 +0100</pubDate>
+      <category>Club News</category>
+      <enclosure url="https://club.example/image.jpg" type="image/jpeg" length="1"/>
+    </item>
+    <item>
+      <title>No date</title>
+      <guid>no-date</guid>
+    </item>
+  </channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a</id>
+    <title>Squad numbers confirmed</title>
+    <link href="https://club.example/news/squad-numbers"/>
+    <link rel="enclosure" type="video/mp4" href="https://club.example/video.mp4"/>
+    <published>2022-07-04T12:00:00Z</published>
+    <updated>2022-07-04T12:30:00Z</updated>
+    <summary>Teaser text</summary>
+    <content type="html">&lt;p&gt;Body&lt;/p&gt;</content>
+    <category term="Club News"/>
+  </entry>
+</feed>`
+
+const jsonFeed = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "items": [
+    {
+      "id": "1",
+      "url": "https://club.example/news/squad-numbers",
+      "title": "Squad numbers confirmed",
+      "content_html": "<p>Body</p>",
+      "summary": "Teaser text",
+      "image": "https://club.example/image.jpg",
+      "date_published": "2022-07-04T12:00:00Z",
+      "date_modified": "2022-07-04T12:30:00Z",
+      "tags": ["Club News"]
+    }
+  ]
+}`
+
+func TestSources(t *testing.T) {
+	testCases := []struct {
+		description            string
+		sourceType             string
+		feed                   string
+		expectedLastUpdateDate string
+		expectedImageUrl       string
+		expectedVideoUrl       interface{}
+	}{
+		{
+			description:            "should map rss 2.0 items",
+			sourceType:             "rss",
+			feed:                   rssFeed,
+			expectedLastUpdateDate: "2022-07-04 12:00:00",
+			expectedImageUrl:       "https://club.example/image.jpg",
+		},
+		{
+			description:            "should map atom entries",
+			sourceType:             "atom",
+			feed:                   atomFeed,
+			expectedLastUpdateDate: "2022-07-04 12:30:00",
+			expectedVideoUrl:       "https://club.example/video.mp4",
+		},
+		{
+			description:            "should map json feed items",
+			sourceType:             "jsonfeed",
+			feed:                   jsonFeed,
+			expectedLastUpdateDate: "2022-07-04 12:30:00",
+			expectedImageUrl:       "https://club.example/image.jpg",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tc.feed))
+			}))
+			defer srv.Close()
+
+			src, err := source.New(config.Source{Name: "club", Type: tc.sourceType, Url: srv.URL}, upstream.NewClient(config.Upstream{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			articles, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(articles) != 1 {
+				t.Fatalf("expected 1 article, got %d", len(articles))
+			}
+
+			assertArticle(t, articles[0], tc.expectedLastUpdateDate, tc.expectedImageUrl, tc.expectedVideoUrl)
+		})
+	}
+}
+
+func assertArticle(t *testing.T, na news.NewsArticle, lastUpdateDate, imageUrl string, videoUrl interface{}) {
+	t.Helper()
+
+	if len(na.Data.Id) != 36 {
+		t.Fatalf("expected a uuid article id, got %q", na.Data.Id)
+	}
+
+	if na.Data.Title != "Squad numbers confirmed" || na.Data.Url != "https://club.example/news/squad-numbers" {
+		t.Fatalf("unexpected title or url: %q, %q", na.Data.Title, na.Data.Url)
+	}
+
+	if na.Data.Content != "<p>Body</p>" || na.Data.Teaser != "Teaser text" {
+		t.Fatalf("unexpected content or teaser: %q, %v", na.Data.Content, na.Data.Teaser)
+	}
+
+	if len(na.Data.Type) != 1 || na.Data.Type[0] != "Club News" {
+		t.Fatalf("unexpected type: %v", na.Data.Type)
+	}
+
+	if na.Data.Published != "2022-07-04 12:00:00" || na.LastUpdateDate != lastUpdateDate {
+		t.Fatalf("unexpected published or last update date: %s, %s", na.Data.Published, na.LastUpdateDate)
+	}
+
+	if na.Data.ImageUrl != imageUrl || na.Data.VideoUrl != videoUrl {
+		t.Fatalf("unexpected image or video url: %q, %v", na.Data.ImageUrl, na.Data.VideoUrl)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := source.New(config.Source{Name: "club", Type: "carrier-pigeon", Url: "https://club.example"}, upstream.NewClient(config.Upstream{})); err == nil {
+		t.Fatal("expected an error for an unsupported source type")
+	}
+
+	if _, err := source.New(config.Source{Name: "club", Type: "rss"}, upstream.NewClient(config.Upstream{})); err == nil {
+		t.Fatal("expected an error for a source without url")
+	}
+}
@@ -0,0 +1,387 @@
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/ingestion/source"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/tracing"
+	"com.thanos/pkg/upstream"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Club groups the sources of a single club
+type Club struct {
+	Name    string
+	TeamId  string
+	Sources []source.Source
+}
+
+// SyncResult is the breakdown of a sync across all sources
+type SyncResult struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+	Withdrawn int
+	Failed    int
+	Sources   []SourceResult
+}
+
+// SourceResult is the breakdown of a sync of a single source. Failed counts the articles that
+// could not be enriched, Err is set when the source itself could not be synced
+type SourceResult struct {
+	Club      string
+	Source    string
+	Fetched   int
+	Inserted  int
+	Updated   int
+	Unchanged int
+	Withdrawn int
+	Failed    int
+	Err       error
+}
+
+// Observer is notified of the outcome of the sync of every source, e.g. to record metrics
+type Observer interface {
+	ObserveSource(SourceResult)
+}
+
+// Syncer fetches the latest news articles of every club from their upstream sources and stores them
+type Syncer struct {
+	clubs      []Club
+	repository storage.DBRepo
+	revisions  storage.RevisionRepo
+	status     storage.SyncStatusRepo
+	client     *upstream.Client
+	observer   Observer
+	publisher  events.Publisher
+	log        *logger.Logger
+}
+
+// NewSyncer creates a new Syncer. client is the upstream client of the sources, the circuit state of its
+// feeds is stored along with the status of every sync. observer is notified of every synced source and
+// publisher of every stored change
+func NewSyncer(
+	repo storage.DBRepo,
+	revisions storage.RevisionRepo,
+	status storage.SyncStatusRepo,
+	client *upstream.Client,
+	observer Observer,
+	publisher events.Publisher,
+	clubs []Club,
+	l *logger.Logger,
+) *Syncer {
+	return &Syncer{
+		clubs:      clubs,
+		repository: repo,
+		revisions:  revisions,
+		status:     status,
+		client:     client,
+		observer:   observer,
+		publisher:  publisher,
+		log:        l,
+	}
+}
+
+// NewClubs creates the clubs and their sources from their configuration. Source names must be unique
+// across all clubs since they identify the articles of a source
+func NewClubs(cfg []config.Club, client *upstream.Client) ([]Club, error) {
+	sourceNames := make(map[string]bool)
+
+	clubs := make([]Club, 0, len(cfg))
+	for _, cc := range cfg {
+		club := Club{Name: cc.Name, TeamId: cc.TeamId}
+		for _, sc := range cc.Sources {
+			if sourceNames[sc.Name] {
+				return nil, fmt.Errorf("duplicate news source name %s", sc.Name)
+			}
+			sourceNames[sc.Name] = true
+
+			src, err := source.New(sc, client)
+			if err != nil {
+				return nil, fmt.Errorf("invalid news source configuration of club %s, %w", cc.Name, err)
+			}
+			club.Sources = append(club.Sources, src)
+		}
+		clubs = append(clubs, club)
+	}
+
+	return clubs, nil
+}
+
+// Run syncs news articles once immediately and then on every interval until the context is cancelled
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sync(ctx); err != nil {
+			s.log.WithError(err).Error("news articles sync failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync stores the new or changed news articles of every club source, stamped with their club.
+// A failing source doesn't prevent the articles of the others from being stored.
+// The sync is successful when every source was synced, its status is stored either way
+func (s *Syncer) Sync(ctx context.Context) (_ *SyncResult, err error) {
+	ctx, span := tracing.Start(ctx, "ingestion.Sync")
+	defer func() { tracing.End(span, err) }()
+
+	result := &SyncResult{}
+	var failed []string
+
+	for _, club := range s.clubs {
+		for _, src := range club.Sources {
+			sr := s.syncSource(ctx, club, src)
+			s.observer.ObserveSource(sr)
+
+			result.Inserted += sr.Inserted
+			result.Updated += sr.Updated
+			result.Unchanged += sr.Unchanged
+			result.Withdrawn += sr.Withdrawn
+			result.Failed += sr.Failed
+			result.Sources = append(result.Sources, sr)
+
+			fields := logrus.Fields{
+				"club":      club.Name,
+				"source":    src.Name(),
+				"fetched":   sr.Fetched,
+				"inserted":  sr.Inserted,
+				"updated":   sr.Updated,
+				"unchanged": sr.Unchanged,
+				"withdrawn": sr.Withdrawn,
+				"failed":    sr.Failed,
+			}
+
+			if sr.Err != nil {
+				s.log.WithContext(ctx).WithError(sr.Err).WithFields(fields).Error("could not sync news articles")
+				failed = append(failed, club.Name+"/"+src.Name())
+				continue
+			}
+			s.log.WithContext(ctx).WithFields(fields).Info("synced news articles")
+		}
+	}
+
+	s.storeStatus(ctx, len(failed) == 0)
+
+	if len(failed) > 0 {
+		return result, fmt.Errorf("could not sync news articles of sources: %s", strings.Join(failed, ", "))
+	}
+
+	return result, nil
+}
+
+// storeStatus stores the outcome of a sync along with the circuit state of the upstream feeds.
+// A sync status that can't be stored doesn't fail the sync
+func (s *Syncer) storeStatus(ctx context.Context, success bool) {
+	status := storage.SyncStatus{LastSyncAt: time.Now().UTC(), Feeds: make(map[string]string)}
+	if success {
+		status.LastSuccessAt = status.LastSyncAt
+	}
+	for feed, state := range s.client.States() {
+		status.Feeds[feed] = string(state)
+	}
+
+	if err := s.status.SetSyncStatus(ctx, status); err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("could not store the sync status")
+	}
+}
+
+// syncSource stores the new or changed news articles of a source. Sources that implement source.Enricher
+// publish a real LastUpdateDate, their articles whose LastUpdateDate matches the stored one are unchanged and
+// the rest get enriched, articles that fail to be enriched are skipped so that they get retried on the next sync.
+// Finally articles whose content hash matches the stored one are unchanged and only get their LastUpdateDate updated.
+// Every stored article gets a new revision recorded and an event published.
+// Articles that are unpublished upstream, or that are missing from the feed although they were published
+// after its oldest article, are withdrawn. Withdrawn articles that show up again are stored anew
+func (s *Syncer) syncSource(ctx context.Context, club Club, src source.Source) (sr SourceResult) {
+	ctx, span := tracing.Start(ctx, "ingestion.syncSource", trace.WithAttributes(
+		attribute.String("ingestion.club", club.Name),
+		attribute.String("ingestion.source", src.Name()),
+	))
+	defer func() { tracing.End(span, sr.Err) }()
+
+	sr = SourceResult{Club: club.Name, Source: src.Name()}
+
+	newsArticles, err := src.Fetch(ctx)
+	if err != nil {
+		sr.Err = err
+		return sr
+	}
+	sr.Fetched = len(newsArticles)
+
+	ids := make([]string, len(newsArticles))
+	for i := range newsArticles {
+		ids[i] = newsArticles[i].Data.Id
+	}
+
+	fingerprints, err := s.repository.GetFingerprints(ctx, club.Name, ids)
+	if err != nil {
+		sr.Err = fmt.Errorf("could not retrieve stored fingerprints: %w", err)
+		return sr
+	}
+
+	enricher, enrich := src.(source.Enricher)
+	changed := make([]news.NewsArticle, 0, len(newsArticles))
+	changes := make([]events.Event, 0, len(newsArticles))
+	touched := make(map[string]string)
+
+	var unpublished []string
+	now := time.Now().UTC()
+
+	for i := range newsArticles {
+		na := newsArticles[i]
+		fp, stored := fingerprints[na.Data.Id]
+		if na.Unpublished {
+			if stored && fp.Withdrawn == nil {
+				unpublished = append(unpublished, na.Data.Id)
+			}
+			continue
+		}
+
+		na.Source = src.Name()
+		restored := stored && fp.Withdrawn != nil
+		// Feeds without update dates only tell an edit apart by the content hash
+		if enrich && stored && !restored && fp.LastUpdateDate == na.LastUpdateDate {
+			sr.Unchanged++
+			continue
+		}
+
+		if enrich {
+			if na, err = enricher.Enrich(ctx, na); err != nil {
+				s.log.WithContext(ctx).WithError(err).Errorf("could not retrieve details of news article %s", na.Data.Id)
+				sr.Failed++
+				continue
+			}
+		}
+
+		na.Club = club.Name
+		na.Data.TeamId = club.TeamId
+		na.ContentHash = na.Data.Hash()
+		na.Revision = fp.Revision + 1
+
+		change := events.Event{Type: events.ArticleUpdated, At: now, ArticleID: na.Data.Id, Club: club.Name}
+		switch {
+		case !stored:
+			sr.Inserted++
+			change.Type = events.ArticleCreated
+		case !restored && fp.ContentHash == na.ContentHash:
+			sr.Unchanged++
+			if fp.LastUpdateDate != na.LastUpdateDate {
+				touched[na.Data.Id] = na.LastUpdateDate
+			}
+			continue
+		default:
+			sr.Updated++
+		}
+
+		data := na.Data
+		change.Data = &data
+		changed = append(changed, na)
+		changes = append(changes, change)
+	}
+
+	if len(changed) > 0 {
+		if _, err := s.repository.BulkInsert(ctx, changed); err != nil {
+			sr.Inserted, sr.Updated = 0, 0
+			sr.Err = fmt.Errorf("bulkInsert operation failed: %w", err)
+			return sr
+		}
+		s.publisher.Publish(changes...)
+
+		if err := s.revisions.AddRevisions(ctx, changed); err != nil {
+			s.log.WithContext(ctx).WithError(err).Error("could not store revisions of changed news articles")
+		}
+	}
+
+	if err := s.repository.SetLastUpdateDates(ctx, club.Name, touched); err != nil {
+		s.log.WithContext(ctx).WithError(err).Warn("could not update last update dates of unchanged news articles")
+	}
+
+	s.withdraw(ctx, &sr, club, unpublished, news.WithdrawnUnpublished)
+	s.withdraw(ctx, &sr, club, s.missingArticles(ctx, club, src, newsArticles), news.WithdrawnDeleted)
+
+	return sr
+}
+
+// missingArticles returns the ids of the stored articles of a club's source that are missing from its feed,
+// although they were published after the oldest article of the feed and should therefore appear in it
+func (s *Syncer) missingArticles(ctx context.Context, club Club, src source.Source, newsArticles []news.NewsArticle) []string {
+	var oldest time.Time
+	inFeed := make(map[string]bool, len(newsArticles))
+
+	for _, na := range newsArticles {
+		inFeed[na.Data.Id] = true
+
+		published, err := time.Parse(news.DateTimeFormat, na.Data.Published)
+		if err != nil {
+			// without a reliable window, no article can be considered missing
+			return nil
+		}
+		if oldest.IsZero() || published.Before(oldest) {
+			oldest = published
+		}
+	}
+
+	if oldest.IsZero() {
+		return nil
+	}
+
+	ids, err := s.repository.GetSourceArticleIDs(ctx, club.Name, src.Name(), oldest)
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Warn("could not retrieve stored news articles to detect deleted ones")
+		return nil
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if !inFeed[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing
+}
+
+// withdraw marks the given articles of a club as withdrawn for the given reason
+func (s *Syncer) withdraw(ctx context.Context, sr *SourceResult, club Club, ids []string, reason string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	withdrawn, err := s.repository.WithdrawArticles(ctx, club.Name, ids, reason)
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Warnf("could not withdraw %s news articles", reason)
+		return
+	}
+	sr.Withdrawn += len(withdrawn)
+
+	withdrawal := news.Withdrawal{At: time.Now().UTC(), Reason: reason}
+	changes := make([]events.Event, len(withdrawn))
+	for i, id := range withdrawn {
+		changes[i] = events.Event{
+			Type:      events.ArticleWithdrawn,
+			At:        withdrawal.At,
+			ArticleID: id,
+			Club:      club.Name,
+			Withdrawn: &withdrawal,
+		}
+	}
+	s.publisher.Publish(changes...)
+}
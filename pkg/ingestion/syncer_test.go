@@ -0,0 +1,368 @@
+package ingestion_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/ingestion"
+	"com.thanos/pkg/ingestion/source"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/upstream"
+	"github.com/golang/mock/gomock"
+)
+
+func TestSyncer_Sync(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		bytez, err := os.ReadFile("../../news.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 645078, the first article of the feed, got unpublished upstream
+		bytez = bytes.Replace(bytez, []byte("<IsPublished>True"), []byte("<IsPublished>False"), 1)
+		_, _ = w.Write(bytez)
+	})
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../../single_article.xml")
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Upstream.RateLimit = 0
+
+	client := upstream.NewClient(cfg.Upstream)
+	src, err := source.New(config.Source{
+		Name:            "incrowd",
+		Type:            "incrowd",
+		Url:             srv.URL + "/list?count=",
+		DetailsUrl:      srv.URL + "/article?id=",
+		ArticlesPerCall: 50,
+	}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 645067 was stored before with a different last update date but its content didn't change
+	unchanged := enrichedArticle(t, news.Data{
+		Id:          "645067",
+		Published:   "2022-07-03 15:00:00",
+		Title:       "PA to Director of Football and Head Coach role available",
+		OptaMatchId: "",
+		Url:         "https://www.brentfordfc.com/news/2022/july/pa-to-director-of-football-and-head-coach-role/",
+		TeamId:      "t94",
+	})
+
+	ctrl := gomock.NewController(t)
+	dbrepo := storage.NewMockDBRepo(ctrl)
+
+	dbrepo.EXPECT().
+		GetFingerprints(gomock.Any(), "brentford", gomock.Any()).
+		Return(map[string]storage.Fingerprint{
+			// unpublished upstream, must be withdrawn
+			"645078": {LastUpdateDate: "2022-07-04 07:24:35"},
+			"645067": {LastUpdateDate: "2022-07-03 14:00:00", ContentHash: unchanged.Hash()},
+			// changed content
+			"645062": {LastUpdateDate: "2022-07-04 01:00:00", ContentHash: "stale", Revision: 1},
+			// withdrawn before but back in the feed, must be stored anew
+			"643775": {
+				LastUpdateDate: "2022-07-03 13:00:10",
+				ContentHash:    "stale",
+				Revision:       1,
+				Withdrawn:      &news.Withdrawal{Reason: news.WithdrawnDeleted},
+			},
+		}, nil)
+	dbrepo.EXPECT().
+		BulkInsert(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, articles []news.NewsArticle) (*storage.BulkInsertResult, error) {
+			if len(articles) != 48 {
+				t.Fatalf("expected 48 new, changed or restored articles, got %d", len(articles))
+			}
+
+			for _, a := range articles {
+				if a.Data.Id == "645078" || a.Data.Id == "645067" {
+					t.Fatalf("unpublished or unchanged article %s should not be stored", a.Data.Id)
+				}
+				if a.Source != "incrowd" {
+					t.Fatalf("article %s should be stamped with its source", a.Data.Id)
+				}
+				if a.Club != "brentford" || a.Data.TeamId != "t94" {
+					t.Fatalf("article %s should be stamped with its club", a.Data.Id)
+				}
+				if a.Data.Content == "" {
+					t.Fatalf("article %s should be enriched with its body text", a.Data.Id)
+				}
+				if a.ContentHash != a.Data.Hash() {
+					t.Fatalf("article %s should carry its content hash", a.Data.Id)
+				}
+			}
+
+			return &storage.BulkInsertResult{UpsertedCount: 46, ModifiedCount: 2}, nil
+		})
+
+	revisions := storage.NewMockRevisionRepo(ctrl)
+	revisions.EXPECT().
+		AddRevisions(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, articles []news.NewsArticle) error {
+			for _, a := range articles {
+				expected := 1
+				if a.Data.Id == "645062" || a.Data.Id == "643775" {
+					expected = 2
+				}
+				if a.Revision != expected {
+					t.Fatalf("expected article %s to be at revision %d, got %d", a.Data.Id, expected, a.Revision)
+				}
+			}
+			return nil
+		})
+	dbrepo.EXPECT().
+		SetLastUpdateDates(gomock.Any(), "brentford", map[string]string{"645067": "2022-07-04 11:15:04"}).
+		Return(nil)
+	dbrepo.EXPECT().
+		WithdrawArticles(gomock.Any(), "brentford", []string{"645078"}, news.WithdrawnUnpublished).
+		Return([]string{"645078"}, nil)
+	// 640000 and 640001 were published after the oldest article of the feed but are missing from it,
+	// 640001 was withdrawn in the meantime
+	dbrepo.EXPECT().
+		GetSourceArticleIDs(gomock.Any(), "brentford", "incrowd", gomock.Any()).
+		Return([]string{"645078", "645062", "640000", "640001"}, nil)
+	dbrepo.EXPECT().
+		WithdrawArticles(gomock.Any(), "brentford", []string{"640000", "640001"}, news.WithdrawnDeleted).
+		Return([]string{"640000"}, nil)
+
+	var status storage.SyncStatus
+	syncStatus := storage.NewMockSyncStatusRepo(ctrl)
+	syncStatus.EXPECT().
+		SetSyncStatus(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, s storage.SyncStatus) { status = s }).
+		Return(nil)
+
+	bus := events.NewBus(0)
+	sub, _ := bus.Subscribe(0)
+
+	s := ingestion.NewSyncer(
+		dbrepo,
+		revisions,
+		syncStatus,
+		client,
+		metrics.New().Ingestion,
+		bus,
+		[]ingestion.Club{{Name: "brentford", TeamId: "t94", Sources: []source.Source{src}}},
+		logger.NewLogger(cfg.Logger, logger.DisableOutput()),
+	)
+
+	result, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.LastSuccessAt.IsZero() || !status.LastSuccessAt.Equal(status.LastSyncAt) || status.Feeds["incrowd"] != string(upstream.StateClosed) {
+		t.Fatalf("expected a successful sync status, got %+v", status)
+	}
+
+	if result.Inserted != 46 || result.Updated != 2 || result.Unchanged != 1 || result.Withdrawn != 2 || result.Failed != 0 {
+		t.Fatalf("unexpected sync result: %+v", result)
+	}
+
+	if len(result.Sources) != 1 || result.Sources[0].Fetched != 50 {
+		t.Fatalf("unexpected source results: %+v", result.Sources)
+	}
+
+	bus.Close()
+	published := make(map[events.Type]int)
+	for e := range sub.Events() {
+		published[e.Type]++
+		if e.Club != "brentford" || e.ArticleID == "" {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+		if e.Type == events.ArticleUpdated && e.Data == nil {
+			t.Fatalf("expected the updated article in its event: %+v", e)
+		}
+	}
+	if published[events.ArticleCreated] != 46 || published[events.ArticleUpdated] != 2 || published[events.ArticleWithdrawn] != 2 {
+		t.Fatalf("unexpected published events: %+v", published)
+	}
+}
+
+// enrichedArticle returns the data the syncer stores for an article of news.xml,
+// given that every article's details are those of single_article.xml
+func enrichedArticle(t *testing.T, d news.Data) news.Data {
+	t.Helper()
+
+	bytez, err := os.ReadFile("../../single_article.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info news.NewsArticleInformation
+	if err := xml.Unmarshal(bytez, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	return news.Enrich(news.NewsArticle{Data: d}, info).Data
+}
+
+func TestSyncer_SyncEditedRSSItem(t *testing.T) {
+	body := "<p>Original body</p>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0"?>
+<rss version="2.0">
+  <channel>
+    <item>
+      <title>Squad numbers confirmed</title>
+      <link>https://club.example/news/squad-numbers</link>
+      <guid>https://club.example/news/squad-numbers</guid>
+      <description><![CDATA[%s]]></description>
+      <pubDate>Mon, 04 Jul 2022 13:00:00 +0100</pubDate>
+    </item>
+  </channel>
+</rss>`, body)
+	}))
+	defer srv.Close()
+
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Upstream.RateLimit = 0
+
+	client := upstream.NewClient(cfg.Upstream)
+	src, err := source.New(config.Source{Name: "club-rss", Type: "rss", Url: srv.URL}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The item as it was stored before its body was edited, RSS items keep their pubDate when edited
+	articles, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("expected 1 article, got %d", len(articles))
+	}
+	original := articles[0]
+	original.Data.TeamId = "t94"
+
+	body = "<p>Edited body</p>"
+
+	ctrl := gomock.NewController(t)
+	dbrepo := storage.NewMockDBRepo(ctrl)
+
+	dbrepo.EXPECT().
+		GetFingerprints(gomock.Any(), "brentford", []string{original.Data.Id}).
+		Return(map[string]storage.Fingerprint{
+			original.Data.Id: {LastUpdateDate: original.LastUpdateDate, ContentHash: original.Data.Hash(), Revision: 1},
+		}, nil)
+	dbrepo.EXPECT().
+		BulkInsert(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, articles []news.NewsArticle) (*storage.BulkInsertResult, error) {
+			if len(articles) != 1 || articles[0].Data.Content != body {
+				t.Fatalf("expected the edited article to be stored, got %+v", articles)
+			}
+
+			return &storage.BulkInsertResult{ModifiedCount: 1}, nil
+		})
+	dbrepo.EXPECT().
+		SetLastUpdateDates(gomock.Any(), "brentford", map[string]string{}).
+		Return(nil)
+	dbrepo.EXPECT().
+		GetSourceArticleIDs(gomock.Any(), "brentford", "club-rss", gomock.Any()).
+		Return([]string{original.Data.Id}, nil)
+
+	revisions := storage.NewMockRevisionRepo(ctrl)
+	revisions.EXPECT().
+		AddRevisions(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, articles []news.NewsArticle) error {
+			if len(articles) != 1 || articles[0].Revision != 2 {
+				t.Fatalf("expected the edited article to be stored as revision 2, got %+v", articles)
+			}
+			return nil
+		})
+
+	syncStatus := storage.NewMockSyncStatusRepo(ctrl)
+	syncStatus.EXPECT().SetSyncStatus(gomock.Any(), gomock.Any()).Return(nil)
+
+	s := ingestion.NewSyncer(
+		dbrepo,
+		revisions,
+		syncStatus,
+		client,
+		metrics.New().Ingestion,
+		events.NewBus(0),
+		[]ingestion.Club{{Name: "brentford", TeamId: "t94", Sources: []source.Source{src}}},
+		logger.NewLogger(cfg.Logger, logger.DisableOutput()),
+	)
+
+	result, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Updated != 1 || result.Unchanged != 0 {
+		t.Fatalf("expected the edited item to be updated, got: %+v", result)
+	}
+}
+
+func TestSyncer_SyncUpstreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	cfg, err := config.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Upstream.MaxRetries = 0
+
+	client := upstream.NewClient(cfg.Upstream)
+	src, err := source.New(config.Source{Name: "incrowd", Type: "incrowd", Url: srv.URL + "/list?count="}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	dbrepo := storage.NewMockDBRepo(ctrl)
+
+	var status storage.SyncStatus
+	syncStatus := storage.NewMockSyncStatusRepo(ctrl)
+	syncStatus.EXPECT().
+		SetSyncStatus(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, s storage.SyncStatus) { status = s }).
+		Return(nil)
+
+	s := ingestion.NewSyncer(
+		dbrepo,
+		storage.NewMockRevisionRepo(ctrl),
+		syncStatus,
+		client,
+		metrics.New().Ingestion,
+		events.NewBus(0),
+		[]ingestion.Club{{Name: "brentford", TeamId: "t94", Sources: []source.Source{src}}},
+		logger.NewLogger(cfg.Logger, logger.DisableOutput()),
+	)
+
+	if _, err := s.Sync(context.Background()); err == nil {
+		t.Fatal("expected an error when the upstream feed fails")
+	}
+
+	if status.LastSyncAt.IsZero() || !status.LastSuccessAt.IsZero() {
+		t.Fatalf("expected a failed sync status, got %+v", status)
+	}
+}
@@ -12,12 +12,13 @@ type Logger struct {
 	*logrus.Entry
 }
 
-// NewLogger creates a new logger
+// NewLogger creates a new logger. Entries logged with a context get the ids of its span as trace.id and span.id
 func NewLogger(cfg config.Logger, options ...Option) *Logger {
 	l := logrus.New()
 	l.SetLevel(logrus.Level(cfg.LogLevel))
 	l.SetReportCaller(false)
 	l.SetFormatter(&logrus.JSONFormatter{})
+	l.AddHook(traceHook{})
 
 	entry := l.WithFields(logrus.Fields{
 		"application":           cfg.AppName,
@@ -28,6 +29,10 @@ func NewLogger(cfg config.Logger, options ...Option) *Logger {
 		Entry: entry,
 	}
 
+	for _, option := range options {
+		option(&logger)
+	}
+
 	return &logger
 }
 
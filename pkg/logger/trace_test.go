@@ -0,0 +1,57 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLogger_TraceIDs(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	testCases := []struct {
+		description string
+		ctx         context.Context
+		traceID     interface{}
+		spanID      interface{}
+	}{
+		{"should log the ids of the span of the context", ctx, traceID.String(), spanID.String()},
+		{"should not log ids without a span", context.Background(), nil, nil},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := logger.NewLogger(config.Logger{LogLevel: 6})
+			l.Logger.SetOutput(&buf)
+
+			entry := l.WithContext(tc.ctx)
+			entry.Info("test")
+
+			var fields map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+				t.Fatal(err)
+			}
+
+			if fields["trace.id"] != tc.traceID || fields["span.id"] != tc.spanID {
+				t.Fatalf("expected trace.id %v and span.id %v, got %v and %v", tc.traceID, tc.spanID, fields["trace.id"], fields["span.id"])
+			}
+
+			if _, ok := entry.Data["trace.id"]; ok {
+				t.Fatal("expected the fields of the entry not to be modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Cache are the metrics of the response cache of the api
+type Cache struct {
+	hits          *prometheus.CounterVec
+	misses        *prometheus.CounterVec
+	entries       prometheus.Gauge
+	invalidations prometheus.Counter
+}
+
+func newCache() *Cache {
+	counter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      name,
+			Help:      help,
+		}, []string{"operation"})
+	}
+
+	return &Cache{
+		hits:   counter("hits_total", "Number of storage reads served by the cache, by operation."),
+		misses: counter("misses_total", "Number of storage reads missing the cache, by operation. Concurrent misses share a single read."),
+		entries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "entries",
+			Help:      "Number of cached storage reads.",
+		}),
+		invalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "invalidations_total",
+			Help:      "Number of times the cache was invalidated by a change of the stored articles.",
+		}),
+	}
+}
+
+func (c *Cache) register(r prometheus.Registerer) {
+	r.MustRegister(c.hits, c.misses, c.entries, c.invalidations)
+}
+
+// Hit records a read of operation served by the cache
+func (c *Cache) Hit(operation string) {
+	c.hits.WithLabelValues(operation).Inc()
+}
+
+// Miss records a read of operation missing the cache
+func (c *Cache) Miss(operation string) {
+	c.misses.WithLabelValues(operation).Inc()
+}
+
+// SetEntries records the number of cached reads
+func (c *Cache) SetEntries(n int) {
+	c.entries.Set(float64(n))
+}
+
+// Invalidated records an invalidation of the cache
+func (c *Cache) Invalidated() {
+	c.invalidations.Inc()
+}
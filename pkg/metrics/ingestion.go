@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"time"
+
+	"com.thanos/pkg/ingestion"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Ingestion are the metrics of the syncs of the news articles, per club source
+type Ingestion struct {
+	fetched     *prometheus.CounterVec
+	inserted    *prometheus.CounterVec
+	updated     *prometheus.CounterVec
+	unchanged   *prometheus.CounterVec
+	withdrawn   *prometheus.CounterVec
+	failed      *prometheus.CounterVec
+	syncErrors  *prometheus.CounterVec
+	lastSuccess *prometheus.GaugeVec
+}
+
+func newIngestion() *Ingestion {
+	counter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ingestion",
+			Name:      name,
+			Help:      help,
+		}, []string{"club", "source"})
+	}
+
+	return &Ingestion{
+		fetched:    counter("articles_fetched_total", "Number of articles fetched from upstream, by club and source."),
+		inserted:   counter("articles_inserted_total", "Number of new articles stored, by club and source."),
+		updated:    counter("articles_updated_total", "Number of changed articles stored, by club and source."),
+		unchanged:  counter("articles_unchanged_total", "Number of fetched articles that didn't change, by club and source."),
+		withdrawn:  counter("articles_withdrawn_total", "Number of articles withdrawn upstream, by club and source."),
+		failed:     counter("articles_failed_total", "Number of articles that could not be enriched, by club and source."),
+		syncErrors: counter("sync_errors_total", "Number of syncs of a source that failed, by club and source."),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "ingestion",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time of the last successful sync, by club and source.",
+		}, []string{"club", "source"}),
+	}
+}
+
+func (i *Ingestion) register(r prometheus.Registerer) {
+	r.MustRegister(i.fetched, i.inserted, i.updated, i.unchanged, i.withdrawn, i.failed, i.syncErrors, i.lastSuccess)
+}
+
+// ObserveSource records the outcome of the sync of a source
+func (i *Ingestion) ObserveSource(s ingestion.SourceResult) {
+	i.fetched.WithLabelValues(s.Club, s.Source).Add(float64(s.Fetched))
+	i.inserted.WithLabelValues(s.Club, s.Source).Add(float64(s.Inserted))
+	i.updated.WithLabelValues(s.Club, s.Source).Add(float64(s.Updated))
+	i.unchanged.WithLabelValues(s.Club, s.Source).Add(float64(s.Unchanged))
+	i.withdrawn.WithLabelValues(s.Club, s.Source).Add(float64(s.Withdrawn))
+	i.failed.WithLabelValues(s.Club, s.Source).Add(float64(s.Failed))
+
+	if s.Err != nil {
+		i.syncErrors.WithLabelValues(s.Club, s.Source).Inc()
+		return
+	}
+	i.lastSuccess.WithLabelValues(s.Club, s.Source).Set(float64(time.Now().Unix()))
+}
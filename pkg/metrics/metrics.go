@@ -0,0 +1,50 @@
+// Package metrics exposes the prometheus metrics of the api and the fetcher. Metric names and labels are
+// relied upon by dashboards and alerts, they must not change
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "news"
+
+// Metrics are the collectors of a process, registered on their own registry
+type Metrics struct {
+	registry  *prometheus.Registry
+	HTTP      *HTTP
+	Storage   *Storage
+	Ingestion *Ingestion
+	Cache     *Cache
+}
+
+// New creates and registers every collector, along with the go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry:  prometheus.NewRegistry(),
+		HTTP:      newHTTP(),
+		Storage:   newStorage(),
+		Ingestion: newIngestion(),
+		Cache:     newCache(),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m.HTTP.register(m.registry)
+	m.Storage.register(m.registry)
+	m.Ingestion.register(m.registry)
+	m.Cache.register(m.registry)
+
+	return m
+}
+
+// Handler serves the metrics in the prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
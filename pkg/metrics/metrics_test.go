@@ -0,0 +1,116 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/ingestion"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/storage/memory"
+	"com.thanos/pkg/storage/storagetest"
+	"github.com/go-chi/chi"
+)
+
+func TestHTTP_Middleware(t *testing.T) {
+	m := metrics.New()
+
+	r := chi.NewRouter()
+	r.Use(m.HTTP.Middleware)
+	r.Get("/article/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/version", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/article/1", "/article/2", "/version", "/unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expectMetrics(t, m,
+		`news_http_requests_total{code="404",method="GET",route="/article/{id}"} 2`,
+		`news_http_requests_total{code="200",method="GET",route="/version"} 1`,
+		`news_http_requests_total{code="404",method="GET",route="unmatched"} 1`,
+		`news_http_request_duration_seconds_count{method="GET",route="/article/{id}"} 2`,
+	)
+}
+
+func TestStorage_DBRepo(t *testing.T) {
+	m := metrics.New()
+	repo := m.Storage.DBRepo("memory", memory.NewRepository(time.Hour))
+
+	storagetest.TestDBRepo(t, repo)
+
+	if _, err := repo.GetArticleByID(context.Background(), "", "missing"); err == nil {
+		t.Fatal("expected a not found error")
+	}
+
+	expectMetrics(t, m,
+		`news_storage_operation_errors_total{driver="memory",kind="not_found",operation="getArticleByID"}`,
+		`news_storage_operation_duration_seconds_count{driver="memory",operation="bulkInsert"}`,
+	)
+}
+
+func TestStorage_RevisionRepo(t *testing.T) {
+	m := metrics.New()
+
+	storagetest.TestRevisionRepo(t, m.Storage.RevisionRepo("memory", memory.NewRevisionRepository(time.Hour)))
+
+	expectMetrics(t, m,
+		`news_storage_operation_duration_seconds_count{driver="memory",operation="addRevisions"}`,
+		`news_storage_operation_duration_seconds_count{driver="memory",operation="getRevisions"}`,
+	)
+}
+
+func TestIngestion_ObserveSource(t *testing.T) {
+	m := metrics.New()
+
+	m.Ingestion.ObserveSource(ingestion.SourceResult{Club: "brentford", Source: "incrowd", Fetched: 50, Inserted: 46, Updated: 2, Unchanged: 1, Failed: 1})
+	m.Ingestion.ObserveSource(ingestion.SourceResult{Club: "brentford", Source: "incrowd", Fetched: 50, Unchanged: 50})
+	m.Ingestion.ObserveSource(ingestion.SourceResult{Club: "brentford", Source: "rss", Err: errors.New("upstream unavailable")})
+
+	expectMetrics(t, m,
+		`news_ingestion_articles_fetched_total{club="brentford",source="incrowd"} 100`,
+		`news_ingestion_articles_inserted_total{club="brentford",source="incrowd"} 46`,
+		`news_ingestion_articles_updated_total{club="brentford",source="incrowd"} 2`,
+		`news_ingestion_articles_unchanged_total{club="brentford",source="incrowd"} 51`,
+		`news_ingestion_articles_failed_total{club="brentford",source="incrowd"} 1`,
+		`news_ingestion_sync_errors_total{club="brentford",source="rss"} 1`,
+		`news_ingestion_last_success_timestamp_seconds{club="brentford",source="incrowd"}`,
+	)
+
+	if body := scrape(t, m); strings.Contains(body, `news_ingestion_last_success_timestamp_seconds{club="brentford",source="rss"}`) {
+		t.Fatal("expected no last success of a failed source")
+	}
+}
+
+// expectMetrics fails the test unless every expected sample is served by m, samples without a value only
+// need to be present
+func expectMetrics(t *testing.T, m *metrics.Metrics, expected ...string) {
+	t.Helper()
+
+	body := scrape(t, m)
+	for _, e := range expected {
+		if !strings.Contains(body, e) {
+			t.Errorf("expected metric %s, got:\n%s", e, body)
+		}
+	}
+}
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, err := io.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
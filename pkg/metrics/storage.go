@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errorKinds label storage errors by their kind, errors of an unknown kind are labelled unknown
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{storage.ErrNotFound, "not_found"},
+	{storage.ErrConflict, "conflict"},
+	{storage.ErrUnavailable, "unavailable"},
+	{storage.ErrTimeout, "timeout"},
+	{storage.ErrInvalidQuery, "invalid_query"},
+}
+
+// Storage are the metrics of the storage operations
+type Storage struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+func newStorage() *Storage {
+	return &Storage{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of the storage operations by storage driver and operation.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"driver", "operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_errors_total",
+			Help:      "Number of failed storage operations by storage driver, operation and error kind.",
+		}, []string{"driver", "operation", "kind"}),
+	}
+}
+
+func (s *Storage) register(r prometheus.Registerer) {
+	r.MustRegister(s.duration, s.errors)
+}
+
+// observe records the latency of an operation that started at start, and its error if it failed
+func (s *Storage) observe(driver, operation string, start time.Time, err error) {
+	s.duration.WithLabelValues(driver, operation).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		return
+	}
+
+	kind := "unknown"
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			kind = k.kind
+			break
+		}
+	}
+	s.errors.WithLabelValues(driver, operation, kind).Inc()
+}
+
+// DBRepo returns a repository observing every operation of repo, labelled with its storage driver
+func (s *Storage) DBRepo(driver string, repo storage.DBRepo) storage.DBRepo {
+	return &dbRepo{repo: repo, driver: driver, storage: s}
+}
+
+// RevisionRepo returns a repository observing every operation of repo, labelled with its storage driver
+func (s *Storage) RevisionRepo(driver string, repo storage.RevisionRepo) storage.RevisionRepo {
+	return &revisionRepo{repo: repo, driver: driver, storage: s}
+}
+
+type dbRepo struct {
+	repo    storage.DBRepo
+	driver  string
+	storage *Storage
+}
+
+func (r *dbRepo) GetArticleByID(ctx context.Context, club, id string) (res storage.Result, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "getArticleByID", start, err) }(time.Now())
+	return r.repo.GetArticleByID(ctx, club, id)
+}
+
+func (r *dbRepo) GetNewsPage(ctx context.Context, q storage.PageQuery) (page storage.Page, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "getNewsPage", start, err) }(time.Now())
+	return r.repo.GetNewsPage(ctx, q)
+}
+
+func (r *dbRepo) StreamNews(ctx context.Context, q storage.PageQuery, fn func(storage.Result) error) (err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "streamNews", start, err) }(time.Now())
+	return r.repo.StreamNews(ctx, q, fn)
+}
+
+func (r *dbRepo) SearchNews(ctx context.Context, q storage.SearchQuery) (page storage.SearchPage, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "searchNews", start, err) }(time.Now())
+	return r.repo.SearchNews(ctx, q)
+}
+
+func (r *dbRepo) GetFingerprints(ctx context.Context, club string, ids []string) (fps map[string]storage.Fingerprint, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "getFingerprints", start, err) }(time.Now())
+	return r.repo.GetFingerprints(ctx, club, ids)
+}
+
+func (r *dbRepo) SetLastUpdateDates(ctx context.Context, club string, lastUpdateDates map[string]string) (err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "setLastUpdateDates", start, err) }(time.Now())
+	return r.repo.SetLastUpdateDates(ctx, club, lastUpdateDates)
+}
+
+func (r *dbRepo) GetSourceArticleIDs(ctx context.Context, club, source string, publishedAfter time.Time) (ids []string, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "getSourceArticleIDs", start, err) }(time.Now())
+	return r.repo.GetSourceArticleIDs(ctx, club, source, publishedAfter)
+}
+
+func (r *dbRepo) WithdrawArticles(ctx context.Context, club string, ids []string, reason string) (withdrawn []string, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "withdrawArticles", start, err) }(time.Now())
+	return r.repo.WithdrawArticles(ctx, club, ids, reason)
+}
+
+func (r *dbRepo) BulkInsert(ctx context.Context, newsArticles []news.NewsArticle) (res *storage.BulkInsertResult, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "bulkInsert", start, err) }(time.Now())
+	return r.repo.BulkInsert(ctx, newsArticles)
+}
+
+func (r *dbRepo) GetUpdatedArticles(ctx context.Context, since time.Time) (updated []storage.UpdatedArticle, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "getUpdatedArticles", start, err) }(time.Now())
+	return r.repo.GetUpdatedArticles(ctx, since)
+}
+
+type revisionRepo struct {
+	repo    storage.RevisionRepo
+	driver  string
+	storage *Storage
+}
+
+func (r *revisionRepo) AddRevisions(ctx context.Context, newsArticles []news.NewsArticle) (err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "addRevisions", start, err) }(time.Now())
+	return r.repo.AddRevisions(ctx, newsArticles)
+}
+
+func (r *revisionRepo) GetRevisions(ctx context.Context, club, articleID string) (revisions []storage.Revision, err error) {
+	defer func(start time.Time) { r.storage.observe(r.driver, "getRevisions", start, err) }(time.Now())
+	return r.repo.GetRevisions(ctx, club, articleID)
+}
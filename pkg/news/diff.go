@@ -0,0 +1,147 @@
+package news
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// maxWordDiffCells bounds the size of the word-level diff table, larger contents are diffed as a whole
+const maxWordDiffCells = 4_000_000
+
+// Change describes how a single field of an article changed. Content changes are described
+// word by word in Words instead of From and To
+type Change struct {
+	Field string       `json:"field"`
+	From  interface{}  `json:"from,omitempty"`
+	To    interface{}  `json:"to,omitempty"`
+	Words []WordChange `json:"words,omitempty"`
+}
+
+// WordChange is a run of words that were kept, inserted or deleted
+type WordChange struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Diff returns the field-level changes between two versions of an article's data
+func Diff(from, to Data) []Change {
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"teamId", from.TeamId, to.TeamId},
+		{"optaMatchId", from.OptaMatchId, to.OptaMatchId},
+		{"title", from.Title, to.Title},
+		{"subtitle", from.Subtitle, to.Subtitle},
+		{"type", from.Type, to.Type},
+		{"teaser", from.Teaser, to.Teaser},
+		{"url", from.Url, to.Url},
+		{"imageUrl", from.ImageUrl, to.ImageUrl},
+		{"galleryUrls", from.GalleryUrls, to.GalleryUrls},
+		{"videoUrl", from.VideoUrl, to.VideoUrl},
+		{"published", from.Published, to.Published},
+	}
+
+	var changes []Change
+
+	for _, f := range fields {
+		if !equal(f.from, f.to) {
+			changes = append(changes, Change{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+
+	if from.Content != to.Content {
+		changes = append(changes, contentChange(from.Content, to.Content))
+	}
+
+	return changes
+}
+
+// equal compares field values by their JSON representation so that empty values and values
+// decoded from storage (e.g. bson arrays) compare equal to their in-memory counterparts
+func equal(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+
+	return normalize(string(ja)) == normalize(string(jb))
+}
+
+func normalize(j string) string {
+	switch j {
+	case `""`, "[]", "{}":
+		return "null"
+	}
+
+	return j
+}
+
+func contentChange(from, to string) Change {
+	a, b := strings.Fields(from), strings.Fields(to)
+	if len(a)*len(b) > maxWordDiffCells {
+		return Change{Field: "content", From: from, To: to}
+	}
+
+	return Change{Field: "content", Words: diffWords(a, b)}
+}
+
+// diffWords computes a word-level diff based on the longest common subsequence of both texts
+func diffWords(a, b []string) []WordChange {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var changes []WordChange
+	add := func(op, word string) {
+		if n := len(changes); n > 0 && changes[n-1].Op == op {
+			changes[n-1].Text += " " + word
+			return
+		}
+		changes = append(changes, WordChange{Op: op, Text: word})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add(OpEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(OpDelete, a[i])
+			i++
+		default:
+			add(OpInsert, b[j])
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		add(OpDelete, a[i])
+	}
+	for ; j < len(b); j++ {
+		add(OpInsert, b[j])
+	}
+
+	return changes
+}
@@ -0,0 +1,44 @@
+package news_test
+
+import (
+	"reflect"
+	"testing"
+
+	"com.thanos/pkg/news"
+)
+
+func TestDiff(t *testing.T) {
+	from := news.Data{
+		Title:     "Club supports fans",
+		Content:   "<p>Bees fans will be joining in.</p>",
+		Published: "2022-07-04 11:00:00",
+		Type:      []string{},
+	}
+	to := news.Data{
+		Title:     "Club supports fans heading to tournament",
+		Content:   "<p>Bees fans will definitely be joining in.</p>",
+		Published: "2022-07-04 11:00:00",
+		Type:      nil,
+		VideoUrl:  "https://video.mp4",
+	}
+
+	changes := news.Diff(from, to)
+
+	expected := []news.Change{
+		{Field: "title", From: "Club supports fans", To: "Club supports fans heading to tournament"},
+		{Field: "videoUrl", From: nil, To: "https://video.mp4"},
+		{Field: "content", Words: []news.WordChange{
+			{Op: news.OpEqual, Text: "<p>Bees fans will"},
+			{Op: news.OpInsert, Text: "definitely"},
+			{Op: news.OpEqual, Text: "be joining in.</p>"},
+		}},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected changes %+v, got %+v", expected, changes)
+	}
+
+	if changes := news.Diff(to, to); len(changes) != 0 {
+		t.Fatalf("expected no changes between equal data, got %+v", changes)
+	}
+}
@@ -1,6 +1,16 @@
 package news
 
-import "encoding/xml"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// DateTimeFormat is the layout of the Published and LastUpdateDate fields of an article
+const DateTimeFormat = "2006-01-02 15:04:05"
 
 type NewListInformation struct {
 	XMLName             xml.Name `xml:"NewListInformation"`
@@ -25,6 +35,30 @@ type NewListInformation struct {
 	} `xml:"NewsletterNewsItems"`
 }
 
+type NewsArticleInformation struct {
+	XMLName        xml.Name `xml:"NewsArticleInformation"`
+	Text           string   `xml:",chardata"`
+	ClubName       string   `xml:"ClubName"`
+	ClubWebsiteURL string   `xml:"ClubWebsiteURL"`
+	NewsArticle    struct {
+		Text              string `xml:",chardata"`
+		ArticleURL        string `xml:"ArticleURL"`
+		NewsArticleID     string `xml:"NewsArticleID"`
+		PublishDate       string `xml:"PublishDate"`
+		Taxonomies        string `xml:"Taxonomies"`
+		TeaserText        string `xml:"TeaserText"`
+		Subtitle          string `xml:"Subtitle"`
+		ThumbnailImageURL string `xml:"ThumbnailImageURL"`
+		Title             string `xml:"Title"`
+		BodyText          string `xml:"BodyText"`
+		GalleryImageURLs  string `xml:"GalleryImageURLs"`
+		VideoURL          string `xml:"VideoURL"`
+		OptaMatchId       string `xml:"OptaMatchId"`
+		LastUpdateDate    string `xml:"LastUpdateDate"`
+		IsPublished       string `xml:"IsPublished"`
+	} `xml:"NewsArticle"`
+}
+
 type Data struct {
 	Id          string      `json:"id"  bson:"id"`
 	TeamId      string      `json:"teamId"  bson:"teamID"`
@@ -38,16 +72,104 @@ type Data struct {
 	GalleryUrls interface{} `json:"galleryUrls" bson:"galleryUrls"`
 	VideoUrl    interface{} `json:"videoUrl"  bson:"videoUrl"`
 	Published   string      `json:"published"  bson:"published"`
+	// Subtitle is stored but kept out of the response to preserve its shape
+	Subtitle string `json:"-" bson:"subtitle"`
+}
+
+// Hash returns a hash of the article's content, used to detect whether a stored article changed
+func (d Data) Hash() string {
+	h := sha256.New()
+	_ = json.NewEncoder(h).Encode(d)
+	h.Write([]byte(d.Subtitle))
+
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 type NewsArticle struct {
-	Data     Data     `json:"data" bson:"data"`
-	Metadata Metadata `json:"metadata"`
-	Status   string   `json:"status" bson:"status"`
+	Data           Data     `json:"data" bson:"data"`
+	Metadata       Metadata `json:"metadata"`
+	Status         string   `json:"status" bson:"status"`
+	LastUpdateDate string   `json:"-" bson:"lastUpdateDate"`
+	ContentHash    string   `json:"-" bson:"contentHash"`
+	Revision       int      `json:"-" bson:"revision"`
+	Club           string   `json:"-" bson:"club"`
+	Source         string   `json:"-" bson:"source"`
+	// Unpublished is set by sources for articles that were pulled upstream, it's never stored
+	Unpublished bool `json:"-" bson:"-"`
 }
 
+// Withdrawal records why and when an article was withdrawn upstream
+type Withdrawal struct {
+	At     time.Time `json:"at" bson:"at"`
+	Reason string    `json:"reason" bson:"reason"`
+}
+
+// Withdrawal reasons
+const (
+	WithdrawnUnpublished = "unpublished"
+	WithdrawnDeleted     = "deleted"
+)
+
 type Metadata struct {
 	CreatedAt  string `json:"createdAt" bson:"createdAt"`
 	Sort       string `json:"sort" bson:"sort"`
 	TotalItems int    `json:"totalItems" bson:"totalItems"`
+	NextCursor string `json:"nextCursor,omitempty" bson:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty" bson:"prevCursor,omitempty"`
+}
+
+// Enrich merges the details of an article information payload into a news article.
+// Empty optional values are kept as nil so that they serialize as null
+func Enrich(na NewsArticle, info NewsArticleInformation) NewsArticle {
+	details := info.NewsArticle
+
+	na.Data.Content = details.BodyText
+	na.Data.Subtitle = details.Subtitle
+	na.Data.Teaser = nil
+	na.Data.GalleryUrls = nil
+	na.Data.VideoUrl = nil
+
+	if details.TeaserText != "" {
+		na.Data.Teaser = details.TeaserText
+	}
+
+	if details.ThumbnailImageURL != "" {
+		na.Data.ImageUrl = details.ThumbnailImageURL
+	}
+
+	if details.VideoURL != "" {
+		na.Data.VideoUrl = details.VideoURL
+	}
+
+	var galleryUrls []string
+	for _, u := range strings.Split(details.GalleryImageURLs, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			galleryUrls = append(galleryUrls, u)
+		}
+	}
+	if len(galleryUrls) > 0 {
+		na.Data.GalleryUrls = galleryUrls
+	}
+
+	if taxonomies := Taxonomies(details.Taxonomies); len(taxonomies) > 0 {
+		na.Data.Type = taxonomies
+	}
+
+	if details.LastUpdateDate != "" {
+		na.LastUpdateDate = details.LastUpdateDate
+	}
+
+	return na
+}
+
+// Taxonomies splits the comma separated taxonomies of an article into its types
+func Taxonomies(s string) []string {
+	var taxonomies []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			taxonomies = append(taxonomies, t)
+		}
+	}
+
+	return taxonomies
 }
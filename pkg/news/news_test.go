@@ -0,0 +1,73 @@
+package news_test
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+
+	"com.thanos/pkg/news"
+)
+
+func TestEnrich(t *testing.T) {
+	bytez, err := os.ReadFile("../../single_article.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info news.NewsArticleInformation
+	if err := xml.Unmarshal(bytez, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	na := news.Enrich(news.NewsArticle{
+		Data: news.Data{
+			Id:    "645150",
+			Title: "Club supports fans heading to tournament",
+		},
+		LastUpdateDate: "2022-07-04 11:00:00",
+	}, info)
+
+	if na.Data.Content != info.NewsArticle.BodyText || na.Data.Content == "" {
+		t.Fatalf("expected content to be the article body text, got: %q", na.Data.Content)
+	}
+
+	if na.Data.Subtitle != "Brentford FC represented at WorldNET 2022" {
+		t.Fatalf("unexpected subtitle: %q", na.Data.Subtitle)
+	}
+
+	if na.Data.Teaser != nil || na.Data.GalleryUrls != nil || na.Data.VideoUrl != nil {
+		t.Fatal("empty teaser, gallery and video should be nil")
+	}
+
+	if len(na.Data.Type) != 1 || na.Data.Type[0] != "Community" {
+		t.Fatalf("expected type to be taken from the taxonomies, got: %v", na.Data.Type)
+	}
+
+	if na.LastUpdateDate != "2022-07-04 11:15:04" {
+		t.Fatalf("expected last update date to be taken from the details, got: %s", na.LastUpdateDate)
+	}
+
+	info.NewsArticle.GalleryImageURLs = "https://a.jpg, https://b.jpg"
+	info.NewsArticle.VideoURL = "https://video.mp4"
+
+	na = news.Enrich(na, info)
+
+	galleryUrls, ok := na.Data.GalleryUrls.([]string)
+	if !ok || len(galleryUrls) != 2 || galleryUrls[1] != "https://b.jpg" {
+		t.Fatalf("unexpected gallery urls: %v", na.Data.GalleryUrls)
+	}
+
+	if na.Data.VideoUrl != "https://video.mp4" {
+		t.Fatalf("unexpected video url: %v", na.Data.VideoUrl)
+	}
+}
+
+func TestTaxonomies(t *testing.T) {
+	if taxonomies := news.Taxonomies(" Club News, Players ,,"); len(taxonomies) != 2 || taxonomies[1] != "Players" {
+		t.Fatalf("unexpected taxonomies: %v", taxonomies)
+	}
+
+	if taxonomies := news.Taxonomies(""); taxonomies != nil {
+		t.Fatalf("expected no taxonomies, got: %v", taxonomies)
+	}
+}
@@ -0,0 +1,182 @@
+package news
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// snippetContext is the number of characters kept around the first match of a highlighted snippet
+const snippetContext = 80
+
+var (
+	htmlTag    = regexp.MustCompile(`<[^>]*>`)
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// SearchTerms are the words and "quoted phrases" of a full-text search. -negated words and phrases are
+// kept apart in Excluded and never highlighted since they never appear in the results
+type SearchTerms struct {
+	Words    []string
+	Phrases  []string
+	Excluded []string
+}
+
+// ParseSearch parses a full-text search the way MongoDB's $text does
+func ParseSearch(q string) SearchTerms {
+	var terms SearchTerms
+
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		negated := strings.HasPrefix(q, "-")
+		if negated {
+			q = q[1:]
+		}
+
+		if strings.HasPrefix(q, `"`) {
+			// an unterminated phrase runs until the end of the search
+			phrase, rest := q[1:], ""
+			if end := strings.Index(phrase, `"`); end >= 0 {
+				phrase, rest = phrase[:end], phrase[end+1:]
+			}
+			if phrase = strings.Join(strings.Fields(phrase), " "); phrase != "" {
+				if negated {
+					terms.Excluded = append(terms.Excluded, phrase)
+				} else {
+					terms.Phrases = append(terms.Phrases, phrase)
+				}
+			}
+			q = rest
+			continue
+		}
+
+		end := strings.IndexFunc(q, unicode.IsSpace)
+		if end < 0 {
+			end = len(q)
+		}
+		if word := strings.Trim(q[:end], `"`); word != "" {
+			if negated {
+				terms.Excluded = append(terms.Excluded, word)
+			} else {
+				terms.Words = append(terms.Words, word)
+			}
+		}
+		q = q[end:]
+	}
+
+	return terms
+}
+
+// Highlights returns a snippet of every searchable field of the article that matches the terms,
+// keyed by the field's json name. Matches are wrapped in <em> and the rest of the snippet is html escaped
+func (t SearchTerms) Highlights(d Data) map[string]string {
+	re := t.pattern()
+	if re == nil {
+		return nil
+	}
+
+	fields := map[string]string{
+		"title":    d.Title,
+		"subtitle": d.Subtitle,
+		"content":  d.Content,
+	}
+	if teaser, ok := d.Teaser.(string); ok {
+		fields["teaser"] = teaser
+	}
+
+	highlights := make(map[string]string)
+	for name, text := range fields {
+		if snippet := highlight(re, text); snippet != "" {
+			highlights[name] = snippet
+		}
+	}
+
+	return highlights
+}
+
+// pattern matches any of the terms case insensitively. Words also match longer words they are the stem of,
+// similarly to the stemming of MongoDB's text index
+func (t SearchTerms) pattern() *regexp.Regexp {
+	var alternatives []string
+	for _, p := range t.Phrases {
+		alternatives = append(alternatives, strings.Join(quoteFields(p), `\s+`))
+	}
+	for _, w := range t.Words {
+		stem := strings.TrimSuffix(strings.ToLower(w), "s")
+		if stem == "" {
+			continue
+		}
+		alternatives = append(alternatives, regexp.QuoteMeta(stem)+`\w*`)
+	}
+
+	if len(alternatives) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)`)
+}
+
+func quoteFields(s string) []string {
+	fields := strings.Fields(s)
+	for i := range fields {
+		fields[i] = regexp.QuoteMeta(fields[i])
+	}
+	return fields
+}
+
+// PlainText strips the html tags and entities of a text and collapses its whitespace
+func PlainText(text string) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(html.UnescapeString(htmlTag.ReplaceAllString(text, " ")), " "))
+}
+
+// highlight returns the part of the text around its first match, or an empty string when it doesn't match
+func highlight(re *regexp.Regexp, text string) string {
+	text = PlainText(text)
+
+	first := re.FindStringIndex(text)
+	if first == nil {
+		return ""
+	}
+
+	// Snap the snippet to the surrounding spaces so that it doesn't cut words
+	start, end := 0, len(text)
+	if first[0] > snippetContext {
+		start = first[0] - snippetContext
+		if i := strings.IndexByte(text[start:first[0]], ' '); i >= 0 {
+			start += i + 1
+		} else {
+			start = first[0]
+		}
+	}
+	if first[1]+snippetContext < len(text) {
+		end = first[1] + snippetContext
+		if i := strings.LastIndexByte(text[first[1]:end], ' '); i >= 0 {
+			end = first[1] + i
+		} else {
+			end = first[1]
+		}
+	}
+
+	snippet := text[start:end]
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	last := 0
+	for _, m := range re.FindAllStringIndex(snippet, -1) {
+		b.WriteString(html.EscapeString(snippet[last:m[0]]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(snippet[m[0]:m[1]]))
+		b.WriteString("</em>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(snippet[last:]))
+
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package news_test
+
+import (
+	"reflect"
+	"testing"
+
+	"com.thanos/pkg/news"
+)
+
+func TestParseSearch(t *testing.T) {
+	testCases := []struct {
+		description string
+		q           string
+		expected    news.SearchTerms
+	}{
+		{
+			description: "should parse words",
+			q:           "  brentford   tournament ",
+			expected:    news.SearchTerms{Words: []string{"brentford", "tournament"}},
+		},
+		{
+			description: "should parse phrases",
+			q:           `"premier league" kicks "summer`,
+			expected:    news.SearchTerms{Words: []string{"kicks"}, Phrases: []string{"premier league", "summer"}},
+		},
+		{
+			description: "should keep negated words and phrases apart",
+			q:           `fans -tickets -"season ticket"`,
+			expected:    news.SearchTerms{Words: []string{"fans"}, Excluded: []string{"tickets", "season ticket"}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			if terms := news.ParseSearch(tc.q); !reflect.DeepEqual(terms, tc.expected) {
+				t.Fatalf("expected %+v, got %+v", tc.expected, terms)
+			}
+		})
+	}
+}
+
+func TestSearchTerms_Highlights(t *testing.T) {
+	d := news.Data{
+		Title:  "Club supports fans heading to tournament",
+		Teaser: nil,
+		Content: "<p>Brentford FC was represented at WorldNET 2022, an event bringing together the supporters of clubs across Europe.</p>" +
+			"<p>The Premier League Kicks tournament &amp; the club&#39;s fans travelled with the team, supporting them in every game " +
+			"of the tournaments held this summer across the country.</p>",
+	}
+
+	highlights := news.ParseSearch(`tournaments "premier  league" -brentford`).Highlights(d)
+
+	if highlights["title"] != "Club supports fans heading to <em>tournament</em>" {
+		t.Fatalf("unexpected title highlight: %q", highlights["title"])
+	}
+
+	expected := "…2022, an event bringing together the supporters of clubs across Europe. " +
+		"The <em>Premier League</em> Kicks <em>tournament</em> &amp; the club&#39;s fans travelled with the team, supporting them in…"
+	if highlights["content"] != expected {
+		t.Fatalf("unexpected content highlight: %q", highlights["content"])
+	}
+
+	if _, ok := highlights["subtitle"]; ok {
+		t.Fatal("fields that don't match should not be highlighted")
+	}
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"time"
+
+	"com.thanos/pkg/news"
+)
+
+// Result represents a stored article
+type Result struct {
+	ID          string    `json:"-" bson:"_id"`
+	ArticleID   string    `json:"-" bson:"articleID"`
+	PublishedAt time.Time `json:"-" bson:"publishedAt"`
+	Club        string    `json:"-" bson:"club"`
+	// LastUpdateDate and ContentHash identify the version of the article, they validate conditional requests
+	LastUpdateDate string    `json:"-" bson:"lastUpdateDate"`
+	ContentHash    string    `json:"-" bson:"contentHash"`
+	Data           news.Data `json:"data"`
+	// Withdrawn is set for articles that were unpublished or deleted upstream
+	Withdrawn *news.Withdrawal `json:"withdrawn,omitempty" bson:"withdrawn,omitempty"`
+}
+
+// Fingerprint identifies the stored version of an article
+type Fingerprint struct {
+	LastUpdateDate string           `bson:"lastUpdateDate"`
+	ContentHash    string           `bson:"contentHash"`
+	Revision       int              `bson:"revision"`
+	Withdrawn      *news.Withdrawal `bson:"withdrawn"`
+}
+
+// UpdatedArticle is a stored article along with its latest write, it's the change the write made
+type UpdatedArticle struct {
+	Result `bson:",inline"`
+	// Revision is 1 for an article that was written once, i.e. inserted
+	Revision int `bson:"revision"`
+	// UpdatedAt is when the article was last stored or withdrawn
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// BulkInsertResult represents the result of a bulk insert operation
+type BulkInsertResult struct {
+	InsertedCount int64
+	UpsertedCount int64
+	ModifiedCount int64
+}
+
+// PageQuery describes a single page of the -published ordered list of articles
+type PageQuery struct {
+	// Club restricts the page to the articles of a club, empty means all clubs
+	Club   string
+	Limit  int
+	Cursor *Cursor
+	// IncludeWithdrawn includes the articles that were withdrawn upstream
+	IncludeWithdrawn bool
+	Filter           Filter
+}
+
+// Filter restricts a page to the articles matching all of its set fields
+type Filter struct {
+	// Types matches the articles having any of the types
+	Types []string
+	// From and To bound the publish time of the articles, To is exclusive
+	From        *time.Time
+	To          *time.Time
+	OptaMatchId string
+	HasVideo    *bool
+	HasGallery  *bool
+	TeamId      string
+}
+
+// Page represents a page of newsArticles along with the cursors of its neighbouring pages
+type Page struct {
+	Results    []Result
+	TotalItems int64
+	Next       *Cursor
+	Prev       *Cursor
+}
+
+// SearchQuery describes a single page of the articles matching a full-text search
+type SearchQuery struct {
+	PageQuery
+	// Text is a full-text search, "quoted phrases" must be present and -words must be absent
+	Text string
+}
+
+// SearchResult represents an article matching a full-text search along with its relevance
+type SearchResult struct {
+	Result `bson:",inline"`
+	Score  float64 `json:"score" bson:"score"`
+}
+
+// SearchPage represents a page of search results along with the cursors of its neighbouring pages
+type SearchPage struct {
+	Results    []SearchResult
+	TotalItems int64
+	Next       *Cursor
+	Prev       *Cursor
+}
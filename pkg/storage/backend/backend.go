@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/storage/memory"
+	"com.thanos/pkg/storage/mongodb"
+	"com.thanos/pkg/storage/sqlite"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	DriverMongo  = "mongo"
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+)
+
+// Backend is the article, revision, sync status and webhook storage selected by the storage driver
+type Backend struct {
+	Repo       storage.DBRepo
+	Revisions  storage.RevisionRepo
+	SyncStatus storage.SyncStatusRepo
+	Webhooks   storage.WebhookRepo
+	// Watcher publishes the changes of the articles written by other processes, it's nil for other drivers
+	Watcher *mongodb.Watcher
+	// Local is set when the stored articles only live in this process, so they can't be shared
+	// with a separate fetcher
+	Local bool
+	ping  func(ctx context.Context) error
+	close func(ctx context.Context) error
+}
+
+// Open opens the storage backend of the configured driver. The mongo driver connects to the database, applies
+// its pending migrations and creates the collection indexes, the sqlite driver migrates the database and runs
+// its janitor until closed
+func Open(cfg *config.Config, l *logger.Logger) (*Backend, error) {
+	switch cfg.Storage.Driver {
+	case DriverMongo:
+		return openMongo(cfg.Mongo, cfg.Watcher, l)
+	case DriverSQLite:
+		return openSQLite(cfg.SQLite, cfg.Storage.TTL, l)
+	case DriverMemory:
+		return &Backend{
+			Repo:       memory.NewRepository(cfg.Storage.TTL),
+			Revisions:  memory.NewRevisionRepository(cfg.Storage.TTL),
+			SyncStatus: memory.NewSyncStatusRepository(),
+			Webhooks:   memory.NewWebhookRepository(cfg.Storage.TTL),
+			Local:      true,
+			ping:       func(context.Context) error { return nil },
+			close:      func(context.Context) error { return nil },
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+func openMongo(cfg config.Mongo, watcher config.Watcher, l *logger.Logger) (*Backend, error) {
+	mClient, err := mongodb.NewMongoClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("mongoDB connection unavailable: %w", err)
+	}
+
+	db := mClient.Database(cfg.Database)
+	collection := db.Collection(cfg.Collection)
+	if err = mongodb.Migrate(context.Background(), collection); err != nil {
+		_ = mClient.Disconnect(context.Background())
+		return nil, fmt.Errorf("could not migrate db: %w", err)
+	}
+
+	if err = mongodb.CreateIndexes(collection, cfg.TTL); err != nil {
+		_ = mClient.Disconnect(context.Background())
+		return nil, fmt.Errorf("could not create db indexes: %w", err)
+	}
+
+	revisionsCollection := db.Collection(cfg.RevisionsCollection)
+	if err = mongodb.CreateRevisionIndexes(revisionsCollection, cfg.TTL); err != nil {
+		_ = mClient.Disconnect(context.Background())
+		return nil, fmt.Errorf("could not create db revision indexes: %w", err)
+	}
+
+	deliveriesCollection := db.Collection(cfg.WebhookDeliveriesCollection)
+	if err = mongodb.CreateWebhookIndexes(deliveriesCollection, cfg.TTL); err != nil {
+		_ = mClient.Disconnect(context.Background())
+		return nil, fmt.Errorf("could not create db webhook delivery indexes: %w", err)
+	}
+
+	repo := mongodb.NewMongoRepo(collection, cfg)
+
+	return &Backend{
+		Repo:       repo,
+		Revisions:  mongodb.NewMongoRevisionRepo(revisionsCollection),
+		SyncStatus: mongodb.NewMongoSyncStatusRepo(db.Collection(cfg.SyncStatusCollection)),
+		Webhooks:   mongodb.NewMongoWebhookRepo(db.Collection(cfg.WebhooksCollection), deliveriesCollection),
+		Watcher:    mongodb.NewWatcher(repo, db.Collection(cfg.WatchersCollection), watcher, l),
+		ping: func(ctx context.Context) error {
+			return mClient.Ping(ctx, readpref.Primary())
+		},
+		close: mClient.Disconnect,
+	}, nil
+}
+
+func openSQLite(cfg config.SQLite, ttl time.Duration, l *logger.Logger) (*Backend, error) {
+	db, err := sqlite.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sqlite.NewJanitor(db, ttl, l).Run(ctx, cfg.JanitorInterval)
+	}()
+
+	return &Backend{
+		Repo:       sqlite.NewRepository(db, ttl),
+		Revisions:  sqlite.NewRevisionRepository(db, ttl),
+		SyncStatus: sqlite.NewSyncStatusRepository(db),
+		Webhooks:   sqlite.NewWebhookRepository(db),
+		ping:       db.PingContext,
+		close: func(context.Context) error {
+			stop()
+			<-done
+			return db.Close()
+		},
+	}, nil
+}
+
+// Ping checks that the storage can be reached
+func (b *Backend) Ping(ctx context.Context) error {
+	return b.ping(ctx)
+}
+
+// Close releases the resources of the backend
+func (b *Backend) Close(ctx context.Context) error {
+	return b.close(ctx)
+}
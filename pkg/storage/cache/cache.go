@@ -0,0 +1,285 @@
+// Package cache caches the reads of the stored articles in front of the storage
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"golang.org/x/sync/singleflight"
+)
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// DBRepo is a repository caching the pages and articles read from repo in a bounded LRU. Concurrent reads missing
+// the cache are collapsed into a single read of repo. Writes go through and invalidate the cache, as do the changes
+// of the articles written by other processes when Invalidate is called
+type DBRepo struct {
+	repo    storage.DBRepo
+	size    int
+	ttl     time.Duration
+	metrics *metrics.Cache
+	group   singleflight.Group
+
+	mu sync.Mutex
+	// generation is incremented by every invalidation, reads that started before are not cached
+	generation uint64
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+// NewDBRepo creates a new DBRepo caching up to cfg.Size reads of repo for cfg.TTL
+func NewDBRepo(repo storage.DBRepo, cfg config.Cache, m *metrics.Cache) *DBRepo {
+	return &DBRepo{
+		repo:    repo,
+		size:    cfg.Size,
+		ttl:     cfg.TTL,
+		metrics: m,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Invalidate drops every cached read
+func (r *DBRepo) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.entries = make(map[string]*list.Element)
+	r.lru.Init()
+
+	r.metrics.SetEntries(0)
+	r.metrics.Invalidated()
+}
+
+// WatchSyncs invalidates the cache whenever the latest sync stored in status changed, checking it on every interval
+// until the context is cancelled. It keeps the cache fresh when the articles are synced by another process, i.e. the
+// fetcher, whose changes aren't watched. Failed checks are retried on the next interval
+func (r *DBRepo) WatchSyncs(ctx context.Context, status storage.SyncStatusRepo, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// the zero time stands for no sync yet, the first sync invalidates the reads made before it too
+	var lastSyncAt time.Time
+	checked := false
+	for {
+		s, err := status.GetSyncStatus(ctx)
+		if errors.Is(err, storage.ErrNotFound) {
+			s, err = storage.SyncStatus{}, nil
+		}
+
+		if err == nil {
+			if checked && !s.LastSyncAt.Equal(lastSyncAt) {
+				r.Invalidate()
+			}
+			lastSyncAt, checked = s.LastSyncAt, true
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *DBRepo) GetArticleByID(ctx context.Context, club, id string) (storage.Result, error) {
+	key := url.Values{"op": {"getArticleByID"}, "club": {club}, "id": {id}}.Encode()
+
+	v, err := r.read(ctx, "getArticleByID", key, func(ctx context.Context) (interface{}, error) {
+		return r.repo.GetArticleByID(ctx, club, id)
+	})
+	if err != nil {
+		return storage.Result{}, err
+	}
+
+	return v.(storage.Result), nil
+}
+
+func (r *DBRepo) GetNewsPage(ctx context.Context, q storage.PageQuery) (storage.Page, error) {
+	v, err := r.read(ctx, "getNewsPage", pageKey(q), func(ctx context.Context) (interface{}, error) {
+		return r.repo.GetNewsPage(ctx, q)
+	})
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	// the cached results are shared, callers get their own slice of them
+	page := v.(storage.Page)
+	results := make([]storage.Result, len(page.Results))
+	copy(results, page.Results)
+	page.Results = results
+
+	return page, nil
+}
+
+func (r *DBRepo) StreamNews(ctx context.Context, q storage.PageQuery, fn func(storage.Result) error) error {
+	return r.repo.StreamNews(ctx, q, fn)
+}
+
+func (r *DBRepo) GetUpdatedArticles(ctx context.Context, since time.Time) ([]storage.UpdatedArticle, error) {
+	return r.repo.GetUpdatedArticles(ctx, since)
+}
+
+func (r *DBRepo) SearchNews(ctx context.Context, q storage.SearchQuery) (storage.SearchPage, error) {
+	return r.repo.SearchNews(ctx, q)
+}
+
+func (r *DBRepo) GetFingerprints(ctx context.Context, club string, ids []string) (map[string]storage.Fingerprint, error) {
+	return r.repo.GetFingerprints(ctx, club, ids)
+}
+
+func (r *DBRepo) GetSourceArticleIDs(ctx context.Context, club, source string, publishedAfter time.Time) ([]string, error) {
+	return r.repo.GetSourceArticleIDs(ctx, club, source, publishedAfter)
+}
+
+// SetLastUpdateDates invalidates the cache, the last update dates validate the conditional requests of articles
+func (r *DBRepo) SetLastUpdateDates(ctx context.Context, club string, lastUpdateDates map[string]string) error {
+	defer r.invalidate(len(lastUpdateDates))
+	return r.repo.SetLastUpdateDates(ctx, club, lastUpdateDates)
+}
+
+func (r *DBRepo) WithdrawArticles(ctx context.Context, club string, ids []string, reason string) ([]string, error) {
+	defer r.invalidate(len(ids))
+	return r.repo.WithdrawArticles(ctx, club, ids, reason)
+}
+
+func (r *DBRepo) BulkInsert(ctx context.Context, newsArticles []news.NewsArticle) (*storage.BulkInsertResult, error) {
+	defer r.invalidate(len(newsArticles))
+	return r.repo.BulkInsert(ctx, newsArticles)
+}
+
+// invalidate invalidates the cache after a write of n articles. Failed writes may have been partially applied
+func (r *DBRepo) invalidate(n int) {
+	if n > 0 {
+		r.Invalidate()
+	}
+}
+
+// read returns the cached value of key, or reads it with load and caches it. Reads of the same key missing the cache
+// concurrently share a single load, only loads that started after the latest invalidation are cached
+func (r *DBRepo) read(ctx context.Context, operation, key string, load func(context.Context) (interface{}, error)) (interface{}, error) {
+	r.mu.Lock()
+	v, ok := r.get(key)
+	generation := r.generation
+	r.mu.Unlock()
+
+	if ok {
+		r.metrics.Hit(operation)
+		return v, nil
+	}
+	r.metrics.Miss(operation)
+
+	v, err, shared := r.group.Do(strconv.FormatUint(generation, 10)+"&"+key, func() (interface{}, error) {
+		v, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.generation == generation {
+			r.add(key, v)
+		}
+
+		return v, nil
+	})
+
+	// a shared load is cancelled along with the request that started it, other requests read on their own
+	if shared && ctx.Err() == nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return load(ctx)
+	}
+
+	return v, err
+}
+
+// get returns the unexpired cached value of key and marks it as the most recently used
+func (r *DBRepo) get(key string) (interface{}, bool) {
+	el, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		r.remove(el)
+		return nil, false
+	}
+
+	r.lru.MoveToFront(el)
+
+	return e.value, true
+}
+
+// add caches the value of key, evicting the least recently used value when the cache is full
+func (r *DBRepo) add(key string, value interface{}) {
+	if el, ok := r.entries[key]; ok {
+		r.remove(el)
+	}
+
+	r.entries[key] = r.lru.PushFront(&entry{key: key, value: value, expires: time.Now().Add(r.ttl)})
+	if r.lru.Len() > r.size {
+		r.remove(r.lru.Back())
+	}
+
+	r.metrics.SetEntries(r.lru.Len())
+}
+
+func (r *DBRepo) remove(el *list.Element) {
+	r.lru.Remove(el)
+	delete(r.entries, el.Value.(*entry).key)
+
+	r.metrics.SetEntries(r.lru.Len())
+}
+
+// pageKey normalizes a page query, queries of the same page have the same key whatever the order of their params
+func pageKey(q storage.PageQuery) string {
+	key := url.Values{
+		"op":               {"getNewsPage"},
+		"club":             {q.Club},
+		"limit":            {strconv.Itoa(q.Limit)},
+		"includeWithdrawn": {strconv.FormatBool(q.IncludeWithdrawn)},
+		"optaMatchId":      {q.Filter.OptaMatchId},
+		"teamId":           {q.Filter.TeamId},
+	}
+
+	if q.Cursor != nil {
+		key.Set("cursor", q.Cursor.Encode())
+	}
+
+	if len(q.Filter.Types) > 0 {
+		types := make([]string, len(q.Filter.Types))
+		copy(types, q.Filter.Types)
+		sort.Strings(types)
+		key["type"] = types
+	}
+
+	if q.Filter.From != nil {
+		key.Set("from", q.Filter.From.UTC().Format(time.RFC3339Nano))
+	}
+	if q.Filter.To != nil {
+		key.Set("to", q.Filter.To.UTC().Format(time.RFC3339Nano))
+	}
+	if q.Filter.HasVideo != nil {
+		key.Set("hasVideo", strconv.FormatBool(*q.Filter.HasVideo))
+	}
+	if q.Filter.HasGallery != nil {
+		key.Set("hasGallery", strconv.FormatBool(*q.Filter.HasGallery))
+	}
+
+	return key.Encode()
+}
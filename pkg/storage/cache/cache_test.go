@@ -0,0 +1,238 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/metrics"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/storage/cache"
+	"com.thanos/pkg/storage/memory"
+	"com.thanos/pkg/storage/storagetest"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDBRepo_Conformance(t *testing.T) {
+	// the conformance tests read back what they write, which a stale cache would fail
+	storagetest.TestDBRepo(t, cache.NewDBRepo(memory.NewRepository(time.Hour), config.Cache{Size: 100, TTL: time.Minute}, metrics.New().Cache))
+}
+
+func TestDBRepo_GetNewsPage(t *testing.T) {
+	ctx := context.Background()
+	page := func(types ...string) storage.PageQuery {
+		return storage.PageQuery{Limit: 20, Filter: storage.Filter{Types: types}}
+	}
+
+	testCases := []struct {
+		description   string
+		cfg           config.Cache
+		err           error
+		reads         func(r *cache.DBRepo)
+		expectedLoads int32
+	}{
+		{
+			description: "should serve the reads of the same page from the cache",
+			cfg:         config.Cache{Size: 10, TTL: time.Minute},
+			reads: func(r *cache.DBRepo) {
+				_, _ = r.GetNewsPage(ctx, page("News", "Match Report"))
+				_, _ = r.GetNewsPage(ctx, page("Match Report", "News"))
+			},
+			expectedLoads: 1,
+		},
+		{
+			description: "should collapse concurrent reads missing the cache",
+			cfg:         config.Cache{Size: 10, TTL: time.Minute},
+			reads: func(r *cache.DBRepo) {
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						_, _ = r.GetNewsPage(ctx, page())
+					}()
+				}
+				wg.Wait()
+			},
+			expectedLoads: 1,
+		},
+		{
+			description: "should read again after an invalidation",
+			cfg:         config.Cache{Size: 10, TTL: time.Minute},
+			reads: func(r *cache.DBRepo) {
+				_, _ = r.GetNewsPage(ctx, page())
+				r.Invalidate()
+				_, _ = r.GetNewsPage(ctx, page())
+			},
+			expectedLoads: 2,
+		},
+		{
+			description: "should evict the least recently used page",
+			cfg:         config.Cache{Size: 1, TTL: time.Minute},
+			reads: func(r *cache.DBRepo) {
+				_, _ = r.GetNewsPage(ctx, page("News"))
+				_, _ = r.GetNewsPage(ctx, page("Match Report"))
+				_, _ = r.GetNewsPage(ctx, page("News"))
+			},
+			expectedLoads: 3,
+		},
+		{
+			description: "should read again once the ttl expired",
+			cfg:         config.Cache{Size: 10, TTL: time.Millisecond},
+			reads: func(r *cache.DBRepo) {
+				_, _ = r.GetNewsPage(ctx, page())
+				time.Sleep(5 * time.Millisecond)
+				_, _ = r.GetNewsPage(ctx, page())
+			},
+			expectedLoads: 2,
+		},
+		{
+			description: "should not cache failed reads",
+			cfg:         config.Cache{Size: 10, TTL: time.Minute},
+			err:         errors.New("storage unavailable"),
+			reads: func(r *cache.DBRepo) {
+				_, _ = r.GetNewsPage(ctx, page())
+				_, _ = r.GetNewsPage(ctx, page())
+			},
+			expectedLoads: 2,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			var loads int32
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			dbrepo.EXPECT().
+				GetNewsPage(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(context.Context, storage.PageQuery) (storage.Page, error) {
+					atomic.AddInt32(&loads, 1)
+					// concurrent reads wait for the first one
+					time.Sleep(10 * time.Millisecond)
+					return storage.Page{Results: []storage.Result{{ArticleID: "645150"}}}, tc.err
+				}).
+				AnyTimes()
+
+			tc.reads(cache.NewDBRepo(dbrepo, tc.cfg, metrics.New().Cache))
+
+			if loads := atomic.LoadInt32(&loads); loads != tc.expectedLoads {
+				t.Fatalf("expected %d reads of the storage, got %d", tc.expectedLoads, loads)
+			}
+		})
+	}
+}
+
+func TestDBRepo_Metrics(t *testing.T) {
+	ctx := context.Background()
+	m := metrics.New()
+
+	repo := memory.NewRepository(time.Hour)
+	r := cache.NewDBRepo(repo, config.Cache{Size: 10, TTL: time.Minute}, m.Cache)
+
+	for i := 0; i < 3; i++ {
+		_, _ = r.GetArticleByID(ctx, "", "missing")
+		_, _ = r.GetNewsPage(ctx, storage.PageQuery{Limit: 20})
+	}
+	_, _ = r.WithdrawArticles(ctx, "", []string{"missing"}, "deleted")
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+
+	for _, expected := range []string{
+		`news_cache_misses_total{operation="getArticleByID"} 3`,
+		`news_cache_misses_total{operation="getNewsPage"} 1`,
+		`news_cache_hits_total{operation="getNewsPage"} 2`,
+		`news_cache_entries 0`,
+		`news_cache_invalidations_total 1`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Fatalf("expected metric %s in:\n%s", expected, body)
+		}
+	}
+}
+
+func TestDBRepo_WatchSyncs(t *testing.T) {
+	testCases := []struct {
+		description   string
+		synced        bool
+		sync          bool
+		expectedLoads int32
+	}{
+		{
+			description:   "should read again after a sync",
+			synced:        true,
+			sync:          true,
+			expectedLoads: 2,
+		},
+		{
+			description:   "should read again after the first sync",
+			sync:          true,
+			expectedLoads: 2,
+		},
+		{
+			description:   "should serve the reads from the cache without a sync",
+			synced:        true,
+			expectedLoads: 1,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ctrl := gomock.NewController(t)
+
+			var loads int32
+			dbrepo := storage.NewMockDBRepo(ctrl)
+			dbrepo.EXPECT().
+				GetNewsPage(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(context.Context, storage.PageQuery) (storage.Page, error) {
+					atomic.AddInt32(&loads, 1)
+					return storage.Page{}, nil
+				}).
+				AnyTimes()
+
+			status := memory.NewSyncStatusRepository()
+			if tc.synced {
+				if err := status.SetSyncStatus(ctx, storage.SyncStatus{LastSyncAt: time.Now().Add(-time.Minute)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r := cache.NewDBRepo(dbrepo, config.Cache{Size: 10, TTL: time.Minute}, metrics.New().Cache)
+			go r.WatchSyncs(ctx, status, time.Millisecond)
+
+			// the first check records the latest sync
+			time.Sleep(20 * time.Millisecond)
+			_, _ = r.GetNewsPage(ctx, storage.PageQuery{Limit: 20})
+
+			if tc.sync {
+				if err := status.SetSyncStatus(ctx, storage.SyncStatus{LastSyncAt: time.Now()}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			_, _ = r.GetNewsPage(ctx, storage.PageQuery{Limit: 20})
+
+			if loads := atomic.LoadInt32(&loads); loads != tc.expectedLoads {
+				t.Fatalf("expected %d reads of the storage, got %d", tc.expectedLoads, loads)
+			}
+		})
+	}
+}
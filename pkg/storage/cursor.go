@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor can not be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at a position in the -published ordered list of articles.
+// Articles sharing a publish time are ordered by their articleID so that the position is stable
+type Cursor struct {
+	// Score is the relevance of the article for cursors of search results, which are ordered by -score first
+	Score       float64
+	PublishedAt time.Time
+	ArticleID   string
+	// Backward cursors return the page preceding the position instead of the one following it
+	Backward bool
+}
+
+type cursorToken struct {
+	Score       float64 `json:"s,omitempty"`
+	PublishedAt int64   `json:"p"`
+	ArticleID   string  `json:"a"`
+	Backward    bool    `json:"b,omitempty"`
+}
+
+// Encode returns the opaque string representation of the cursor
+func (c Cursor) Encode() string {
+	bytez, _ := json.Marshal(cursorToken{
+		Score:       c.Score,
+		PublishedAt: c.PublishedAt.UnixNano(),
+		ArticleID:   c.ArticleID,
+		Backward:    c.Backward,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(bytez)
+}
+
+// DecodeCursor parses an opaque cursor previously returned by Encode
+func DecodeCursor(s string) (Cursor, error) {
+	bytez, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var token cursorToken
+	if err = json.Unmarshal(bytez, &token); err != nil || token.ArticleID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{
+		Score:       token.Score,
+		PublishedAt: time.Unix(0, token.PublishedAt).UTC(),
+		ArticleID:   token.ArticleID,
+		Backward:    token.Backward,
+	}, nil
+}
+
+// PageCursors returns the cursors of the pages around a page of n results fetched from the given cursor.
+// hasMore reports whether there are more results in the direction the page was fetched in and
+// position returns the position of the i-th result of the page
+func PageCursors(c *Cursor, n int, hasMore bool, position func(i int) Cursor) (next, prev *Cursor) {
+	if n == 0 {
+		return nil, nil
+	}
+
+	backward := c != nil && c.Backward
+	if (!backward && hasMore) || backward {
+		last := position(n - 1)
+		next = &last
+	}
+	if (backward && hasMore) || (!backward && c != nil) {
+		first := position(0)
+		first.Backward = true
+		prev = &first
+	}
+
+	return next, prev
+}
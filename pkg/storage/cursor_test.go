@@ -0,0 +1,36 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"com.thanos/pkg/storage"
+)
+
+func TestCursor(t *testing.T) {
+	c := storage.Cursor{
+		PublishedAt: time.Date(2022, 7, 4, 13, 0, 0, 0, time.UTC),
+		ArticleID:   "645168",
+		Backward:    true,
+	}
+
+	decoded, err := storage.DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !decoded.PublishedAt.Equal(c.PublishedAt) || decoded.ArticleID != c.ArticleID || !decoded.Backward {
+		t.Fatalf("expected cursor %+v, got %+v", c, decoded)
+	}
+
+	c.Score = 1.75
+	if decoded, err = storage.DecodeCursor(c.Encode()); err != nil || decoded.Score != c.Score {
+		t.Fatalf("expected the score of a search cursor to be kept, got %+v (%v)", decoded, err)
+	}
+
+	for _, s := range []string{"", "not base64!", "e30"} {
+		if _, err := storage.DecodeCursor(s); err != storage.ErrInvalidCursor {
+			t.Fatalf("expected invalid cursor error for %q, got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"com.thanos/pkg/news"
+)
+
+//go:generate mockgen -source=dbrepo.go -destination=dbrepomock.go -package=storage
+
+type DBRepo interface {
+	GetArticleByID(context.Context, string, string) (Result, error)
+	GetNewsPage(context.Context, PageQuery) (Page, error)
+	StreamNews(context.Context, PageQuery, func(Result) error) error
+	SearchNews(context.Context, SearchQuery) (SearchPage, error)
+	GetFingerprints(context.Context, string, []string) (map[string]Fingerprint, error)
+	SetLastUpdateDates(context.Context, string, map[string]string) error
+	GetSourceArticleIDs(context.Context, string, string, time.Time) ([]string, error)
+	WithdrawArticles(context.Context, string, []string, string) ([]string, error)
+	BulkInsert(context.Context, []news.NewsArticle) (*BulkInsertResult, error)
+	GetUpdatedArticles(context.Context, time.Time) ([]UpdatedArticle, error)
+}
+
+type RevisionRepo interface {
+	AddRevisions(context.Context, []news.NewsArticle) error
+	GetRevisions(context.Context, string, string) ([]Revision, error)
+}
+
+type SyncStatusRepo interface {
+	SetSyncStatus(context.Context, SyncStatus) error
+	GetSyncStatus(context.Context) (SyncStatus, error)
+}
+
+type WebhookRepo interface {
+	CreateWebhook(context.Context, Webhook) error
+	GetWebhooks(context.Context) ([]Webhook, error)
+	GetWebhook(context.Context, string) (Webhook, error)
+	DeleteWebhook(context.Context, string) error
+	AddDeliveries(context.Context, []Delivery) error
+	ClaimDeliveries(context.Context, time.Time, time.Duration, int) ([]Delivery, error)
+	UpdateDelivery(context.Context, Delivery) error
+	GetDeliveries(context.Context, string, int) ([]Delivery, error)
+}
@@ -0,0 +1,42 @@
+package storage
+
+import "errors"
+
+// Kinds of storage errors, storage backends report their failures as one of them so that callers
+// can handle them without knowing the backend. Check for them with errors.Is
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrUnavailable  = errors.New("unavailable")
+	ErrTimeout      = errors.New("timeout")
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// Error is a storage failure of a given kind. It keeps the underlying backend error
+type Error struct {
+	Kind error
+	Op   string
+	Err  error
+}
+
+// NewError returns an error of the given kind for a failed operation
+func NewError(kind error, op string, err error) *Error {
+	return &Error{Kind: kind, Op: op, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Op + ": " + e.Kind.Error()
+	}
+	return e.Op + ": " + e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying backend error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error is of the target kind
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
@@ -0,0 +1,30 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"com.thanos/pkg/storage"
+)
+
+func TestError(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", storage.NewError(storage.ErrTimeout, "getArticleByID", context.DeadlineExceeded))
+
+	if !errors.Is(err, storage.ErrTimeout) {
+		t.Fatal("expected the error to be of its kind")
+	}
+
+	if errors.Is(err, storage.ErrNotFound) {
+		t.Fatal("expected the error not to be of another kind")
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected the backend error to be kept")
+	}
+
+	if msg := storage.NewError(storage.ErrNotFound, "getArticleByID", nil).Error(); msg != "getArticleByID: not found" {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+}
@@ -0,0 +1,387 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+)
+
+// Repository is an in-memory storage.DBRepo. It follows the TTL, sorting and upsert semantics of
+// the mongo Repository so that it can stand in for it in local development and tests.
+// Stored articles only live as long as the process
+type Repository struct {
+	mu  sync.RWMutex
+	ttl time.Duration
+	// articles are keyed by articleKey, article ids are only unique within a club
+	articles map[string]*article
+	seq      int
+	now      func() time.Time
+}
+
+// article is a stored article along with the fields the mongo Repository keeps next to its data
+type article struct {
+	result    storage.Result
+	status    string
+	revision  int
+	source    string
+	createdAt string
+	updatedAt time.Time
+}
+
+// articleKey returns the key of the article of a club with the given id
+func articleKey(club, id string) string {
+	return club + "/" + id
+}
+
+// NewRepository creates a new in-memory repository whose articles expire ttl after their publish time
+func NewRepository(ttl time.Duration) *Repository {
+	return &Repository{
+		ttl:      ttl,
+		articles: make(map[string]*article),
+		now:      time.Now,
+	}
+}
+
+// GetArticleByID returns the article of a club with the given id. When club is empty the latest published
+// article with that id is returned, whatever its club
+func (r *Repository) GetArticleByID(_ context.Context, club, id string) (storage.Result, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *article
+	if club != "" {
+		found = r.articles[articleKey(club, id)]
+	} else {
+		for _, a := range r.articles {
+			if a.result.ArticleID == id && r.live(a) && (found == nil || a.result.PublishedAt.After(found.result.PublishedAt)) {
+				found = a
+			}
+		}
+	}
+
+	if found == nil || !r.live(found) {
+		return storage.Result{}, storage.NewError(storage.ErrNotFound, "getArticleByID", nil)
+	}
+
+	return found.result, nil
+}
+
+// GetNewsPage returns a page of newsArticles sorted by -published. Ties on the publish time are
+// broken by articleID so that paging through articles sharing a publish time is stable
+func (r *Repository) GetNewsPage(_ context.Context, q storage.PageQuery) (storage.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matching := r.sorted(func(a *article) bool { return matches(a, q) })
+
+	positions := make([]storage.Cursor, len(matching))
+	for i, a := range matching {
+		positions[i] = storage.Cursor{PublishedAt: a.result.PublishedAt, ArticleID: a.result.ArticleID}
+	}
+
+	from, to, hasMore := pageBounds(positions, q.Cursor, q.Limit)
+
+	page := storage.Page{TotalItems: int64(len(matching)), Results: []storage.Result{}}
+	for _, a := range matching[from:to] {
+		page.Results = append(page.Results, a.result)
+	}
+	page.Next, page.Prev = storage.PageCursors(q.Cursor, to-from, hasMore, func(i int) storage.Cursor {
+		return positions[from+i]
+	})
+
+	return page, nil
+}
+
+// StreamNews calls fn with every article of the -published ordered list following the query's cursor, up to
+// its limit when it's set. The articles are copied first so that fn doesn't block writers
+func (r *Repository) StreamNews(_ context.Context, q storage.PageQuery, fn func(storage.Result) error) error {
+	r.mu.RLock()
+	var results []storage.Result
+	for _, a := range r.sorted(func(a *article) bool { return matches(a, q) }) {
+		c := storage.Cursor{PublishedAt: a.result.PublishedAt, ArticleID: a.result.ArticleID}
+		if q.Cursor != nil && !before(*q.Cursor, c) {
+			continue
+		}
+		if q.Limit > 0 && len(results) == q.Limit {
+			break
+		}
+		results = append(results, a.result)
+	}
+	r.mu.RUnlock()
+
+	for _, res := range results {
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetFingerprints returns the stored Fingerprint of every given article id of a club that exists
+func (r *Repository) GetFingerprints(_ context.Context, club string, ids []string) (map[string]storage.Fingerprint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fingerprints := make(map[string]storage.Fingerprint, len(ids))
+	for _, id := range ids {
+		if a, ok := r.articles[articleKey(club, id)]; ok && r.live(a) {
+			fingerprints[id] = storage.Fingerprint{
+				LastUpdateDate: a.result.LastUpdateDate,
+				ContentHash:    a.result.ContentHash,
+				Revision:       a.revision,
+				Withdrawn:      a.result.Withdrawn,
+			}
+		}
+	}
+
+	return fingerprints, nil
+}
+
+// SetLastUpdateDates updates the stored LastUpdateDate of articles of a club whose content didn't change
+func (r *Repository) SetLastUpdateDates(_ context.Context, club string, lastUpdateDates map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, lud := range lastUpdateDates {
+		if a, ok := r.articles[articleKey(club, id)]; ok {
+			a.result.LastUpdateDate = lud
+		}
+	}
+
+	return nil
+}
+
+// GetSourceArticleIDs returns the ids of the articles of a club's source published after the given time,
+// that have not been withdrawn
+func (r *Repository) GetSourceArticleIDs(_ context.Context, club, source string, publishedAfter time.Time) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := []string{}
+	for _, a := range r.articles {
+		if r.live(a) && a.result.Club == club && a.source == source && a.result.PublishedAt.After(publishedAfter) && a.result.Withdrawn == nil {
+			ids = append(ids, a.result.ArticleID)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// WithdrawArticles marks articles of a club as withdrawn upstream. Articles that are already withdrawn keep
+// their original withdrawal. It returns the ids of the newly withdrawn articles
+func (r *Repository) WithdrawArticles(_ context.Context, club string, ids []string, reason string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	withdrawn := []string{}
+	for _, id := range ids {
+		if a, ok := r.articles[articleKey(club, id)]; ok && a.result.Withdrawn == nil {
+			a.result.Withdrawn = &news.Withdrawal{At: r.now().UTC(), Reason: reason}
+			a.updatedAt = a.result.Withdrawn.At
+			withdrawn = append(withdrawn, id)
+		}
+	}
+
+	return withdrawn, nil
+}
+
+// BulkInsert inserts an array of NewsArticles using upsert to avoid dupes, an article is identified by its club and id.
+// The createdAt metadata is only set when an article is first inserted
+func (r *Repository) BulkInsert(_ context.Context, newsArticles []news.NewsArticle) (*storage.BulkInsertResult, error) {
+	// Validate every article first, a bulk insert either stores all of them or none
+	published := make([]time.Time, len(newsArticles))
+	for i, n := range newsArticles {
+		dt, err := time.Parse(news.DateTimeFormat, n.Data.Published)
+		if err != nil {
+			return &storage.BulkInsertResult{}, storage.NewError(storage.ErrInvalidQuery, "bulkInsert", err)
+		}
+		published[i] = dt
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune()
+
+	result := &storage.BulkInsertResult{}
+	for i, n := range newsArticles {
+		key := articleKey(n.Club, n.Data.Id)
+		a, ok := r.articles[key]
+		if !ok {
+			r.seq++
+			a = &article{
+				result:    storage.Result{ID: fmt.Sprintf("%024x", r.seq), ArticleID: n.Data.Id, Club: n.Club},
+				createdAt: r.now().Format(time.RFC3339),
+			}
+			r.articles[key] = a
+			result.UpsertedCount++
+		} else {
+			result.ModifiedCount++
+		}
+
+		a.result.PublishedAt = published[i]
+		a.result.Data = n.Data
+		// Articles that show up again upstream are no longer withdrawn
+		a.result.Withdrawn = nil
+		a.status = n.Status
+		a.result.LastUpdateDate = n.LastUpdateDate
+		a.result.ContentHash = n.ContentHash
+		a.revision = n.Revision
+		a.source = n.Source
+		a.updatedAt = r.now().UTC()
+	}
+
+	return result, nil
+}
+
+// GetUpdatedArticles returns the articles stored or withdrawn at or after since, oldest write first
+func (r *Repository) GetUpdatedArticles(_ context.Context, since time.Time) ([]storage.UpdatedArticle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	updated := []storage.UpdatedArticle{}
+	for _, a := range r.articles {
+		if r.live(a) && !a.updatedAt.Before(since) {
+			updated = append(updated, storage.UpdatedArticle{Result: a.result, Revision: a.revision, UpdatedAt: a.updatedAt})
+		}
+	}
+	sort.Slice(updated, func(i, j int) bool {
+		if !updated[i].UpdatedAt.Equal(updated[j].UpdatedAt) {
+			return updated[i].UpdatedAt.Before(updated[j].UpdatedAt)
+		}
+		return updated[i].ID < updated[j].ID
+	})
+
+	return updated, nil
+}
+
+// live reports whether an article is still within the TTL window
+func (r *Repository) live(a *article) bool {
+	return !a.result.PublishedAt.Before(r.now().Add(-r.ttl))
+}
+
+// prune removes the expired articles, the equivalent of mongo's TTL index
+func (r *Repository) prune() {
+	for id, a := range r.articles {
+		if !r.live(a) {
+			delete(r.articles, id)
+		}
+	}
+}
+
+// sorted returns the live articles matching the predicate sorted by -publishedAt and -articleID
+func (r *Repository) sorted(match func(a *article) bool) []*article {
+	var articles []*article
+	for _, a := range r.articles {
+		if r.live(a) && match(a) {
+			articles = append(articles, a)
+		}
+	}
+
+	sort.Slice(articles, func(i, j int) bool {
+		return before(
+			storage.Cursor{PublishedAt: articles[i].result.PublishedAt, ArticleID: articles[i].result.ArticleID},
+			storage.Cursor{PublishedAt: articles[j].result.PublishedAt, ArticleID: articles[j].result.ArticleID},
+		)
+	})
+
+	return articles
+}
+
+// before reports whether position a comes before position b in the -score, -publishedAt, -articleID order
+func before(a, b storage.Cursor) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if !a.PublishedAt.Equal(b.PublishedAt) {
+		return a.PublishedAt.After(b.PublishedAt)
+	}
+	return a.ArticleID > b.ArticleID
+}
+
+// pageBounds returns the bounds of the page of sorted positions following the cursor, or preceding it
+// for backward cursors, and whether there are more positions in that direction
+func pageBounds(positions []storage.Cursor, c *storage.Cursor, limit int) (from, to int, hasMore bool) {
+	if c == nil {
+		to = len(positions)
+		if to > limit {
+			to, hasMore = limit, true
+		}
+		return 0, to, hasMore
+	}
+
+	// index of the first position following the cursor
+	after := sort.Search(len(positions), func(i int) bool { return before(*c, positions[i]) })
+
+	if c.Backward {
+		// positions preceding the cursor end right before it, unless the cursor position itself is stored
+		to = sort.Search(len(positions), func(i int) bool { return !before(positions[i], *c) })
+		from = to - limit
+		if from < 0 {
+			from = 0
+		}
+		return from, to, from > 0
+	}
+
+	to = after + limit
+	if to > len(positions) {
+		to = len(positions)
+	}
+	return after, to, to < len(positions)
+}
+
+// matches reports whether an article matches the club, withdrawal and filter of a page query
+func matches(a *article, q storage.PageQuery) bool {
+	res, f := a.result, q.Filter
+
+	switch {
+	case q.Club != "" && res.Club != q.Club:
+		return false
+	case !q.IncludeWithdrawn && res.Withdrawn != nil:
+		return false
+	case f.From != nil && res.PublishedAt.Before(*f.From):
+		return false
+	case f.To != nil && !res.PublishedAt.Before(*f.To):
+		return false
+	case f.OptaMatchId != "" && fmt.Sprint(res.Data.OptaMatchId) != f.OptaMatchId:
+		return false
+	case f.TeamId != "" && res.Data.TeamId != f.TeamId:
+		return false
+	case f.HasVideo != nil && (res.Data.VideoUrl != nil) != *f.HasVideo:
+		return false
+	case f.HasGallery != nil && hasGallery(res.Data) != *f.HasGallery:
+		return false
+	case len(f.Types) > 0 && !hasAnyType(res.Data, f.Types):
+		return false
+	}
+
+	return true
+}
+
+func hasGallery(d news.Data) bool {
+	switch urls := d.GalleryUrls.(type) {
+	case []string:
+		return len(urls) > 0
+	case []interface{}:
+		return len(urls) > 0
+	}
+	return false
+}
+
+func hasAnyType(d news.Data, types []string) bool {
+	for _, t := range d.Type {
+		for _, want := range types {
+			if t == want {
+				return true
+			}
+		}
+	}
+	return false
+}
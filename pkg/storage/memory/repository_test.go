@@ -0,0 +1,25 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"com.thanos/pkg/storage/memory"
+	"com.thanos/pkg/storage/storagetest"
+)
+
+func TestRepository(t *testing.T) {
+	storagetest.TestDBRepo(t, memory.NewRepository(time.Hour))
+}
+
+func TestRevisionRepository(t *testing.T) {
+	storagetest.TestRevisionRepo(t, memory.NewRevisionRepository(time.Hour))
+}
+
+func TestSyncStatusRepository(t *testing.T) {
+	storagetest.TestSyncStatusRepo(t, memory.NewSyncStatusRepository())
+}
+
+func TestWebhookRepository(t *testing.T) {
+	storagetest.TestWebhookRepo(t, memory.NewWebhookRepository(time.Hour))
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+)
+
+// RevisionRepository is an in-memory storage.RevisionRepo. Revisions expire ttl after they were created
+type RevisionRepository struct {
+	mu  sync.RWMutex
+	ttl time.Duration
+	// revisions are keyed by articleKey
+	revisions map[string][]storage.Revision
+	now       func() time.Time
+}
+
+// NewRevisionRepository creates a new in-memory revision repository
+func NewRevisionRepository(ttl time.Duration) *RevisionRepository {
+	return &RevisionRepository{
+		ttl:       ttl,
+		revisions: make(map[string][]storage.Revision),
+		now:       time.Now,
+	}
+}
+
+// AddRevisions stores a revision for every article using the article's revision number.
+// Revisions are never overwritten, storing an existing revision again is a no-op
+func (r *RevisionRepository) AddRevisions(_ context.Context, newsArticles []news.NewsArticle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now().UTC()
+
+	for _, n := range newsArticles {
+		key := articleKey(n.Club, n.Data.Id)
+		revisions := r.live(key)
+		if hasRevision(revisions, n.Revision) {
+			continue
+		}
+
+		revisions = append(revisions, storage.Revision{
+			ArticleID:      n.Data.Id,
+			Club:           n.Club,
+			Revision:       n.Revision,
+			CreatedAt:      now,
+			LastUpdateDate: n.LastUpdateDate,
+			ContentHash:    n.ContentHash,
+			Data:           n.Data,
+		})
+		sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+		r.revisions[key] = revisions
+	}
+
+	return nil
+}
+
+// GetRevisions returns every stored revision of an article of a club ordered by revision number.
+// When club is empty the revisions of the article with that id are returned, whatever its club
+func (r *RevisionRepository) GetRevisions(_ context.Context, club, articleID string) ([]storage.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if club != "" {
+		return append([]storage.Revision{}, r.live(articleKey(club, articleID))...), nil
+	}
+
+	revisions := []storage.Revision{}
+	for key, revs := range r.revisions {
+		if len(revs) > 0 && revs[0].ArticleID == articleID {
+			revisions = append(revisions, r.live(key)...)
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+
+	return revisions, nil
+}
+
+// live returns the revisions of the article with the given key that haven't expired yet
+func (r *RevisionRepository) live(key string) []storage.Revision {
+	expiry := r.now().Add(-r.ttl)
+
+	var revisions []storage.Revision
+	for _, rev := range r.revisions[key] {
+		if !rev.CreatedAt.Before(expiry) {
+			revisions = append(revisions, rev)
+		}
+	}
+
+	return revisions
+}
+
+func hasRevision(revisions []storage.Revision, revision int) bool {
+	for _, rev := range revisions {
+		if rev.Revision == revision {
+			return true
+		}
+	}
+	return false
+}
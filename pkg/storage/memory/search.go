@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+)
+
+// Field weights, the same as the ones of the mongo text index
+var searchWeights = []struct {
+	weight float64
+	text   func(d news.Data) string
+}{
+	{10, func(d news.Data) string { return d.Title }},
+	{5, func(d news.Data) string { return d.Subtitle }},
+	{3, func(d news.Data) string { s, _ := d.Teaser.(string); return s }},
+	{1, func(d news.Data) string { return d.Content }},
+}
+
+// SearchNews returns a page of the articles matching a full-text search sorted by -score.
+// Like mongo's $text, an article matches when it contains any of the words, all of the
+// phrases and none of the excluded terms. Words match the words sharing their stem
+func (r *Repository) SearchNews(_ context.Context, q storage.SearchQuery) (storage.SearchPage, error) {
+	terms := news.ParseSearch(q.Text)
+	if len(terms.Words) == 0 && len(terms.Phrases) == 0 {
+		return storage.SearchPage{}, storage.NewError(storage.ErrInvalidQuery, "searchNews", nil)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matching []*article
+	var positions []storage.Cursor
+	for _, a := range r.articles {
+		if !r.live(a) || !matches(a, q.PageQuery) {
+			continue
+		}
+		if score, ok := score(a.result.Data, terms); ok {
+			matching = append(matching, a)
+			positions = append(positions, storage.Cursor{Score: score, PublishedAt: a.result.PublishedAt, ArticleID: a.result.ArticleID})
+		}
+	}
+	sort.Sort(byPosition{positions, matching})
+
+	from, to, hasMore := pageBounds(positions, q.Cursor, q.Limit)
+
+	page := storage.SearchPage{TotalItems: int64(len(matching)), Results: []storage.SearchResult{}}
+	for i, a := range matching[from:to] {
+		page.Results = append(page.Results, storage.SearchResult{Result: a.result, Score: positions[from+i].Score})
+	}
+	page.Next, page.Prev = storage.PageCursors(q.Cursor, to-from, hasMore, func(i int) storage.Cursor {
+		return positions[from+i]
+	})
+
+	return page, nil
+}
+
+// byPosition sorts search results and their positions alike
+type byPosition struct {
+	positions []storage.Cursor
+	articles  []*article
+}
+
+func (b byPosition) Len() int           { return len(b.positions) }
+func (b byPosition) Less(i, j int) bool { return before(b.positions[i], b.positions[j]) }
+func (b byPosition) Swap(i, j int) {
+	b.positions[i], b.positions[j] = b.positions[j], b.positions[i]
+	b.articles[i], b.articles[j] = b.articles[j], b.articles[i]
+}
+
+// score returns the weighted number of matches of the terms in the searchable fields of an article
+// and whether the article matches the search at all. Terms are compared by the stems of their words
+func score(d news.Data, terms news.SearchTerms) (float64, bool) {
+	var total float64
+	var all strings.Builder
+	phrases := make([]bool, len(terms.Phrases))
+
+	for _, f := range searchWeights {
+		text := stems(f.text(d))
+		all.WriteString(text)
+
+		for _, w := range terms.Words {
+			if stem := stems(w); stem != "" {
+				total += f.weight * float64(strings.Count(text, stem))
+			}
+		}
+
+		for i, p := range terms.Phrases {
+			if stem := stems(p); stem != "" && strings.Contains(text, stem) {
+				phrases[i] = true
+				total += f.weight * float64(strings.Count(text, stem))
+			}
+		}
+	}
+
+	for _, found := range phrases {
+		if !found {
+			return 0, false
+		}
+	}
+
+	for _, e := range terms.Excluded {
+		if stem := stems(e); stem != "" && strings.Contains(all.String(), stem) {
+			return 0, false
+		}
+	}
+
+	return total, total > 0
+}
+
+// stems returns the stems of the words of the plain text of a field, each one wrapped in spaces,
+// so that counting a term's stems in it counts whole word matches
+func stems(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(news.PlainText(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	for i, w := range words {
+		if len(w) > 3 {
+			words[i] = strings.TrimSuffix(w, "s")
+		}
+	}
+
+	return " " + strings.Join(words, "  ") + " "
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"com.thanos/pkg/storage"
+)
+
+// SyncStatusRepository is an in-memory storage.SyncStatusRepo
+type SyncStatusRepository struct {
+	mu     sync.RWMutex
+	status *storage.SyncStatus
+}
+
+// NewSyncStatusRepository creates a new in-memory sync status repository
+func NewSyncStatusRepository() *SyncStatusRepository {
+	return &SyncStatusRepository{}
+}
+
+// SetSyncStatus stores the status of the latest sync. A zero LastSuccessAt keeps the stored one
+func (r *SyncStatusRepository) SetSyncStatus(_ context.Context, status storage.SyncStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if status.LastSuccessAt.IsZero() && r.status != nil {
+		status.LastSuccessAt = r.status.LastSuccessAt
+	}
+
+	feeds := make(map[string]string, len(status.Feeds))
+	for feed, state := range status.Feeds {
+		feeds[feed] = state
+	}
+	status.Feeds = feeds
+
+	r.status = &status
+
+	return nil
+}
+
+// GetSyncStatus returns the status of the latest sync, or a storage.ErrNotFound error when nothing was synced yet
+func (r *SyncStatusRepository) GetSyncStatus(_ context.Context) (storage.SyncStatus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.status == nil {
+		return storage.SyncStatus{}, storage.NewError(storage.ErrNotFound, "getSyncStatus", nil)
+	}
+
+	return *r.status, nil
+}
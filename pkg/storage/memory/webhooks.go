@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"com.thanos/pkg/storage"
+)
+
+// WebhookRepository is an in-memory storage.WebhookRepo. Deliveries expire ttl after they were created
+type WebhookRepository struct {
+	mu         sync.RWMutex
+	ttl        time.Duration
+	webhooks   map[string]storage.Webhook
+	deliveries map[string]storage.Delivery
+	now        func() time.Time
+}
+
+// NewWebhookRepository creates a new in-memory webhook repository
+func NewWebhookRepository(ttl time.Duration) *WebhookRepository {
+	return &WebhookRepository{
+		ttl:        ttl,
+		webhooks:   make(map[string]storage.Webhook),
+		deliveries: make(map[string]storage.Delivery),
+		now:        time.Now,
+	}
+}
+
+// CreateWebhook stores a new webhook, a storage.ErrConflict error when its id is taken
+func (r *WebhookRepository) CreateWebhook(_ context.Context, w storage.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[w.ID]; ok {
+		return storage.NewError(storage.ErrConflict, "createWebhook", nil)
+	}
+	w.Events = append([]string{}, w.Events...)
+	w.Clubs = append([]string{}, w.Clubs...)
+	r.webhooks[w.ID] = w
+
+	return nil
+}
+
+// GetWebhooks returns every webhook ordered by creation
+func (r *WebhookRepository) GetWebhooks(_ context.Context) ([]storage.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhooks := make([]storage.Webhook, 0, len(r.webhooks))
+	for _, w := range r.webhooks {
+		webhooks = append(webhooks, w)
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].ID < webhooks[j].ID
+	})
+
+	return webhooks, nil
+}
+
+// GetWebhook returns a webhook, a storage.ErrNotFound error when it doesn't exist
+func (r *WebhookRepository) GetWebhook(_ context.Context, id string) (storage.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.webhooks[id]
+	if !ok {
+		return w, storage.NewError(storage.ErrNotFound, "getWebhook", nil)
+	}
+
+	return w, nil
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries, a storage.ErrNotFound error when it doesn't exist
+func (r *WebhookRepository) DeleteWebhook(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return storage.NewError(storage.ErrNotFound, "deleteWebhook", nil)
+	}
+	delete(r.webhooks, id)
+
+	for deliveryID, d := range r.deliveries {
+		if d.WebhookID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+// AddDeliveries queues the deliveries
+func (r *WebhookRepository) AddDeliveries(_ context.Context, deliveries []storage.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune()
+	for _, d := range deliveries {
+		if _, ok := r.deliveries[d.ID]; ok {
+			return storage.NewError(storage.ErrConflict, "addDeliveries", nil)
+		}
+		r.deliveries[d.ID] = d
+	}
+
+	return nil
+}
+
+// ClaimDeliveries returns up to limit pending deliveries due at now, oldest first, and postpones their next
+// attempt by lease so that they're not claimed again while they're attempted
+func (r *WebhookRepository) ClaimDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]storage.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := []storage.Delivery{}
+	for _, d := range r.deliveries {
+		if d.Status == storage.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].NextAttemptAt = now.Add(lease)
+		r.deliveries[due[i].ID] = due[i]
+	}
+
+	return due, nil
+}
+
+// UpdateDelivery stores the outcome of an attempted delivery
+func (r *WebhookRepository) UpdateDelivery(_ context.Context, d storage.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.deliveries[d.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = d.Status
+	stored.Attempts = d.Attempts
+	stored.NextAttemptAt = d.NextAttemptAt
+	stored.ResponseStatus = d.ResponseStatus
+	stored.Error = d.Error
+	r.deliveries[d.ID] = stored
+
+	return nil
+}
+
+// GetDeliveries returns the latest limit deliveries of a webhook, newest first
+func (r *WebhookRepository) GetDeliveries(_ context.Context, webhookID string, limit int) ([]storage.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expiry := r.now().Add(-r.ttl)
+
+	deliveries := []storage.Delivery{}
+	for _, d := range r.deliveries {
+		if d.WebhookID == webhookID && !d.CreatedAt.Before(expiry) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+// prune deletes the expired deliveries, the repository must be locked
+func (r *WebhookRepository) prune() {
+	expiry := r.now().Add(-r.ttl)
+
+	for id, d := range r.deliveries {
+		if d.CreatedAt.Before(expiry) {
+			delete(r.deliveries, id)
+		}
+	}
+}
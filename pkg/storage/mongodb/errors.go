@@ -0,0 +1,57 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"com.thanos/pkg/storage"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// Server error codes of queries that can never succeed
+var invalidQueryCodes = []int{
+	2,  // BadValue
+	9,  // FailedToParse
+	14, // TypeMismatch
+	27, // IndexNotFound, e.g. a $text search without a text index
+}
+
+// translateError reports a mongo failure of an operation as a storage error, so that callers
+// don't depend on the mongo driver. Errors of an unknown kind are returned as they are
+func translateError(op string, err error) error {
+	var storageErr *storage.Error
+	if err == nil || errors.As(err, &storageErr) {
+		return err
+	}
+
+	var kind error
+	var serverSelection topology.ServerSelectionError
+	var serverErr mongo.ServerError
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		kind = storage.ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		kind = storage.ErrConflict
+	case mongo.IsTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		kind = storage.ErrTimeout
+	case mongo.IsNetworkError(err), errors.As(err, &serverSelection), errors.Is(err, mongo.ErrClientDisconnected):
+		kind = storage.ErrUnavailable
+	case errors.As(err, &serverErr) && hasAnyErrorCode(serverErr, invalidQueryCodes):
+		kind = storage.ErrInvalidQuery
+	default:
+		return err
+	}
+
+	return storage.NewError(kind, op, err)
+}
+
+func hasAnyErrorCode(err mongo.ServerError, codes []int) bool {
+	for _, c := range codes {
+		if err.HasErrorCode(c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// CreateIndexes creates the indexes of the articles collection. Creating an existing index is a no-op
+// so it's safe for every binary that uses the collection to call this on startup
+func CreateIndexes(coll *mongo.Collection, ttl time.Duration) error {
+	indexOpts := options.Index()
+	indexOpts.SetUnique(true)
+
+	indexView := coll.Indexes()
+	expireAfterSeconds := int32(ttl.Seconds())
+
+	_, err := indexView.CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{
+				// Article ids are only unique within a club
+				Keys: bsonx.Doc{
+					{Key: "club", Value: bsonx.Int32(1)},
+					{Key: "articleID", Value: bsonx.Int32(1)},
+				},
+				Options: indexOpts,
+			},
+			{
+				Keys: bsonx.Doc{
+					{Key: "publishedAt", Value: bsonx.Int32(1)},
+				},
+				Options: &options.IndexOptions{ExpireAfterSeconds: &expireAfterSeconds},
+			},
+			{
+				// Supports the stable -published sort used by cursor pagination
+				Keys: bsonx.Doc{
+					{Key: "publishedAt", Value: bsonx.Int32(-1)},
+					{Key: "articleID", Value: bsonx.Int32(-1)},
+				},
+			},
+			{
+				// Supports finding the articles of a source that went missing from its feed
+				Keys: bsonx.Doc{
+					{Key: "source", Value: bsonx.Int32(1)},
+					{Key: "publishedAt", Value: bsonx.Int32(-1)},
+				},
+			},
+			{
+				// Supports listing the articles of a single club
+				Keys: bsonx.Doc{
+					{Key: "club", Value: bsonx.Int32(1)},
+					{Key: "publishedAt", Value: bsonx.Int32(-1)},
+					{Key: "articleID", Value: bsonx.Int32(-1)},
+				},
+			},
+			{
+				// Support filtering the -published sorted articles by type, team and match
+				Keys: bsonx.Doc{
+					{Key: "data.type", Value: bsonx.Int32(1)},
+					{Key: "publishedAt", Value: bsonx.Int32(-1)},
+					{Key: "articleID", Value: bsonx.Int32(-1)},
+				},
+			},
+			{
+				Keys: bsonx.Doc{
+					{Key: "data.teamID", Value: bsonx.Int32(1)},
+					{Key: "publishedAt", Value: bsonx.Int32(-1)},
+					{Key: "articleID", Value: bsonx.Int32(-1)},
+				},
+			},
+			{
+				Keys: bsonx.Doc{
+					{Key: "data.optaMatchID", Value: bsonx.Int32(1)},
+					{Key: "publishedAt", Value: bsonx.Int32(-1)},
+					{Key: "articleID", Value: bsonx.Int32(-1)},
+				},
+			},
+			{
+				// Supports full-text search, a collection can only have a single text index
+				Keys: bsonx.Doc{
+					{Key: "data.title", Value: bsonx.String("text")},
+					{Key: "data.subtitle", Value: bsonx.String("text")},
+					{Key: "data.teaser", Value: bsonx.String("text")},
+					{Key: "data.content", Value: bsonx.String("text")},
+				},
+				Options: options.Index().
+					SetName("articles_text").
+					SetWeights(bson.D{
+						{Key: "data.title", Value: 10},
+						{Key: "data.subtitle", Value: 5},
+						{Key: "data.teaser", Value: 3},
+						{Key: "data.content", Value: 1},
+					}),
+			},
+			{
+				// Supports polling the latest writes
+				Keys: bsonx.Doc{
+					{Key: "updatedAt", Value: bsonx.Int32(1)},
+				},
+			},
+		})
+
+	return err
+}
@@ -0,0 +1,82 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrationsCollection records the migrations applied to a database
+const migrationsCollection = "migrations"
+
+// Server error codes of dropping an index that doesn't exist
+const (
+	namespaceNotFound = 26
+	indexNotFound     = 27
+)
+
+// migration is a one-time change of the stored articles
+type migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, coll *mongo.Collection) error
+}
+
+// migrations are applied in order, each of them only once per database
+var migrations = []migration{
+	{
+		Version:     1,
+		Description: "drop the articleID index, article ids are only unique within a club",
+		Up: func(ctx context.Context, coll *mongo.Collection) error {
+			return dropIndex(ctx, coll.Indexes(), "articleID_1")
+		},
+	},
+}
+
+// Migrate applies the migrations of the articles collection that haven't been applied yet and records them in
+// the migrations collection of its database. It must run before CreateIndexes. Migrations are idempotent so
+// binaries starting at the same time may both apply one, the first to record it wins
+func Migrate(ctx context.Context, coll *mongo.Collection) error {
+	applied := coll.Database().Collection(migrationsCollection)
+
+	for _, m := range migrations {
+		err := applied.FindOne(ctx, bson.D{{Key: "_id", Value: m.Version}}).Err()
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("could not check migration %d: %w", m.Version, err)
+		}
+
+		if err := m.Up(ctx, coll); err != nil {
+			return fmt.Errorf("could not apply migration %d (%s): %w", m.Version, m.Description, err)
+		}
+
+		_, err = applied.InsertOne(ctx, bson.D{
+			{Key: "_id", Value: m.Version},
+			{Key: "description", Value: m.Description},
+			{Key: "appliedAt", Value: time.Now().UTC()},
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("could not record migration %d: %w", m.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// dropIndex drops an index of a collection, dropping an index or from a collection that doesn't exist is a no-op
+func dropIndex(ctx context.Context, indexView mongo.IndexView, name string) error {
+	_, err := indexView.DropOne(ctx, name)
+
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && (serverErr.HasErrorCode(namespaceNotFound) || serverErr.HasErrorCode(indexNotFound)) {
+		return nil
+	}
+
+	return err
+}
@@ -2,17 +2,18 @@ package mongodb
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"com.thanos/pkg/config"
 	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const DATE_TIME_FORMAT = "2006-01-02 15:04:05"
+const DATE_TIME_FORMAT = news.DateTimeFormat
 
 // Repository mongo struct
 type Repository struct {
@@ -20,12 +21,6 @@ type Repository struct {
 	cfg                config.Mongo
 }
 
-// BulkInsertResult represents the result of a bulk insert operation
-type BulkInsertResult struct {
-	InsertedCount int64
-	UpsertedCount int64
-}
-
 // NewMongoRepo creates a new Mongo repository
 func NewMongoRepo(n *mongo.Collection, c config.Mongo) *Repository {
 	return &Repository{
@@ -34,63 +29,266 @@ func NewMongoRepo(n *mongo.Collection, c config.Mongo) *Repository {
 	}
 }
 
-// Result represents a mongodb result
-type Result struct {
-	ID        string    `json:"-" bson:"_id"`
-	ArticleID string    `json:"-" bson:"articleID"`
-	Data      news.Data `json:"data"`
+// GetArticleByID returns the article of a club with the given id. When club is empty the latest published
+// article with that id is returned, whatever its club
+func (r Repository) GetArticleByID(ctx context.Context, club, id string) (newsArticle storage.Result, err error) {
+	defer func() { err = translateError("getArticleByID", err) }()
+
+	filter := bson.D{{Key: "articleID", Value: id}}
+	if club != "" {
+		filter = append(filter, bson.E{Key: "club", Value: club})
+	}
+
+	result := r.articlesCollection.FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "publishedAt", Value: -1}}))
+
+	err = result.Decode(&newsArticle)
+
+	return newsArticle, err
 }
 
-func (r Repository) GetArticleByID(ctx context.Context, id string) (newsArticle Result, err error) {
-	result := r.articlesCollection.FindOne(ctx, bson.D{{Key: "articleID", Value: id}})
+// GetFingerprints returns the stored Fingerprint of every given article id of a club that exists
+func (r Repository) GetFingerprints(ctx context.Context, club string, ids []string) (_ map[string]storage.Fingerprint, err error) {
+	defer func() { err = translateError("getFingerprints", err) }()
+
+	fingerprints := make(map[string]storage.Fingerprint, len(ids))
+
+	cursor, err := r.articlesCollection.Find(
+		ctx,
+		bson.D{
+			{Key: "club", Value: club},
+			{Key: "articleID", Value: bson.D{{Key: "$in", Value: ids}}},
+		},
+		options.Find().SetProjection(bson.D{
+			{Key: "articleID", Value: 1},
+			{Key: "lastUpdateDate", Value: 1},
+			{Key: "contentHash", Value: 1},
+			{Key: "revision", Value: 1},
+			{Key: "withdrawn", Value: 1},
+		}),
+	)
+	if err != nil {
+		return fingerprints, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ArticleID           string `bson:"articleID"`
+			storage.Fingerprint `bson:",inline"`
+		}
 
-	if err = result.Decode(&newsArticle); err != nil {
-		if err == mongo.ErrNoDocuments {
-			return newsArticle, fmt.Errorf("article id (%s) does not exist: %w", id, err)
+		if err = cursor.Decode(&doc); err != nil {
+			return fingerprints, err
 		}
+
+		fingerprints[doc.ArticleID] = doc.Fingerprint
 	}
 
-	return newsArticle, err
+	return fingerprints, cursor.Err()
 }
 
-// GetNews returns a list of all newArticles
-func (r Repository) GetNews(ctx context.Context) (newsArticles []Result, err error) {
-	sortStage := bson.D{{"$sort", bson.D{{"published", -1}}}}
-	cursor, err := r.articlesCollection.Aggregate(
-		context.Background(),
-		mongo.Pipeline{
+// SetLastUpdateDates updates the stored LastUpdateDate of articles of a club whose content didn't change
+func (r Repository) SetLastUpdateDates(ctx context.Context, club string, lastUpdateDates map[string]string) (err error) {
+	defer func() { err = translateError("setLastUpdateDates", err) }()
+
+	if len(lastUpdateDates) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(lastUpdateDates))
+	for id, lud := range lastUpdateDates {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "club", Value: club}, {Key: "articleID", Value: id}}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "lastUpdateDate", Value: lud}}}}))
+	}
+
+	_, err = r.articlesCollection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+
+	return err
+}
+
+// GetSourceArticleIDs returns the ids of the articles of a club's source published after the given time,
+// that have not been withdrawn
+func (r Repository) GetSourceArticleIDs(ctx context.Context, club, source string, publishedAfter time.Time) (_ []string, err error) {
+	defer func() { err = translateError("getSourceArticleIDs", err) }()
+
+	ids := []string{}
+
+	cursor, err := r.articlesCollection.Find(
+		ctx,
+		bson.D{
+			{Key: "club", Value: club},
+			{Key: "source", Value: source},
+			{Key: "publishedAt", Value: bson.D{{Key: "$gt", Value: publishedAfter}}},
+			{Key: "withdrawn", Value: bson.D{{Key: "$exists", Value: false}}},
+		},
+		options.Find().SetProjection(bson.D{{Key: "articleID", Value: 1}}),
+	)
+	if err != nil {
+		return ids, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ArticleID string `bson:"articleID"`
+		}
+
+		if err = cursor.Decode(&doc); err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, doc.ArticleID)
+	}
+
+	return ids, cursor.Err()
+}
+
+// WithdrawArticles marks articles of a club as withdrawn upstream. Articles that are already withdrawn keep
+// their original withdrawal. It returns the ids of the newly withdrawn articles
+func (r Repository) WithdrawArticles(ctx context.Context, club string, ids []string, reason string) (_ []string, err error) {
+	defer func() { err = translateError("withdrawArticles", err) }()
+
+	withdrawn := []string{}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "withdrawn", Value: news.Withdrawal{At: time.Now().UTC(), Reason: reason}},
+		}},
+		{Key: "$currentDate", Value: bson.D{
+			{Key: "updatedAt", Value: true},
+		}},
+	}
+
+	// Articles are withdrawn one at a time to find out which of them were withdrawn by this call
+	for _, id := range ids {
+		err = r.articlesCollection.FindOneAndUpdate(
+			ctx,
 			bson.D{
-				{Key: "$match", Value: bson.D{
-					{Key: "publishedAt", Value: bson.D{
-						{Key: "$gte", Value: time.Now().Add(-r.cfg.TTL).UTC()},
-					}},
-				}},
+				{Key: "club", Value: club},
+				{Key: "articleID", Value: id},
+				{Key: "withdrawn", Value: bson.D{{Key: "$exists", Value: false}}},
 			},
-			sortStage,
+			update,
+			options.FindOneAndUpdate().SetProjection(bson.D{{Key: "_id", Value: 1}}),
+		).Err()
+
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			continue
+		case err != nil:
+			return withdrawn, err
+		}
+
+		withdrawn = append(withdrawn, id)
+	}
+
+	return withdrawn, nil
+}
+
+// GetNewsPage returns a page of newsArticles sorted by -published. Ties on the publish time are
+// broken by articleID so that paging through articles sharing a publish time is stable
+func (r Repository) GetNewsPage(ctx context.Context, q storage.PageQuery) (page storage.Page, err error) {
+	defer func() { err = translateError("getNewsPage", err) }()
+
+	ttlMatch := r.pageMatch(q)
+
+	page.TotalItems, err = r.articlesCollection.CountDocuments(ctx, ttlMatch)
+	if err != nil {
+		return page, err
+	}
+
+	backward := q.Cursor != nil && q.Cursor.Backward
+	direction := sortDirection(q.Cursor)
+
+	match := ttlMatch
+	if q.Cursor != nil {
+		match = bson.D{{Key: "$and", Value: bson.A{ttlMatch, seekMatch(*q.Cursor, false)}}}
+	}
+
+	// Fetch one extra document to find out whether there are more pages in that direction
+	cursor, err := r.articlesCollection.Aggregate(
+		ctx,
+		mongo.Pipeline{
+			bson.D{{Key: "$match", Value: match}},
+			bson.D{{Key: "$sort", Value: bson.D{
+				{Key: "publishedAt", Value: direction},
+				{Key: "articleID", Value: direction},
+			}}},
+			bson.D{{Key: "$limit", Value: q.Limit + 1}},
 		},
 		options.Aggregate(),
 	)
-
 	if err != nil {
-		return newsArticles, err
+		return page, err
 	}
+	defer cursor.Close(ctx)
 
-	for cursor.Next(ctx) {
-		article := Result{}
+	results := make([]storage.Result, 0, q.Limit+1)
+	if err = cursor.All(ctx, &results); err != nil {
+		return page, err
+	}
 
-		err = cursor.Decode(&article)
-		if err != nil {
-			return newsArticles, err
+	hasMore := len(results) > q.Limit
+	if hasMore {
+		results = results[:q.Limit]
+	}
+
+	if backward {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
 		}
+	}
 
-		newsArticles = append(newsArticles, article)
+	page.Results = results
+	page.Next, page.Prev = storage.PageCursors(q.Cursor, len(results), hasMore, func(i int) storage.Cursor {
+		return storage.Cursor{PublishedAt: results[i].PublishedAt, ArticleID: results[i].ArticleID}
+	})
+
+	return page, nil
+}
+
+// StreamNews calls fn with every article of the -published ordered list following the query's cursor, up to
+// its limit when it's set, as they are read from the database. Backward cursors are streamed forward from their
+// position. Streaming stops at the first error of fn, which is returned
+func (r Repository) StreamNews(ctx context.Context, q storage.PageQuery, fn func(storage.Result) error) (err error) {
+	defer func() { err = translateError("streamNews", err) }()
+
+	match := r.pageMatch(q)
+	if q.Cursor != nil {
+		c := *q.Cursor
+		c.Backward = false
+		match = bson.D{{Key: "$and", Value: bson.A{match, seekMatch(c, false)}}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "publishedAt", Value: -1}, {Key: "articleID", Value: -1}})
+	if q.Limit > 0 {
+		opts.SetLimit(int64(q.Limit))
 	}
 
-	return newsArticles, err
+	cursor, err := r.articlesCollection.Find(ctx, match, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var res storage.Result
+		if err = cursor.Decode(&res); err != nil {
+			return err
+		}
+		if err = fn(res); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
 }
 
-// BulkInsert inserts an array of NewsArticles using upsert to avoid dupes
-func (r Repository) BulkInsert(news []news.NewsArticle) (*BulkInsertResult, error) {
+// BulkInsert inserts an array of NewsArticles using upsert to avoid dupes, an article is identified by its club and id.
+// The createdAt metadata is only set when an article is first inserted, updatedAt is set by the server on every write
+func (r Repository) BulkInsert(ctx context.Context, news []news.NewsArticle) (_ *storage.BulkInsertResult, err error) {
+	defer func() { err = translateError("bulkInsert", err) }()
+
 	// Update records in any order
 	bulkWriteOpts := options.BulkWrite()
 	bulkWriteOpts.SetOrdered(false)
@@ -102,39 +300,159 @@ func (r Repository) BulkInsert(news []news.NewsArticle) (*BulkInsertResult, erro
 
 		dt, err := time.Parse(DATE_TIME_FORMAT, n.Data.Published)
 		if err != nil {
-			return nil, err
+			return nil, storage.NewError(storage.ErrInvalidQuery, "bulkInsert", err)
 		}
 
-		n.Metadata.CreatedAt = time.Now().Format(time.RFC3339)
-
 		model := bulkModel.SetFilter(bson.D{
+			{Key: "club", Value: n.Club},
 			{Key: "articleID", Value: n.Data.Id},
 		}).SetUpdate(bson.D{
-			{Key: "$set", Value: n},
 			{Key: "$set", Value: bson.D{
+				{Key: "data", Value: n.Data},
+				{Key: "status", Value: n.Status},
+				{Key: "lastUpdateDate", Value: n.LastUpdateDate},
+				{Key: "contentHash", Value: n.ContentHash},
+				{Key: "revision", Value: n.Revision},
+				{Key: "source", Value: n.Source},
 				{Key: "publishedAt", Value: dt},
 			}},
+			// Articles that show up again upstream are no longer withdrawn
+			{Key: "$unset", Value: bson.D{
+				{Key: "withdrawn", Value: ""},
+			}},
+			{Key: "$currentDate", Value: bson.D{
+				{Key: "updatedAt", Value: true},
+			}},
+			{Key: "$setOnInsert", Value: bson.D{
+				{Key: "metadata", Value: bson.D{
+					{Key: "createdAt", Value: time.Now().Format(time.RFC3339)},
+				}},
+			}},
 		}).SetUpsert(true)
 
 		models[i] = model
 	}
 
-	res, err := r.articlesCollection.BulkWrite(context.Background(), models, bulkWriteOpts)
+	res, err := r.articlesCollection.BulkWrite(ctx, models, bulkWriteOpts)
 	result := newBulkWriteResult(res)
 
 	return &result, err
 }
 
-func newBulkWriteResult(bwr *mongo.BulkWriteResult) BulkInsertResult {
+// GetUpdatedArticles returns the articles stored or withdrawn at or after since, oldest write first
+func (r Repository) GetUpdatedArticles(ctx context.Context, since time.Time) (_ []storage.UpdatedArticle, err error) {
+	defer func() { err = translateError("getUpdatedArticles", err) }()
+
+	updated := []storage.UpdatedArticle{}
+
+	cursor, err := r.articlesCollection.Find(
+		ctx,
+		bson.D{
+			{Key: "updatedAt", Value: bson.D{{Key: "$gte", Value: since}}},
+			{Key: "publishedAt", Value: bson.D{{Key: "$gte", Value: time.Now().Add(-r.cfg.TTL).UTC()}}},
+		},
+		options.Find().SetSort(bson.D{{Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return updated, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &updated)
+
+	return updated, err
+}
+
+func newBulkWriteResult(bwr *mongo.BulkWriteResult) storage.BulkInsertResult {
 	if bwr == nil {
-		return BulkInsertResult{
+		return storage.BulkInsertResult{
 			InsertedCount: 0,
 			UpsertedCount: 0,
+			ModifiedCount: 0,
 		}
 	}
 
-	return BulkInsertResult{
+	return storage.BulkInsertResult{
 		InsertedCount: bwr.InsertedCount,
 		UpsertedCount: bwr.UpsertedCount,
+		ModifiedCount: bwr.ModifiedCount,
+	}
+}
+
+// pageMatch matches the articles within the TTL window that a page query may return
+func (r Repository) pageMatch(q storage.PageQuery) bson.D {
+	f := q.Filter
+
+	from := time.Now().Add(-r.cfg.TTL).UTC()
+	if f.From != nil && f.From.After(from) {
+		from = f.From.UTC()
+	}
+	publishedAt := bson.D{{Key: "$gte", Value: from}}
+	if f.To != nil {
+		publishedAt = append(publishedAt, bson.E{Key: "$lt", Value: f.To.UTC()})
+	}
+
+	match := bson.D{{Key: "publishedAt", Value: publishedAt}}
+	if q.Club != "" {
+		match = append(match, bson.E{Key: "club", Value: q.Club})
+	}
+	if !q.IncludeWithdrawn {
+		match = append(match, bson.E{Key: "withdrawn", Value: bson.D{{Key: "$exists", Value: false}}})
+	}
+	if len(f.Types) > 0 {
+		match = append(match, bson.E{Key: "data.type", Value: bson.D{{Key: "$in", Value: f.Types}}})
+	}
+	if f.OptaMatchId != "" {
+		match = append(match, bson.E{Key: "data.optaMatchID", Value: f.OptaMatchId})
+	}
+	if f.TeamId != "" {
+		match = append(match, bson.E{Key: "data.teamID", Value: f.TeamId})
+	}
+	if f.HasVideo != nil {
+		// articles without a video store it as null
+		cmp := "$eq"
+		if *f.HasVideo {
+			cmp = "$ne"
+		}
+		match = append(match, bson.E{Key: "data.videoUrl", Value: bson.D{{Key: cmp, Value: nil}}})
+	}
+	if f.HasGallery != nil {
+		match = append(match, bson.E{Key: "data.galleryUrls.0", Value: bson.D{{Key: "$exists", Value: *f.HasGallery}}})
+	}
+
+	return match
+}
+
+// sortDirection returns the direction of the page sort, pages preceding a backward cursor are sorted ascending
+func sortDirection(c *storage.Cursor) int {
+	if c != nil && c.Backward {
+		return 1
+	}
+	return -1
+}
+
+// seekMatch matches the articles following the cursor position, or preceding it for backward cursors.
+// Positions are ordered by publishedAt and articleID, preceded by the relevance score for search cursors
+func seekMatch(c storage.Cursor, withScore bool) bson.D {
+	cmp := "$lt"
+	if c.Backward {
+		cmp = "$gt"
+	}
+
+	or := bson.A{
+		bson.D{{Key: "publishedAt", Value: bson.D{{Key: cmp, Value: c.PublishedAt}}}},
+		bson.D{
+			{Key: "publishedAt", Value: c.PublishedAt},
+			{Key: "articleID", Value: bson.D{{Key: cmp, Value: c.ArticleID}}},
+		},
 	}
+
+	if withScore {
+		for i := range or {
+			or[i] = append(bson.D{{Key: "score", Value: c.Score}}, or[i].(bson.D)...)
+		}
+		or = append(bson.A{bson.D{{Key: "score", Value: bson.D{{Key: cmp, Value: c.Score}}}}}, or...)
+	}
+
+	return bson.D{{Key: "$or", Value: or}}
 }
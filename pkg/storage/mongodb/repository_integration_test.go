@@ -2,25 +2,48 @@ package mongodb_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/ingestion"
+	"com.thanos/pkg/ingestion/source"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/metrics"
 	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
 	"com.thanos/pkg/storage/mongodb"
+	"com.thanos/pkg/storage/storagetest"
+	"com.thanos/pkg/upstream"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
 )
 
-var repository *mongodb.Repository
+var (
+	cfg        *config.Config
+	db         *mongo.Database
+	repository *mongodb.Repository
+	mClient    *mongo.Client
+	revisions  *mongodb.RevisionRepository
+	syncStatus *mongodb.SyncStatusRepository
+	webhooks   *mongodb.WebhookRepository
+)
 
 func TestMain(m *testing.M) {
 	var err error
 
-	cfg, err := config.New()
+	cfg, err = config.New()
 	if err != nil {
 		fmt.Printf("could not load configuration, failed to start tests. %v", err)
 		os.Exit(1)
@@ -33,73 +56,329 @@ func TestMain(m *testing.M) {
 	log := logrus.New()
 	log.Out = ioutil.Discard
 
-	mClient, err := mongodb.NewMongoClient(cfg.Mongo)
+	mClient, err = mongodb.NewMongoClient(cfg.Mongo)
 	if err != nil {
 		fmt.Printf("could not initialize mongodb client, %v", err)
 		os.Exit(1)
 	}
 
-	db := mClient.Database(cfg.Mongo.Database)
+	db = mClient.Database(cfg.Mongo.Database)
 	collection := db.Collection(cfg.Mongo.Collection)
+	if err = mongodb.CreateIndexes(collection, cfg.Mongo.TTL); err != nil {
+		fmt.Printf("could not create indexes, %v", err)
+		os.Exit(1)
+	}
 	repository = mongodb.NewMongoRepo(collection, cfg.Mongo)
 
+	revisionsCollection := db.Collection(cfg.Mongo.RevisionsCollection)
+	if err = mongodb.CreateRevisionIndexes(revisionsCollection, cfg.Mongo.TTL); err != nil {
+		fmt.Printf("could not create revision indexes, %v", err)
+		os.Exit(1)
+	}
+	revisions = mongodb.NewMongoRevisionRepo(revisionsCollection)
+	syncStatus = mongodb.NewMongoSyncStatusRepo(db.Collection(cfg.Mongo.SyncStatusCollection))
+
+	deliveriesCollection := db.Collection(cfg.Mongo.WebhookDeliveriesCollection)
+	if err = mongodb.CreateWebhookIndexes(deliveriesCollection, cfg.Mongo.TTL); err != nil {
+		fmt.Printf("could not create webhook delivery indexes, %v", err)
+		os.Exit(1)
+	}
+	webhooks = mongodb.NewMongoWebhookRepo(db.Collection(cfg.Mongo.WebhooksCollection), deliveriesCollection)
+
 	// set seed so that random is semi-predictable
 	rand.Seed(54)
 
 	os.Exit(m.Run())
 }
 
-func TestMongoDBRepo_GetNews(t *testing.T) {
-	randomArticle := newArticle("1234")
+func TestMongoDBRepo_GetArticleByID(t *testing.T) {
+	id := "4321"
+	randomArticle := newArticle(id)
 
 	_, err := repository.BulkInsert(
+		context.Background(),
 		[]news.NewsArticle{randomArticle},
 	)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	n, err := repository.GetNews(context.TODO())
+	n, err := repository.GetArticleByID(context.TODO(), "", id)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if len(n) == 0 {
-		t.Fatal("news articles returned should be > 0")
+	if n.ArticleID != id {
+		t.Fatalf("could not find article with id: %s, got: %s", id, n.ArticleID)
 	}
 
 	// TODO: perform deep equality check here
-	if randomArticle.Data.Content != n[0].Data.Content {
+	if randomArticle.Data.Content != n.Data.Content {
 		t.Fatal("news article contents should match")
 	}
 
-	// TODO: all randomly generated newsArticles need to be cleaned at the end of the test to avoid
-	// errors on consecutive runs.
+	if _, err = repository.GetArticleByID(context.TODO(), "", "does-not-exist"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected a not found error, got: %v", err)
+	}
 }
 
-func TestMongoDBRepo_GetArticleByID(t *testing.T) {
-	id := "4321"
+func TestMongoDBRepo_GetNewsPage(t *testing.T) {
+	// Articles sharing a publish time must be paged through in a stable order
+	published := time.Now().Add(-time.Minute).Format(mongodb.DATE_TIME_FORMAT)
+	articles := make([]news.NewsArticle, 3)
+	for i := range articles {
+		articles[i] = newArticle(fmt.Sprintf("99990%d", i))
+		articles[i].Data.Published = published
+	}
+
+	if _, err := repository.BulkInsert(context.Background(), articles); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	q := storage.PageQuery{Limit: 1}
+
+	for {
+		page, err := repository.GetNewsPage(context.TODO(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, r := range page.Results {
+			if seen[r.ArticleID] {
+				t.Fatalf("article %s returned on more than one page", r.ArticleID)
+			}
+			seen[r.ArticleID] = true
+		}
+
+		if page.Next == nil {
+			if page.TotalItems != int64(len(seen)) {
+				t.Fatalf("expected total items %d to match paged items %d", page.TotalItems, len(seen))
+			}
+			break
+		}
+		q.Cursor = page.Next
+	}
+
+	for _, a := range articles {
+		if !seen[a.Data.Id] {
+			t.Fatalf("article %s was never returned", a.Data.Id)
+		}
+	}
+}
+
+func TestMongoDBRepo_GetFingerprints(t *testing.T) {
+	id := "5678"
 	randomArticle := newArticle(id)
+	randomArticle.LastUpdateDate = time.Now().Format(mongodb.DATE_TIME_FORMAT)
+	randomArticle.ContentHash = randomArticle.Data.Hash()
 
-	_, err := repository.BulkInsert(
-		[]news.NewsArticle{randomArticle},
-	)
+	if _, err := repository.BulkInsert(context.Background(), []news.NewsArticle{randomArticle}); err != nil {
+		t.Fatal(err)
+	}
+
+	lastUpdateDate := "2000-01-01 00:00:00"
+	if err := repository.SetLastUpdateDates(context.TODO(), "", map[string]string{id: lastUpdateDate}); err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprints, err := repository.GetFingerprints(context.TODO(), "", []string{id, "does-not-exist"})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	n, err := repository.GetArticleByID(context.TODO(), id)
+	if len(fingerprints) != 1 {
+		t.Fatalf("expected a single fingerprint, got %d", len(fingerprints))
+	}
+
+	if fp := fingerprints[id]; fp.ContentHash != randomArticle.ContentHash || fp.LastUpdateDate != lastUpdateDate {
+		t.Fatalf("unexpected fingerprint: %+v", fp)
+	}
+}
+
+func TestMongoDBRepo_Conformance(t *testing.T) {
+	storagetest.TestDBRepo(t, repository)
+}
+
+func TestMongoRevisionRepo_Conformance(t *testing.T) {
+	storagetest.TestRevisionRepo(t, revisions)
+}
+
+func TestMongoSyncStatusRepo_Conformance(t *testing.T) {
+	storagetest.TestSyncStatusRepo(t, syncStatus)
+}
+
+func TestMongoWebhookRepo_Conformance(t *testing.T) {
+	storagetest.TestWebhookRepo(t, webhooks)
+}
+
+// publisher collects the published events
+type publisher chan events.Event
+
+func (p publisher) Publish(evs ...events.Event) {
+	for _, e := range evs {
+		p <- e
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	id := fmt.Sprintf("watcher-%x", rand.Int63())
+	collection := db.Collection(id)
+	defer func() { _ = collection.Drop(context.Background()) }()
+	if err := mongodb.CreateIndexes(collection, cfg.Mongo.TTL); err != nil {
+		t.Fatal(err)
+	}
+	repo := mongodb.NewMongoRepo(collection, cfg.Mongo)
+
+	watcher := mongodb.NewWatcher(
+		repo,
+		db.Collection(cfg.Mongo.WatchersCollection),
+		config.Watcher{ID: id, PollInterval: 50 * time.Millisecond, RetryDelay: 50 * time.Millisecond},
+		logger.NewLogger(cfg.Logger, logger.DisableOutput()),
+	)
+	changes := make(chan struct{}, 10)
+	watcher.OnChange(func() { changes <- struct{}{} })
+
+	published := make(publisher, 10)
+	go watcher.Run(ctx, published)
+
+	// the watcher only publishes the changes written after it started, whether it streams or polls them
+	time.Sleep(500 * time.Millisecond)
+
+	a := newArticle(id)
+	a.Club, a.Revision = "brentford", 1
+
+	// polling only sees the latest state of an article, every write is awaited before the next one
+	testCases := []struct {
+		description string
+		write       func() error
+		expected    events.Type
+	}{
+		{
+			description: "should publish the insertion of an article",
+			write: func() error {
+				_, err := repo.BulkInsert(ctx, []news.NewsArticle{a})
+				return err
+			},
+			expected: events.ArticleCreated,
+		},
+		{
+			description: "should publish the withdrawal of an article",
+			write: func() error {
+				_, err := repo.WithdrawArticles(ctx, "brentford", []string{id}, news.WithdrawnDeleted)
+				return err
+			},
+			expected: events.ArticleWithdrawn,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			if err := tc.write(); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case e := <-published:
+				if e.Type != tc.expected || e.ArticleID != id || e.Club != "brentford" || e.At.IsZero() {
+					t.Fatalf("expected an %s event of the article, got %+v", tc.expected, e)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("expected an %s event", tc.expected)
+			}
+		})
+	}
+
+	select {
+	case <-changes:
+	default:
+		t.Fatal("expected the change listeners to be notified")
+	}
+}
+
+// TestWatcher_Fetcher streams the articles synced by a separate fetcher to the subscribers of the api,
+// as the default configuration of the mongo driver does
+func TestWatcher_Fetcher(t *testing.T) {
+	if !cfg.Watcher.Enabled {
+		t.Fatal("expected the watcher to be enabled by default")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../../../news.xml")
+	})
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../../../single_article.xml")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	id := fmt.Sprintf("fetcher-%x", rand.Int63())
+	collection := db.Collection(id)
+	defer func() { _ = collection.Drop(context.Background()) }()
+	if err := mongodb.CreateIndexes(collection, cfg.Mongo.TTL); err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+	repo := mongodb.NewMongoRepo(collection, cfg.Mongo)
+
+	// the api only watches the articles, its bus is what its event streams subscribe to
+	watcherCfg := cfg.Watcher
+	watcherCfg.ID = id
+	watcherCfg.PollInterval = 50 * time.Millisecond
+	bus := events.NewBus(0)
+	defer bus.Close()
+	sub, _ := bus.Subscribe(0)
+	go mongodb.NewWatcher(repo, db.Collection(cfg.Mongo.WatchersCollection), watcherCfg, log).Run(ctx, bus)
+
+	// the watcher only publishes the changes written after it started, whether it streams or polls them
+	time.Sleep(500 * time.Millisecond)
+
+	upstreamCfg := cfg.Upstream
+	upstreamCfg.RateLimit = 0
+	client := upstream.NewClient(upstreamCfg)
+	src, err := source.New(config.Source{
+		Name:            "incrowd",
+		Type:            "incrowd",
+		Url:             srv.URL + "/list?count=",
+		DetailsUrl:      srv.URL + "/article?id=",
+		ArticlesPerCall: 50,
+	}, client)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if n.ArticleID != id {
-		t.Fatalf("could not find article with id: %s, got: %s", id, n.ArticleID)
+	// the fetcher syncs the articles without publishing them to the api
+	fetcher := ingestion.NewSyncer(
+		repo,
+		revisions,
+		syncStatus,
+		client,
+		metrics.New().Ingestion,
+		events.Publishers{},
+		[]ingestion.Club{{Name: id, TeamId: "t94", Sources: []source.Source{src}}},
+		log,
+	)
+	if _, err := fetcher.Sync(ctx); err != nil {
+		t.Fatal(err)
 	}
 
-	// TODO: perform deep equality check here
-	if randomArticle.Data.Content != n.Data.Content {
-		t.Fatal("news article contents should match")
+	select {
+	case e := <-sub.Events():
+		if e.Type != events.ArticleCreated || e.Club != id || e.ArticleID == "" || e.Data == nil {
+			t.Fatalf("expected an %s event of a synced article, got %+v", events.ArticleCreated, e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the articles synced by the fetcher to be streamed")
 	}
 }
 
@@ -134,3 +413,48 @@ func randString(n int) string {
 
 	return string(b)
 }
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+
+	db := mClient.Database("migrate-test")
+	defer db.Drop(ctx)
+
+	coll := db.Collection("articles")
+
+	// The unique articleID index of stores created before articles were keyed on their club
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bsonx.Doc{{Key: "articleID", Value: bsonx.Int32(1)}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Applying the migrations twice must be a no-op the second time
+	for i := 0; i < 2; i++ {
+		if err := mongodb.Migrate(ctx, coll); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	specs, err := coll.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, spec := range specs {
+		if spec.Name == "articleID_1" {
+			t.Fatal("expected the articleID index to be dropped")
+		}
+	}
+
+	applied, err := db.Collection("migrations").CountDocuments(ctx, bson.D{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if applied != 1 {
+		t.Fatalf("expected 1 recorded migration, got %d", applied)
+	}
+}
@@ -0,0 +1,124 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// RevisionRepository mongo struct
+type RevisionRepository struct {
+	revisionsCollection *mongo.Collection
+}
+
+// NewMongoRevisionRepo creates a new Mongo revision repository
+func NewMongoRevisionRepo(n *mongo.Collection) *RevisionRepository {
+	return &RevisionRepository{
+		revisionsCollection: n,
+	}
+}
+
+// AddRevisions stores a revision for every article using the article's revision number.
+// Revisions are never overwritten, storing an existing revision again is a no-op
+func (r RevisionRepository) AddRevisions(ctx context.Context, newsArticles []news.NewsArticle) error {
+	if len(newsArticles) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	docs := make([]interface{}, len(newsArticles))
+
+	for i, n := range newsArticles {
+		docs[i] = storage.Revision{
+			ArticleID:      n.Data.Id,
+			Club:           n.Club,
+			Revision:       n.Revision,
+			CreatedAt:      now,
+			LastUpdateDate: n.LastUpdateDate,
+			ContentHash:    n.ContentHash,
+			Data:           n.Data,
+		}
+	}
+
+	_, err := r.revisionsCollection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && isDuplicateKeyOnly(err) {
+		return nil
+	}
+
+	return translateError("addRevisions", err)
+}
+
+// GetRevisions returns every stored revision of an article of a club ordered by revision number.
+// When club is empty the revisions of the article with that id are returned, whatever its club
+func (r RevisionRepository) GetRevisions(ctx context.Context, club, articleID string) (_ []storage.Revision, err error) {
+	defer func() { err = translateError("getRevisions", err) }()
+
+	revisions := []storage.Revision{}
+
+	filter := bson.D{{Key: "articleID", Value: articleID}}
+	if club != "" {
+		filter = append(filter, bson.E{Key: "club", Value: club})
+	}
+
+	cursor, err := r.revisionsCollection.Find(
+		ctx,
+		filter,
+		options.Find().SetSort(bson.D{{Key: "revision", Value: 1}}),
+	)
+	if err != nil {
+		return revisions, err
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, &revisions)
+
+	return revisions, err
+}
+
+// CreateRevisionIndexes creates the indexes of the revisions collection.
+// Revisions expire along with the articles they belong to
+func CreateRevisionIndexes(coll *mongo.Collection, ttl time.Duration) error {
+	expireAfterSeconds := int32(ttl.Seconds())
+
+	_, err := coll.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys: bsonx.Doc{
+					{Key: "club", Value: bsonx.Int32(1)},
+					{Key: "articleID", Value: bsonx.Int32(1)},
+					{Key: "revision", Value: bsonx.Int32(1)},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bsonx.Doc{
+					{Key: "createdAt", Value: bsonx.Int32(1)},
+				},
+				Options: &options.IndexOptions{ExpireAfterSeconds: &expireAfterSeconds},
+			},
+		})
+
+	return err
+}
+
+// isDuplicateKeyOnly reports whether every write error of a bulk write is a duplicate key error
+func isDuplicateKeyOnly(err error) bool {
+	bwe, ok := err.(mongo.BulkWriteException)
+	if !ok || bwe.WriteConcernError != nil || len(bwe.WriteErrors) == 0 {
+		return false
+	}
+
+	for _, we := range bwe.WriteErrors {
+		if we.Code != 11000 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"context"
+
+	"com.thanos/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SearchNews returns a page of the articles matching a full-text search sorted by -score.
+// Ties on the score are broken like the pages of GetNewsPage
+func (r Repository) SearchNews(ctx context.Context, q storage.SearchQuery) (page storage.SearchPage, err error) {
+	defer func() { err = translateError("searchNews", err) }()
+
+	match := append(bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: q.Text}}}}, r.pageMatch(q.PageQuery)...)
+
+	page.TotalItems, err = r.articlesCollection.CountDocuments(ctx, match)
+	if err != nil {
+		return page, err
+	}
+
+	backward := q.Cursor != nil && q.Cursor.Backward
+	direction := sortDirection(q.Cursor)
+
+	// $text must be matched first, the score can only be compared once it has been projected
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: match}},
+		bson.D{{Key: "$addFields", Value: bson.D{
+			{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}},
+		}}},
+	}
+	if q.Cursor != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: seekMatch(*q.Cursor, true)}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "score", Value: direction},
+			{Key: "publishedAt", Value: direction},
+			{Key: "articleID", Value: direction},
+		}}},
+		// Fetch one extra document to find out whether there are more pages in that direction
+		bson.D{{Key: "$limit", Value: q.Limit + 1}},
+	)
+
+	cursor, err := r.articlesCollection.Aggregate(ctx, pipeline, options.Aggregate())
+	if err != nil {
+		return page, err
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]storage.SearchResult, 0, q.Limit+1)
+	if err = cursor.All(ctx, &results); err != nil {
+		return page, err
+	}
+
+	hasMore := len(results) > q.Limit
+	if hasMore {
+		results = results[:q.Limit]
+	}
+
+	if backward {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+
+	page.Results = results
+	page.Next, page.Prev = storage.PageCursors(q.Cursor, len(results), hasMore, func(i int) storage.Cursor {
+		return storage.Cursor{Score: results[i].Score, PublishedAt: results[i].PublishedAt, ArticleID: results[i].ArticleID}
+	})
+
+	return page, nil
+}
@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+
+	"com.thanos/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// syncStatusID is the id of the single sync status document
+const syncStatusID = "fetcher"
+
+// SyncStatusRepository mongo struct
+type SyncStatusRepository struct {
+	syncStatusCollection *mongo.Collection
+}
+
+// NewMongoSyncStatusRepo creates a new Mongo sync status repository
+func NewMongoSyncStatusRepo(n *mongo.Collection) *SyncStatusRepository {
+	return &SyncStatusRepository{
+		syncStatusCollection: n,
+	}
+}
+
+// SetSyncStatus stores the status of the latest sync. A zero LastSuccessAt keeps the stored one
+func (r SyncStatusRepository) SetSyncStatus(ctx context.Context, status storage.SyncStatus) (err error) {
+	defer func() { err = translateError("setSyncStatus", err) }()
+
+	set := bson.D{
+		{Key: "lastSyncAt", Value: status.LastSyncAt.UTC()},
+		{Key: "feeds", Value: status.Feeds},
+	}
+	if !status.LastSuccessAt.IsZero() {
+		set = append(set, bson.E{Key: "lastSuccessAt", Value: status.LastSuccessAt.UTC()})
+	}
+
+	_, err = r.syncStatusCollection.UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: syncStatusID}},
+		bson.D{{Key: "$set", Value: set}},
+		options.Update().SetUpsert(true),
+	)
+
+	return err
+}
+
+// GetSyncStatus returns the status of the latest sync, or a storage.ErrNotFound error when nothing was synced yet
+func (r SyncStatusRepository) GetSyncStatus(ctx context.Context) (status storage.SyncStatus, err error) {
+	defer func() { err = translateError("getSyncStatus", err) }()
+
+	err = r.syncStatusCollection.FindOne(ctx, bson.D{{Key: "_id", Value: syncStatusID}}).Decode(&status)
+
+	return status, err
+}
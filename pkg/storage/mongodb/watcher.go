@@ -0,0 +1,361 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Server error codes of change streams
+const (
+	// changeStreamsUnsupported is returned by deployments that aren't replica sets
+	changeStreamsUnsupported = 40573
+	changeStreamFatalError   = 280
+	changeStreamHistoryLost  = 286
+)
+
+// maxWatchBatch is the number of changes published at once
+const maxWatchBatch = 100
+
+var errChangeStreamsUnsupported = errors.New("change streams are only supported by replica sets")
+
+// watcherState is the position a watcher resumes from
+type watcherState struct {
+	ID string `bson:"_id"`
+	// ResumeToken is the position of the change stream
+	ResumeToken bson.Raw `bson:"resumeToken,omitempty"`
+	// HighWaterMark is the latest updatedAt polled
+	HighWaterMark time.Time `bson:"highWaterMark,omitempty"`
+}
+
+// change is a change stream event of the articles collection
+type change struct {
+	OperationType     string                  `bson:"operationType"`
+	ClusterTime       primitive.Timestamp     `bson:"clusterTime"`
+	FullDocument      *storage.UpdatedArticle `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+// Watcher publishes the changes of the articles collection written by other processes, i.e. the fetcher, to
+// the subscribers of this process. It follows a change stream of the collection, or polls the updatedAt of the
+// articles when the deployment isn't a replica set. Its position is stored so that a restart resumes where it stopped
+type Watcher struct {
+	repo               *Repository
+	watchersCollection *mongo.Collection
+	cfg                config.Watcher
+	log                *logger.Logger
+
+	mu       sync.Mutex
+	onChange []func()
+}
+
+// NewWatcher creates a new Watcher of the articles of repo storing its position in the watchers collection
+func NewWatcher(repo *Repository, watchers *mongo.Collection, cfg config.Watcher, l *logger.Logger) *Watcher {
+	return &Watcher{
+		repo:               repo,
+		watchersCollection: watchers,
+		cfg:                cfg,
+		log:                l,
+	}
+}
+
+// OnChange registers fn to be called after every batch of changes, including the changes that aren't published
+// as events such as expired articles, and whenever changes may have been missed
+func (w *Watcher) OnChange(fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onChange = append(w.onChange, fn)
+}
+
+// Run publishes the changes of the articles until the context is cancelled. Failures are retried after the
+// configured delay
+func (w *Watcher) Run(ctx context.Context, publisher events.Publisher) {
+	polling := false
+
+	for ctx.Err() == nil {
+		var err error
+		if polling {
+			err = w.poll(ctx, publisher)
+		} else if err = w.watch(ctx, publisher); errors.Is(err, errChangeStreamsUnsupported) {
+			w.log.Infof("%s, polling the articles every %s instead", err, w.cfg.PollInterval)
+			polling = true
+			continue
+		}
+
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+
+		// changes may have been missed while the watcher was failing
+		w.log.WithError(err).Errorf("watching the articles failed, retrying in %s", w.cfg.RetryDelay)
+		w.changed()
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.cfg.RetryDelay):
+		}
+	}
+}
+
+// watch publishes the changes of a change stream of the articles, resuming after the stored resume token.
+// The token is stored whenever the stream has no more buffered changes
+func (w *Watcher) watch(ctx context.Context, publisher events.Publisher) error {
+	state, err := w.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if state.ResumeToken != nil {
+		opts.SetStartAfter(state.ResumeToken)
+	}
+
+	cs, err := w.repo.articlesCollection.Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		return w.watchError(ctx, err)
+	}
+	defer cs.Close(context.Background())
+
+	token := state.ResumeToken
+	var batch []events.Event
+	changed := false
+
+	for {
+		if len(batch) >= maxWatchBatch || !cs.TryNext(ctx) {
+			if err = cs.Err(); err != nil {
+				return w.watchError(ctx, err)
+			}
+
+			if changed {
+				w.publish(publisher, batch)
+				batch, changed = nil, false
+			}
+
+			if t := cs.ResumeToken(); t != nil && !bytes.Equal(t, token) {
+				if err = w.setState(ctx, bson.D{{Key: "$set", Value: bson.D{{Key: "resumeToken", Value: t}}}}); err != nil {
+					return err
+				}
+				token = t
+			}
+
+			if !cs.Next(ctx) {
+				return w.watchError(ctx, cs.Err())
+			}
+		}
+
+		var c change
+		if err = cs.Decode(&c); err != nil {
+			return err
+		}
+
+		changed = true
+		if e, ok := c.event(); ok {
+			batch = append(batch, e)
+		}
+	}
+}
+
+// watchError translates the failure of a change stream. The stored resume token is dropped when it can't be resumed
+// from anymore, so that the next stream starts over from the latest change
+func (w *Watcher) watchError(ctx context.Context, err error) error {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return translateError("watch", err)
+	}
+
+	switch {
+	case serverErr.HasErrorCode(changeStreamsUnsupported):
+		return errChangeStreamsUnsupported
+	case hasAnyErrorCode(serverErr, []int{changeStreamFatalError, changeStreamHistoryLost}):
+		w.log.WithError(err).Warn("could not resume watching the articles, changes were missed")
+		if err := w.setState(ctx, bson.D{{Key: "$unset", Value: bson.D{{Key: "resumeToken", Value: ""}}}}); err != nil {
+			return err
+		}
+	}
+
+	return translateError("watch", err)
+}
+
+// event returns the event of a change, if any. Changes that don't change the content of an article, such as a new
+// last update date, and deleted articles aren't published
+func (c change) event() (events.Event, bool) {
+	a := c.FullDocument
+	if a == nil {
+		return events.Event{}, false
+	}
+
+	var typ events.Type
+	switch c.OperationType {
+	case "insert":
+		typ = events.ArticleCreated
+	case "replace":
+		typ = events.ArticleUpdated
+	case "update":
+		_, withdrawn := c.UpdateDescription.UpdatedFields["withdrawn"]
+		_, revised := c.UpdateDescription.UpdatedFields["revision"]
+		switch {
+		case withdrawn && a.Withdrawn != nil:
+			typ = events.ArticleWithdrawn
+		case revised:
+			typ = events.ArticleUpdated
+		default:
+			return events.Event{}, false
+		}
+	default:
+		return events.Event{}, false
+	}
+
+	e := articleEvent(*a, typ)
+	if e.At.IsZero() {
+		e.At = time.Unix(int64(c.ClusterTime.T), 0).UTC()
+	}
+
+	return e, true
+}
+
+// poll publishes the articles written since the stored high-water mark on every poll interval, until it fails
+// or the context is cancelled
+func (w *Watcher) poll(ctx context.Context, publisher events.Publisher) error {
+	state, err := w.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the first poll starts from the latest write rather than publishing every stored article
+	mark := state.HighWaterMark
+	if mark.IsZero() {
+		if mark, err = w.latestWrite(ctx); err != nil {
+			return err
+		}
+	}
+	poller := events.NewPollerFrom(w.repo, w.cfg.PollInterval, mark, w.log)
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err = poller.Poll(ctx, notifyingPublisher{watcher: w, publisher: publisher}); err != nil {
+			return err
+		}
+
+		if latest := poller.Mark(); latest.After(mark) {
+			if err = w.setState(ctx, bson.D{{Key: "$set", Value: bson.D{{Key: "highWaterMark", Value: latest}}}}); err != nil {
+				return err
+			}
+			mark = latest
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// latestWrite returns the updatedAt of the latest written article, the zero time when there's none
+func (w *Watcher) latestWrite(ctx context.Context) (_ time.Time, err error) {
+	defer func() { err = translateError("latestWrite", err) }()
+
+	var a storage.UpdatedArticle
+	err = w.repo.articlesCollection.FindOne(
+		ctx,
+		bson.D{{Key: "updatedAt", Value: bson.D{{Key: "$exists", Value: true}}}},
+		options.FindOne().SetSort(bson.D{{Key: "updatedAt", Value: -1}}),
+	).Decode(&a)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+
+	return a.UpdatedAt, err
+}
+
+// articleEvent returns the event of the given type of an article
+func articleEvent(a storage.UpdatedArticle, typ events.Type) events.Event {
+	e := events.Event{
+		Type:      typ,
+		At:        a.UpdatedAt.UTC(),
+		ArticleID: a.ArticleID,
+		Club:      a.Club,
+	}
+
+	switch typ {
+	case events.ArticleWithdrawn:
+		e.Withdrawn = a.Withdrawn
+	default:
+		data := a.Data
+		e.Data = &data
+	}
+
+	return e
+}
+
+// publish notifies the change listeners before publishing the events, so that subscribers reacting to
+// an event don't read the articles from a stale cache
+func (w *Watcher) publish(publisher events.Publisher, batch []events.Event) {
+	w.changed()
+
+	if len(batch) > 0 {
+		publisher.Publish(batch...)
+	}
+}
+
+// notifyingPublisher publishes the events of the watcher after notifying its change listeners
+type notifyingPublisher struct {
+	watcher   *Watcher
+	publisher events.Publisher
+}
+
+func (p notifyingPublisher) Publish(batch ...events.Event) {
+	p.watcher.publish(p.publisher, batch)
+}
+
+func (w *Watcher) changed() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, fn := range w.onChange {
+		fn()
+	}
+}
+
+// state returns the stored position of the watcher, the zero position when none was stored yet
+func (w *Watcher) state(ctx context.Context) (state watcherState, err error) {
+	defer func() { err = translateError("getWatcherState", err) }()
+
+	err = w.watchersCollection.FindOne(ctx, bson.D{{Key: "_id", Value: w.cfg.ID}}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return watcherState{ID: w.cfg.ID}, nil
+	}
+
+	return state, err
+}
+
+// setState updates the stored position of the watcher
+func (w *Watcher) setState(ctx context.Context, update bson.D) (err error) {
+	defer func() { err = translateError("setWatcherState", err) }()
+
+	_, err = w.watchersCollection.UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: w.cfg.ID}},
+		update,
+		options.Update().SetUpsert(true),
+	)
+
+	return err
+}
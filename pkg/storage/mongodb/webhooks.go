@@ -0,0 +1,194 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"com.thanos/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// WebhookRepository mongo struct
+type WebhookRepository struct {
+	webhooksCollection   *mongo.Collection
+	deliveriesCollection *mongo.Collection
+}
+
+// NewMongoWebhookRepo creates a new Mongo webhook repository storing the webhooks and their deliveries
+// in their own collections
+func NewMongoWebhookRepo(webhooks, deliveries *mongo.Collection) *WebhookRepository {
+	return &WebhookRepository{
+		webhooksCollection:   webhooks,
+		deliveriesCollection: deliveries,
+	}
+}
+
+// CreateWebhook stores a new webhook, a storage.ErrConflict error when its id is taken
+func (r WebhookRepository) CreateWebhook(ctx context.Context, w storage.Webhook) (err error) {
+	defer func() { err = translateError("createWebhook", err) }()
+
+	_, err = r.webhooksCollection.InsertOne(ctx, w)
+
+	return err
+}
+
+// GetWebhooks returns every webhook ordered by creation
+func (r WebhookRepository) GetWebhooks(ctx context.Context) (webhooks []storage.Webhook, err error) {
+	defer func() { err = translateError("getWebhooks", err) }()
+
+	cursor, err := r.webhooksCollection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks = []storage.Webhook{}
+	err = cursor.All(ctx, &webhooks)
+
+	return webhooks, err
+}
+
+// GetWebhook returns a webhook, a storage.ErrNotFound error when it doesn't exist
+func (r WebhookRepository) GetWebhook(ctx context.Context, id string) (w storage.Webhook, err error) {
+	defer func() { err = translateError("getWebhook", err) }()
+
+	err = r.webhooksCollection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&w)
+
+	return w, err
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries, a storage.ErrNotFound error when it doesn't exist
+func (r WebhookRepository) DeleteWebhook(ctx context.Context, id string) (err error) {
+	defer func() { err = translateError("deleteWebhook", err) }()
+
+	res, err := r.webhooksCollection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	_, err = r.deliveriesCollection.DeleteMany(ctx, bson.D{{Key: "webhookID", Value: id}})
+
+	return err
+}
+
+// AddDeliveries queues the deliveries
+func (r WebhookRepository) AddDeliveries(ctx context.Context, deliveries []storage.Delivery) (err error) {
+	defer func() { err = translateError("addDeliveries", err) }()
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(deliveries))
+	for i, d := range deliveries {
+		docs[i] = d
+	}
+
+	_, err = r.deliveriesCollection.InsertMany(ctx, docs)
+
+	return err
+}
+
+// ClaimDeliveries returns up to limit pending deliveries due at now, oldest first, and postpones their next
+// attempt by lease so that no other dispatcher claims them while they're attempted
+func (r WebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (deliveries []storage.Delivery, err error) {
+	defer func() { err = translateError("claimDeliveries", err) }()
+
+	deliveries = []storage.Delivery{}
+	for len(deliveries) < limit {
+		var d storage.Delivery
+		err = r.deliveriesCollection.FindOneAndUpdate(ctx,
+			bson.D{
+				{Key: "status", Value: storage.DeliveryPending},
+				{Key: "nextAttemptAt", Value: bson.D{{Key: "$lte", Value: now.UTC()}}},
+			},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "nextAttemptAt", Value: now.Add(lease).UTC()}}}},
+			options.FindOneAndUpdate().
+				SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+				SetReturnDocument(options.After),
+		).Decode(&d)
+		if err == mongo.ErrNoDocuments {
+			return deliveries, nil
+		}
+		if err != nil {
+			return deliveries, err
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery stores the outcome of an attempted delivery
+func (r WebhookRepository) UpdateDelivery(ctx context.Context, d storage.Delivery) (err error) {
+	defer func() { err = translateError("updateDelivery", err) }()
+
+	_, err = r.deliveriesCollection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: d.ID}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: d.Status},
+			{Key: "attempts", Value: d.Attempts},
+			{Key: "nextAttemptAt", Value: d.NextAttemptAt.UTC()},
+			{Key: "responseStatus", Value: d.ResponseStatus},
+			{Key: "error", Value: d.Error},
+		}}},
+	)
+
+	return err
+}
+
+// GetDeliveries returns the latest limit deliveries of a webhook, newest first
+func (r WebhookRepository) GetDeliveries(ctx context.Context, webhookID string, limit int) (deliveries []storage.Delivery, err error) {
+	defer func() { err = translateError("getDeliveries", err) }()
+
+	cursor, err := r.deliveriesCollection.Find(ctx,
+		bson.D{{Key: "webhookID", Value: webhookID}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries = []storage.Delivery{}
+	err = cursor.All(ctx, &deliveries)
+
+	return deliveries, err
+}
+
+// CreateWebhookIndexes creates the indexes of the deliveries collection. Deliveries expire ttl after
+// they were created
+func CreateWebhookIndexes(deliveries *mongo.Collection, ttl time.Duration) error {
+	expireAfterSeconds := int32(ttl.Seconds())
+
+	_, err := deliveries.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{
+				// Supports claiming the due deliveries
+				Keys: bsonx.Doc{
+					{Key: "status", Value: bsonx.Int32(1)},
+					{Key: "nextAttemptAt", Value: bsonx.Int32(1)},
+				},
+			},
+			{
+				// Supports the delivery log of a webhook
+				Keys: bsonx.Doc{
+					{Key: "webhookID", Value: bsonx.Int32(1)},
+					{Key: "createdAt", Value: bsonx.Int32(-1)},
+				},
+			},
+			{
+				Keys: bsonx.Doc{
+					{Key: "createdAt", Value: bsonx.Int32(1)},
+				},
+				Options: &options.IndexOptions{ExpireAfterSeconds: &expireAfterSeconds},
+			},
+		})
+
+	return err
+}
@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"time"
+
+	"com.thanos/pkg/news"
+)
+
+// Revision is an immutable snapshot of an article, stored every time a change of the article is detected
+type Revision struct {
+	ArticleID      string    `json:"articleId" bson:"articleID"`
+	Club           string    `json:"-" bson:"club"`
+	Revision       int       `json:"revision" bson:"revision"`
+	CreatedAt      time.Time `json:"createdAt" bson:"createdAt"`
+	LastUpdateDate string    `json:"lastUpdateDate" bson:"lastUpdateDate"`
+	ContentHash    string    `json:"contentHash" bson:"contentHash"`
+	Data           news.Data `json:"data" bson:"data"`
+}
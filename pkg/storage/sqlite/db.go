@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	"com.thanos/pkg/config"
+	_ "modernc.org/sqlite"
+)
+
+// migrations are applied in order and recorded in the schema_migrations table, a migration is
+// never changed once released. New schema changes are appended as new migrations
+var migrations = []string{
+	// 1: articles, their full-text index and revisions
+	`CREATE TABLE articles (
+		id               INTEGER PRIMARY KEY,
+		article_id       TEXT NOT NULL,
+		club             TEXT NOT NULL,
+		source           TEXT NOT NULL,
+		published_at     INTEGER NOT NULL,
+		status           TEXT NOT NULL,
+		last_update_date TEXT NOT NULL,
+		content_hash     TEXT NOT NULL,
+		revision         INTEGER NOT NULL,
+		data             TEXT NOT NULL,
+		subtitle         TEXT NOT NULL,
+		withdrawn_at     INTEGER,
+		withdrawn_reason TEXT,
+		created_at       TEXT NOT NULL,
+		-- article ids are only unique within a club
+		UNIQUE (club, article_id)
+	);
+	CREATE INDEX articles_published_at ON articles (published_at DESC, article_id DESC);
+	CREATE INDEX articles_club_published_at ON articles (club, published_at DESC, article_id DESC);
+	CREATE INDEX articles_club_source_published_at ON articles (club, source, published_at);
+	CREATE INDEX articles_team_id ON articles (json_extract(data, '$.teamId'), published_at DESC);
+	CREATE INDEX articles_opta_match_id ON articles (json_extract(data, '$.optaMatchId'), published_at DESC);
+
+	CREATE VIRTUAL TABLE articles_fts USING fts5 (title, subtitle, teaser, content, tokenize = 'porter unicode61');
+	CREATE TRIGGER articles_fts_delete AFTER DELETE ON articles BEGIN
+		DELETE FROM articles_fts WHERE rowid = old.id;
+	END;
+
+	CREATE TABLE revisions (
+		club             TEXT NOT NULL,
+		article_id       TEXT NOT NULL,
+		revision         INTEGER NOT NULL,
+		created_at       INTEGER NOT NULL,
+		last_update_date TEXT NOT NULL,
+		content_hash     TEXT NOT NULL,
+		data             TEXT NOT NULL,
+		subtitle         TEXT NOT NULL,
+		PRIMARY KEY (club, article_id, revision)
+	);
+	CREATE INDEX revisions_article_id ON revisions (article_id, revision);
+	CREATE INDEX revisions_created_at ON revisions (created_at);`,
+
+	// 2: the status of the latest sync, a single row
+	`CREATE TABLE sync_status (
+		id              INTEGER PRIMARY KEY CHECK (id = 1),
+		last_sync_at    INTEGER NOT NULL,
+		last_success_at INTEGER,
+		feeds           TEXT NOT NULL
+	);`,
+
+	// 3: when articles were last written, polled for the writes of other processes
+	`ALTER TABLE articles ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX articles_updated_at ON articles (updated_at);`,
+
+	// 4: the webhooks and the queue of their deliveries
+	`CREATE TABLE webhooks (
+		id         TEXT PRIMARY KEY,
+		url        TEXT NOT NULL,
+		secret     TEXT NOT NULL,
+		events     TEXT NOT NULL,
+		clubs      TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE TABLE webhook_deliveries (
+		id              TEXT PRIMARY KEY,
+		webhook_id      TEXT NOT NULL,
+		event_type      TEXT NOT NULL,
+		article_id      TEXT NOT NULL,
+		payload         TEXT NOT NULL,
+		status          TEXT NOT NULL,
+		attempts        INTEGER NOT NULL,
+		created_at      INTEGER NOT NULL,
+		next_attempt_at INTEGER NOT NULL,
+		response_status INTEGER NOT NULL,
+		error           TEXT NOT NULL
+	);
+	CREATE INDEX webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+	CREATE INDEX webhook_deliveries_webhook_created_at ON webhook_deliveries (webhook_id, created_at DESC);
+	CREATE INDEX webhook_deliveries_created_at ON webhook_deliveries (created_at);`,
+}
+
+// Open opens the database file, creating it when missing, and migrates its schema to the latest version
+func Open(cfg config.SQLite) (*sql.DB, error) {
+	// Concurrent writers, e.g. the api and the fetcher, wait for each other instead of failing
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate", url.PathEscape(cfg.Path))
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", cfg.Path, err)
+	}
+
+	if err = Migrate(context.Background(), db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrate applies the migrations that were not applied to the database yet, each one in its own transaction
+func Migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("failed to create the schema_migrations table: %w", err)
+	}
+
+	for i, migration := range migrations {
+		if err := migrate(ctx, db, i+1, migration); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func migrate(ctx context.Context, db *sql.DB, version int, migration string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The version is checked within the transaction so that concurrent processes apply a migration once
+	var applied int
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if _, err = tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
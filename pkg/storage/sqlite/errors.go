@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"com.thanos/pkg/storage"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// translateError reports a sqlite failure of an operation as a storage error, so that callers
+// don't depend on the database driver. Errors of an unknown kind are returned as they are
+func translateError(op string, err error) error {
+	var storageErr *storage.Error
+	if err == nil || errors.As(err, &storageErr) {
+		return err
+	}
+
+	var kind error
+	var sqliteErr *sqlite.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		kind = storage.ErrNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = storage.ErrTimeout
+	case errors.Is(err, sql.ErrConnDone):
+		kind = storage.ErrUnavailable
+	case errors.As(err, &sqliteErr):
+		// extended result codes keep the primary result code in their lowest byte
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_CONSTRAINT:
+			kind = storage.ErrConflict
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED, sqlite3.SQLITE_CANTOPEN, sqlite3.SQLITE_IOERR:
+			kind = storage.ErrUnavailable
+		case sqlite3.SQLITE_ERROR:
+			// a full-text search the fts5 query syntax can't express
+			if strings.Contains(sqliteErr.Error(), "fts5") {
+				kind = storage.ErrInvalidQuery
+			}
+		}
+	}
+
+	if kind == nil {
+		return err
+	}
+
+	return storage.NewError(kind, op, err)
+}
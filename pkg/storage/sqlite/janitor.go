@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"com.thanos/pkg/logger"
+)
+
+// Janitor deletes the articles, revisions and webhook deliveries past their TTL, the equivalent of mongo's TTL indexes.
+// Articles are deleted along with their full-text index entries
+type Janitor struct {
+	db  *sql.DB
+	ttl time.Duration
+	log *logger.Logger
+}
+
+// NewJanitor creates a new Janitor deleting the articles published, and the revisions and deliveries created, ttl ago
+func NewJanitor(db *sql.DB, ttl time.Duration, l *logger.Logger) *Janitor {
+	return &Janitor{
+		db:  db,
+		ttl: ttl,
+		log: l,
+	}
+}
+
+// Run prunes the database once immediately and then on every interval until the context is cancelled
+func (j *Janitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		articles, revisions, deliveries, err := j.Prune(ctx)
+		if err != nil {
+			j.log.WithError(err).Error("failed to prune expired articles")
+		} else if articles > 0 || revisions > 0 || deliveries > 0 {
+			j.log.Debugf("pruned %d expired articles, %d expired revisions and %d expired deliveries", articles, revisions, deliveries)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune deletes the expired articles, revisions and deliveries and returns how many of each were deleted
+func (j *Janitor) Prune(ctx context.Context) (articles, revisions, deliveries int64, err error) {
+	defer func() { err = translateError("prune", err) }()
+
+	expiry := time.Now().Add(-j.ttl).UnixNano()
+
+	res, err := j.db.ExecContext(ctx, `DELETE FROM articles WHERE published_at < ?`, expiry)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if articles, err = res.RowsAffected(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	if res, err = j.db.ExecContext(ctx, `DELETE FROM revisions WHERE created_at < ?`, expiry); err != nil {
+		return articles, 0, 0, err
+	}
+	if revisions, err = res.RowsAffected(); err != nil {
+		return articles, 0, 0, err
+	}
+
+	if res, err = j.db.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE created_at < ?`, expiry); err != nil {
+		return articles, revisions, 0, err
+	}
+	deliveries, err = res.RowsAffected()
+
+	return articles, revisions, deliveries, err
+}
@@ -0,0 +1,514 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+)
+
+// resultColumns are the columns scanned into a storage.Result by scanResult
+const resultColumns = `a.id, a.article_id, a.published_at, a.club, a.last_update_date, a.content_hash, a.data, a.subtitle,
+	a.withdrawn_at, a.withdrawn_reason`
+
+// Repository is a storage.DBRepo stored in an embedded sqlite database. Articles are only returned
+// within the TTL window, the Janitor deletes them once they expire
+type Repository struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+// NewRepository creates a new sqlite repository whose articles expire ttl after their publish time
+func NewRepository(db *sql.DB, ttl time.Duration) *Repository {
+	return &Repository{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+// GetArticleByID returns the article of a club with the given id. When club is empty the latest published
+// article with that id is returned, whatever its club
+func (r Repository) GetArticleByID(ctx context.Context, club, id string) (newsArticle storage.Result, err error) {
+	defer func() { err = translateError("getArticleByID", err) }()
+
+	query := `SELECT ` + resultColumns + ` FROM articles a WHERE a.article_id = ? AND a.published_at >= ?`
+	args := []interface{}{id, r.expiry()}
+	if club != "" {
+		query += ` AND a.club = ?`
+		args = append(args, club)
+	}
+	query += ` ORDER BY a.published_at DESC LIMIT 1`
+
+	return scanResult(r.db.QueryRowContext(ctx, query, args...))
+}
+
+// GetFingerprints returns the stored Fingerprint of every given article id of a club that exists
+func (r Repository) GetFingerprints(ctx context.Context, club string, ids []string) (_ map[string]storage.Fingerprint, err error) {
+	defer func() { err = translateError("getFingerprints", err) }()
+
+	fingerprints := make(map[string]storage.Fingerprint, len(ids))
+	if len(ids) == 0 {
+		return fingerprints, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT article_id, last_update_date, content_hash, revision, withdrawn_at, withdrawn_reason
+		FROM articles WHERE club = ? AND article_id IN (`+placeholders(len(ids))+`) AND published_at >= ?`,
+		append(append([]interface{}{club}, stringArgs(ids)...), r.expiry())...,
+	)
+	if err != nil {
+		return fingerprints, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var fp storage.Fingerprint
+		var withdrawnAt sql.NullInt64
+		var withdrawnReason sql.NullString
+
+		if err = rows.Scan(&id, &fp.LastUpdateDate, &fp.ContentHash, &fp.Revision, &withdrawnAt, &withdrawnReason); err != nil {
+			return fingerprints, err
+		}
+
+		fp.Withdrawn = withdrawal(withdrawnAt, withdrawnReason)
+		fingerprints[id] = fp
+	}
+
+	return fingerprints, rows.Err()
+}
+
+// SetLastUpdateDates updates the stored LastUpdateDate of articles of a club whose content didn't change
+func (r Repository) SetLastUpdateDates(ctx context.Context, club string, lastUpdateDates map[string]string) (err error) {
+	defer func() { err = translateError("setLastUpdateDates", err) }()
+
+	if len(lastUpdateDates) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for id, lud := range lastUpdateDates {
+		if _, err = tx.ExecContext(ctx, `UPDATE articles SET last_update_date = ? WHERE club = ? AND article_id = ?`, lud, club, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSourceArticleIDs returns the ids of the articles of a club's source published after the given time,
+// that have not been withdrawn
+func (r Repository) GetSourceArticleIDs(ctx context.Context, club, source string, publishedAfter time.Time) (_ []string, err error) {
+	defer func() { err = translateError("getSourceArticleIDs", err) }()
+
+	ids := []string{}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT article_id FROM articles
+		WHERE club = ? AND source = ? AND published_at > ? AND published_at >= ? AND withdrawn_at IS NULL`,
+		club, source, publishedAfter.UnixNano(), r.expiry(),
+	)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// WithdrawArticles marks articles of a club as withdrawn upstream. Articles that are already withdrawn keep
+// their original withdrawal. It returns the ids of the newly withdrawn articles
+func (r Repository) WithdrawArticles(ctx context.Context, club string, ids []string, reason string) (_ []string, err error) {
+	defer func() { err = translateError("withdrawArticles", err) }()
+
+	withdrawn := []string{}
+	if len(ids) == 0 {
+		return withdrawn, nil
+	}
+
+	now := time.Now().UnixNano()
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE articles SET withdrawn_at = ?, withdrawn_reason = ?, updated_at = ?
+		WHERE club = ? AND article_id IN (`+placeholders(len(ids))+`) AND withdrawn_at IS NULL
+		RETURNING article_id`,
+		append([]interface{}{now, reason, now, club}, stringArgs(ids)...)...,
+	)
+	if err != nil {
+		return withdrawn, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return withdrawn, err
+		}
+
+		withdrawn = append(withdrawn, id)
+	}
+
+	return withdrawn, rows.Err()
+}
+
+// GetNewsPage returns a page of newsArticles sorted by -published. Ties on the publish time are
+// broken by articleID so that paging through articles sharing a publish time is stable
+func (r Repository) GetNewsPage(ctx context.Context, q storage.PageQuery) (page storage.Page, err error) {
+	defer func() { err = translateError("getNewsPage", err) }()
+
+	where, args := r.pageWhere(q)
+
+	if err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM articles a WHERE `+where, args...).Scan(&page.TotalItems); err != nil {
+		return page, err
+	}
+
+	if q.Cursor != nil {
+		seek, seekArgs := seekWhere(*q.Cursor, false)
+		where, args = where+` AND `+seek, append(args, seekArgs...)
+	}
+
+	direction := sortDirection(q.Cursor)
+
+	// Fetch one extra row to find out whether there are more pages in that direction
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+resultColumns+` FROM articles a WHERE `+where+`
+		ORDER BY a.published_at `+direction+`, a.article_id `+direction+` LIMIT ?`,
+		append(args, q.Limit+1)...,
+	)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	results := make([]storage.Result, 0, q.Limit+1)
+	for rows.Next() {
+		res, err := scanResult(rows)
+		if err != nil {
+			return page, err
+		}
+		results = append(results, res)
+	}
+	if err = rows.Err(); err != nil {
+		return page, err
+	}
+
+	hasMore := len(results) > q.Limit
+	if hasMore {
+		results = results[:q.Limit]
+	}
+
+	if q.Cursor != nil && q.Cursor.Backward {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+
+	page.Results = results
+	page.Next, page.Prev = storage.PageCursors(q.Cursor, len(results), hasMore, func(i int) storage.Cursor {
+		return storage.Cursor{PublishedAt: results[i].PublishedAt, ArticleID: results[i].ArticleID}
+	})
+
+	return page, nil
+}
+
+// StreamNews calls fn with every article of the -published ordered list following the query's cursor, up to
+// its limit when it's set, as they are read from the database. Backward cursors are streamed forward from their
+// position. Streaming stops at the first error of fn, which is returned
+func (r Repository) StreamNews(ctx context.Context, q storage.PageQuery, fn func(storage.Result) error) (err error) {
+	defer func() { err = translateError("streamNews", err) }()
+
+	where, args := r.pageWhere(q)
+	if q.Cursor != nil {
+		c := *q.Cursor
+		c.Backward = false
+		seek, seekArgs := seekWhere(c, false)
+		where, args = where+` AND `+seek, append(args, seekArgs...)
+	}
+
+	// a negative limit is no limit
+	limit := -1
+	if q.Limit > 0 {
+		limit = q.Limit
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+resultColumns+` FROM articles a WHERE `+where+`
+		ORDER BY a.published_at DESC, a.article_id DESC LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		res, err := scanResult(rows)
+		if err != nil {
+			return err
+		}
+		if err = fn(res); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// BulkInsert inserts an array of NewsArticles using upsert to avoid dupes, an article is identified by its club and id.
+// The createdAt metadata is only set when an article is first inserted, updatedAt is set on every write
+func (r Repository) BulkInsert(ctx context.Context, newsArticles []news.NewsArticle) (_ *storage.BulkInsertResult, err error) {
+	defer func() { err = translateError("bulkInsert", err) }()
+
+	// Validate every article first, a bulk insert either stores all of them or none
+	published := make([]time.Time, len(newsArticles))
+	for i, n := range newsArticles {
+		dt, err := time.Parse(news.DateTimeFormat, n.Data.Published)
+		if err != nil {
+			return &storage.BulkInsertResult{}, storage.NewError(storage.ErrInvalidQuery, "bulkInsert", err)
+		}
+		published[i] = dt
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &storage.BulkInsertResult{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result := &storage.BulkInsertResult{}
+	for i, n := range newsArticles {
+		data, err := json.Marshal(n.Data)
+		if err != nil {
+			return &storage.BulkInsertResult{}, storage.NewError(storage.ErrInvalidQuery, "bulkInsert", err)
+		}
+
+		var id int64
+		err = tx.QueryRowContext(ctx, `SELECT id FROM articles WHERE club = ? AND article_id = ?`, n.Club, n.Data.Id).Scan(&id)
+		switch {
+		case err == sql.ErrNoRows:
+			res, err := tx.ExecContext(ctx,
+				`INSERT INTO articles (article_id, club, source, published_at, status, last_update_date, content_hash,
+					revision, data, subtitle, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				n.Data.Id, n.Club, n.Source, published[i].UnixNano(), n.Status, n.LastUpdateDate, n.ContentHash,
+				n.Revision, string(data), n.Data.Subtitle, now.Format(time.RFC3339), now.UnixNano(),
+			)
+			if err != nil {
+				return &storage.BulkInsertResult{}, err
+			}
+			if id, err = res.LastInsertId(); err != nil {
+				return &storage.BulkInsertResult{}, err
+			}
+			result.UpsertedCount++
+		case err != nil:
+			return &storage.BulkInsertResult{}, err
+		default:
+			// Articles that show up again upstream are no longer withdrawn
+			_, err = tx.ExecContext(ctx,
+				`UPDATE articles SET source = ?, published_at = ?, status = ?, last_update_date = ?,
+					content_hash = ?, revision = ?, data = ?, subtitle = ?, withdrawn_at = NULL, withdrawn_reason = NULL,
+					updated_at = ?
+				WHERE id = ?`,
+				n.Source, published[i].UnixNano(), n.Status, n.LastUpdateDate,
+				n.ContentHash, n.Revision, string(data), n.Data.Subtitle, now.UnixNano(), id,
+			)
+			if err != nil {
+				return &storage.BulkInsertResult{}, err
+			}
+			result.ModifiedCount++
+		}
+
+		if err = indexArticle(ctx, tx, id, n.Data); err != nil {
+			return &storage.BulkInsertResult{}, err
+		}
+	}
+
+	return result, tx.Commit()
+}
+
+// GetUpdatedArticles returns the articles stored or withdrawn at or after since, oldest write first
+func (r Repository) GetUpdatedArticles(ctx context.Context, since time.Time) (_ []storage.UpdatedArticle, err error) {
+	defer func() { err = translateError("getUpdatedArticles", err) }()
+
+	updated := []storage.UpdatedArticle{}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+resultColumns+`, a.revision, a.updated_at FROM articles a
+		WHERE a.updated_at >= ? AND a.published_at >= ?
+		ORDER BY a.updated_at, a.id`,
+		since.UnixNano(), r.expiry(),
+	)
+	if err != nil {
+		return updated, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u storage.UpdatedArticle
+		var updatedAt int64
+		if u.Result, err = scanResult(rows, &u.Revision, &updatedAt); err != nil {
+			return updated, err
+		}
+		u.UpdatedAt = time.Unix(0, updatedAt).UTC()
+
+		updated = append(updated, u)
+	}
+
+	return updated, rows.Err()
+}
+
+// expiry returns the publish time before which articles are expired
+func (r Repository) expiry() int64 {
+	return time.Now().Add(-r.ttl).UnixNano()
+}
+
+// pageWhere returns the condition matching the articles within the TTL window that a page query may return
+func (r Repository) pageWhere(q storage.PageQuery) (string, []interface{}) {
+	f := q.Filter
+
+	from := r.expiry()
+	if f.From != nil && f.From.UnixNano() > from {
+		from = f.From.UnixNano()
+	}
+
+	conds, args := []string{`a.published_at >= ?`}, []interface{}{from}
+	add := func(cond string, condArgs ...interface{}) {
+		conds, args = append(conds, cond), append(args, condArgs...)
+	}
+
+	if f.To != nil {
+		add(`a.published_at < ?`, f.To.UnixNano())
+	}
+	if q.Club != "" {
+		add(`a.club = ?`, q.Club)
+	}
+	if !q.IncludeWithdrawn {
+		add(`a.withdrawn_at IS NULL`)
+	}
+	if len(f.Types) > 0 {
+		add(`EXISTS (SELECT 1 FROM json_each(a.data, '$.type') WHERE value IN (`+placeholders(len(f.Types))+`))`, stringArgs(f.Types)...)
+	}
+	if f.OptaMatchId != "" {
+		add(`json_extract(a.data, '$.optaMatchId') = ?`, f.OptaMatchId)
+	}
+	if f.TeamId != "" {
+		add(`json_extract(a.data, '$.teamId') = ?`, f.TeamId)
+	}
+	if f.HasVideo != nil {
+		// articles without a video store it as null
+		if *f.HasVideo {
+			add(`json_extract(a.data, '$.videoUrl') IS NOT NULL`)
+		} else {
+			add(`json_extract(a.data, '$.videoUrl') IS NULL`)
+		}
+	}
+	if f.HasGallery != nil {
+		if *f.HasGallery {
+			add(`COALESCE(json_array_length(a.data, '$.galleryUrls'), 0) > 0`)
+		} else {
+			add(`COALESCE(json_array_length(a.data, '$.galleryUrls'), 0) = 0`)
+		}
+	}
+
+	return strings.Join(conds, ` AND `), args
+}
+
+// sortDirection returns the direction of the page sort, pages preceding a backward cursor are sorted ascending
+func sortDirection(c *storage.Cursor) string {
+	if c != nil && c.Backward {
+		return `ASC`
+	}
+	return `DESC`
+}
+
+// seekWhere returns the condition matching the articles following the cursor position, or preceding it for
+// backward cursors. Positions are ordered by publishedAt and articleID, preceded by the score for search cursors
+func seekWhere(c storage.Cursor, withScore bool) (string, []interface{}) {
+	cmp := `<`
+	if c.Backward {
+		cmp = `>`
+	}
+
+	publishedAt := c.PublishedAt.UnixNano()
+	cond := `(a.published_at ` + cmp + ` ? OR (a.published_at = ? AND a.article_id ` + cmp + ` ?))`
+	args := []interface{}{publishedAt, publishedAt, c.ArticleID}
+
+	if withScore {
+		cond = `(a.score ` + cmp + ` ? OR (a.score = ? AND ` + cond + `))`
+		args = append([]interface{}{c.Score, c.Score}, args...)
+	}
+
+	return cond, args
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanResult scans a row of the resultColumns, followed by the extra destinations
+func scanResult(row scanner, extra ...interface{}) (storage.Result, error) {
+	var res storage.Result
+	var id, publishedAt int64
+	var data, subtitle string
+	var withdrawnAt sql.NullInt64
+	var withdrawnReason sql.NullString
+
+	dest := append([]interface{}{
+		&id, &res.ArticleID, &publishedAt, &res.Club, &res.LastUpdateDate, &res.ContentHash, &data, &subtitle,
+		&withdrawnAt, &withdrawnReason,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return res, err
+	}
+
+	if err := json.Unmarshal([]byte(data), &res.Data); err != nil {
+		return res, err
+	}
+
+	res.ID = strconv.FormatInt(id, 10)
+	res.PublishedAt = time.Unix(0, publishedAt).UTC()
+	res.Data.Subtitle = subtitle
+	res.Withdrawn = withdrawal(withdrawnAt, withdrawnReason)
+
+	return res, nil
+}
+
+func withdrawal(at sql.NullInt64, reason sql.NullString) *news.Withdrawal {
+	if !at.Valid {
+		return nil
+	}
+
+	return &news.Withdrawal{At: time.Unix(0, at.Int64).UTC(), Reason: reason.String}
+}
+
+// placeholders returns n comma separated query placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
@@ -0,0 +1,158 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/storage/sqlite"
+	"com.thanos/pkg/storage/storagetest"
+)
+
+func TestRepository(t *testing.T) {
+	storagetest.TestDBRepo(t, sqlite.NewRepository(openDB(t), time.Hour))
+}
+
+func TestRevisionRepository(t *testing.T) {
+	storagetest.TestRevisionRepo(t, sqlite.NewRevisionRepository(openDB(t), time.Hour))
+}
+
+func TestSyncStatusRepository(t *testing.T) {
+	storagetest.TestSyncStatusRepo(t, sqlite.NewSyncStatusRepository(openDB(t)))
+}
+
+func TestWebhookRepository(t *testing.T) {
+	storagetest.TestWebhookRepo(t, sqlite.NewWebhookRepository(openDB(t)))
+}
+
+func TestMigrate(t *testing.T) {
+	db := openDB(t)
+
+	// migrations are only applied once
+	if err := sqlite.Migrate(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	var versions int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&versions); err != nil {
+		t.Fatal(err)
+	}
+	if versions != 4 {
+		t.Fatalf("expected 4 applied migrations, got %d", versions)
+	}
+
+	// articleID is unique within a club
+	insert := `INSERT INTO articles (article_id, club, source, published_at, status, last_update_date, content_hash,
+		revision, data, subtitle, created_at) VALUES ('1', ?, 'source', 0, '', '', '', 0, '{}', '', '')`
+	if _, err := db.Exec(insert, "club"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(insert, "club"); err == nil {
+		t.Fatal("expected a duplicate articleID to be rejected")
+	}
+	if _, err := db.Exec(insert, "other"); err != nil {
+		t.Fatalf("expected the articleID of another club to be accepted, got: %v", err)
+	}
+}
+
+func TestRepository_SearchNews(t *testing.T) {
+	repo := sqlite.NewRepository(openDB(t), time.Hour)
+
+	a := storagetest.Article("1", "club", time.Now())
+	a.Data.Title = "Squad numbers confirmed"
+	if _, err := repo.BulkInsert(context.Background(), []news.NewsArticle{a}); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		description string
+		text        string
+		expected    int64
+	}{
+		{"should match stemmed words", "number", 1},
+		{"should match unterminated phrases", `"squad`, 1},
+		{"should not match excluded words", "squad -confirmed", 0},
+		{"should treat fts5 operators as words", "squad AND NEAR(", 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			page, err := repo.SearchNews(context.Background(), storage.SearchQuery{
+				PageQuery: storage.PageQuery{Limit: 10},
+				Text:      tc.text,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if page.TotalItems != tc.expected {
+				t.Fatalf("expected %d results, got %d", tc.expected, page.TotalItems)
+			}
+		})
+	}
+
+	_, err := repo.SearchNews(context.Background(), storage.SearchQuery{PageQuery: storage.PageQuery{Limit: 10}, Text: " - "})
+	if !errors.Is(err, storage.ErrInvalidQuery) {
+		t.Fatalf("expected an invalid query error for an empty search, got: %v", err)
+	}
+}
+
+func TestJanitor_Prune(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	repo := sqlite.NewRepository(db, time.Hour)
+
+	expired, live := storagetest.Article("expired", "club", time.Now().Add(-2*time.Hour)), storagetest.Article("live", "club", time.Now())
+	if _, err := repo.BulkInsert(context.Background(), []news.NewsArticle{expired, live}); err != nil {
+		t.Fatal(err)
+	}
+
+	webhooks := sqlite.NewWebhookRepository(db)
+	if err := webhooks.AddDeliveries(ctx, []storage.Delivery{
+		{ID: "expired", WebhookID: "webhook", CreatedAt: time.Now().Add(-2 * time.Hour)},
+		{ID: "live", WebhookID: "webhook", CreatedAt: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	articles, revisions, deliveries, err := sqlite.NewJanitor(db, time.Hour, nil).Prune(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if articles != 1 || revisions != 0 || deliveries != 1 {
+		t.Fatalf("expected 1 pruned article, no pruned revisions and 1 pruned delivery, got %d, %d and %d", articles, revisions, deliveries)
+	}
+
+	// the full-text index entries of pruned articles are deleted along with them
+	var indexed int
+	if err = db.QueryRow(`SELECT COUNT(*) FROM articles_fts`).Scan(&indexed); err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 1 {
+		t.Fatalf("expected 1 indexed article, got %d", indexed)
+	}
+
+	if _, err = repo.GetArticleByID(ctx, "", live.Data.Id); err != nil {
+		t.Fatalf("expected the live article to be kept, got: %v", err)
+	}
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sqlite.Open(config.SQLite{Path: filepath.Join(t.TempDir(), "news.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+)
+
+// RevisionRepository is a storage.RevisionRepo stored in an embedded sqlite database.
+// Revisions expire ttl after they were created
+type RevisionRepository struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+// NewRevisionRepository creates a new sqlite revision repository
+func NewRevisionRepository(db *sql.DB, ttl time.Duration) *RevisionRepository {
+	return &RevisionRepository{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+// AddRevisions stores a revision for every article using the article's revision number.
+// Revisions are never overwritten, storing an existing revision again is a no-op
+func (r RevisionRepository) AddRevisions(ctx context.Context, newsArticles []news.NewsArticle) (err error) {
+	defer func() { err = translateError("addRevisions", err) }()
+
+	if len(newsArticles) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixNano()
+
+	for _, n := range newsArticles {
+		data, err := json.Marshal(n.Data)
+		if err != nil {
+			return storage.NewError(storage.ErrInvalidQuery, "addRevisions", err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO revisions (club, article_id, revision, created_at, last_update_date, content_hash, data, subtitle)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (club, article_id, revision) DO NOTHING`,
+			n.Club, n.Data.Id, n.Revision, now, n.LastUpdateDate, n.ContentHash, string(data), n.Data.Subtitle,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetRevisions returns every stored revision of an article of a club ordered by revision number.
+// When club is empty the revisions of the article with that id are returned, whatever its club
+func (r RevisionRepository) GetRevisions(ctx context.Context, club, articleID string) (_ []storage.Revision, err error) {
+	defer func() { err = translateError("getRevisions", err) }()
+
+	revisions := []storage.Revision{}
+
+	query := `SELECT club, article_id, revision, created_at, last_update_date, content_hash, data, subtitle
+		FROM revisions WHERE article_id = ? AND created_at >= ?`
+	args := []interface{}{articleID, time.Now().Add(-r.ttl).UnixNano()}
+	if club != "" {
+		query += ` AND club = ?`
+		args = append(args, club)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query+` ORDER BY revision`, args...)
+	if err != nil {
+		return revisions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rev storage.Revision
+		var createdAt int64
+		var data, subtitle string
+
+		if err = rows.Scan(&rev.Club, &rev.ArticleID, &rev.Revision, &createdAt, &rev.LastUpdateDate, &rev.ContentHash, &data, &subtitle); err != nil {
+			return revisions, err
+		}
+		if err = json.Unmarshal([]byte(data), &rev.Data); err != nil {
+			return revisions, err
+		}
+
+		rev.CreatedAt = time.Unix(0, createdAt).UTC()
+		rev.Data.Subtitle = subtitle
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
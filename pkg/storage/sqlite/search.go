@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+)
+
+// searchScore is the relevance of an article matching the articles_fts MATCH of a query. The fields are
+// weighted like the ones of the mongo text index, bm25 ranks better matches lower
+const searchScore = `-bm25(articles_fts, 10.0, 5.0, 3.0, 1.0)`
+
+// SearchNews returns a page of the articles matching a full-text search sorted by -score.
+// Ties on the score are broken like the pages of GetNewsPage
+func (r Repository) SearchNews(ctx context.Context, q storage.SearchQuery) (page storage.SearchPage, err error) {
+	defer func() { err = translateError("searchNews", err) }()
+
+	match := ftsQuery(news.ParseSearch(q.Text))
+	if match == "" {
+		return page, storage.NewError(storage.ErrInvalidQuery, "searchNews", nil)
+	}
+
+	where, args := r.pageWhere(q.PageQuery)
+	from := `FROM articles_fts JOIN articles a ON a.id = articles_fts.rowid WHERE articles_fts MATCH ? AND ` + where
+	args = append([]interface{}{match}, args...)
+
+	if err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) `+from, args...).Scan(&page.TotalItems); err != nil {
+		return page, err
+	}
+
+	// The score can only be compared once it has been selected
+	query := `SELECT ` + resultColumns + `, a.score FROM (SELECT ` + resultColumns + `, ` + searchScore + ` AS score ` + from + `) a`
+	if q.Cursor != nil {
+		seek, seekArgs := seekWhere(*q.Cursor, true)
+		query, args = query+` WHERE `+seek, append(args, seekArgs...)
+	}
+
+	direction := sortDirection(q.Cursor)
+
+	// Fetch one extra row to find out whether there are more pages in that direction
+	rows, err := r.db.QueryContext(ctx,
+		query+` ORDER BY a.score `+direction+`, a.published_at `+direction+`, a.article_id `+direction+` LIMIT ?`,
+		append(args, q.Limit+1)...,
+	)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	results := make([]storage.SearchResult, 0, q.Limit+1)
+	for rows.Next() {
+		var score float64
+		res, err := scanResult(rows, &score)
+		if err != nil {
+			return page, err
+		}
+		results = append(results, storage.SearchResult{Result: res, Score: score})
+	}
+	if err = rows.Err(); err != nil {
+		return page, err
+	}
+
+	hasMore := len(results) > q.Limit
+	if hasMore {
+		results = results[:q.Limit]
+	}
+
+	if q.Cursor != nil && q.Cursor.Backward {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+
+	page.Results = results
+	page.Next, page.Prev = storage.PageCursors(q.Cursor, len(results), hasMore, func(i int) storage.Cursor {
+		return storage.Cursor{Score: results[i].Score, PublishedAt: results[i].PublishedAt, ArticleID: results[i].ArticleID}
+	})
+
+	return page, nil
+}
+
+// ftsQuery translates search terms to an fts5 query with the semantics of mongo's $text: articles match
+// any of the words, all of the phrases and none of the excluded terms. It returns an empty query when
+// there is nothing to search for
+func ftsQuery(terms news.SearchTerms) string {
+	words := make([]string, len(terms.Words))
+	for i, w := range terms.Words {
+		words[i] = ftsString(w)
+	}
+	phrases := make([]string, len(terms.Phrases))
+	for i, p := range terms.Phrases {
+		phrases[i] = ftsString(p)
+	}
+
+	var query string
+	switch {
+	case len(phrases) > 0 && len(words) > 0:
+		// Words don't restrict the matches of phrases but still count towards their score
+		query = strings.Join(phrases, " AND ") + " AND (" + strings.Join(append(phrases[:1:1], words...), " OR ") + ")"
+	case len(phrases) > 0:
+		query = strings.Join(phrases, " AND ")
+	case len(words) > 0:
+		query = "(" + strings.Join(words, " OR ") + ")"
+	default:
+		return ""
+	}
+
+	for _, e := range terms.Excluded {
+		query += " NOT " + ftsString(e)
+	}
+
+	return query
+}
+
+// ftsString quotes a term as an fts5 string, which matches its words as a phrase
+func ftsString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// indexArticle replaces the full-text index entry of an article with the plain text of its searchable fields
+func indexArticle(ctx context.Context, tx *sql.Tx, id int64, d news.Data) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM articles_fts WHERE rowid = ?`, id); err != nil {
+		return err
+	}
+
+	teaser, _ := d.Teaser.(string)
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO articles_fts (rowid, title, subtitle, teaser, content) VALUES (?, ?, ?, ?, ?)`,
+		id, news.PlainText(d.Title), news.PlainText(d.Subtitle), news.PlainText(teaser), news.PlainText(d.Content),
+	)
+
+	return err
+}
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"com.thanos/pkg/storage"
+)
+
+// SyncStatusRepository is a storage.SyncStatusRepo stored in an embedded sqlite database
+type SyncStatusRepository struct {
+	db *sql.DB
+}
+
+// NewSyncStatusRepository creates a new sqlite sync status repository
+func NewSyncStatusRepository(db *sql.DB) *SyncStatusRepository {
+	return &SyncStatusRepository{
+		db: db,
+	}
+}
+
+// SetSyncStatus stores the status of the latest sync. A zero LastSuccessAt keeps the stored one
+func (r SyncStatusRepository) SetSyncStatus(ctx context.Context, status storage.SyncStatus) (err error) {
+	defer func() { err = translateError("setSyncStatus", err) }()
+
+	feeds, err := json.Marshal(status.Feeds)
+	if err != nil {
+		return err
+	}
+
+	var lastSuccessAt sql.NullInt64
+	if !status.LastSuccessAt.IsZero() {
+		lastSuccessAt = sql.NullInt64{Int64: status.LastSuccessAt.UnixNano(), Valid: true}
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO sync_status (id, last_sync_at, last_success_at, feeds) VALUES (1, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET last_sync_at = excluded.last_sync_at,
+			last_success_at = COALESCE(excluded.last_success_at, last_success_at), feeds = excluded.feeds`,
+		status.LastSyncAt.UnixNano(), lastSuccessAt, string(feeds),
+	)
+
+	return err
+}
+
+// GetSyncStatus returns the status of the latest sync, or a storage.ErrNotFound error when nothing was synced yet
+func (r SyncStatusRepository) GetSyncStatus(ctx context.Context) (status storage.SyncStatus, err error) {
+	defer func() { err = translateError("getSyncStatus", err) }()
+
+	var lastSyncAt int64
+	var lastSuccessAt sql.NullInt64
+	var feeds string
+
+	err = r.db.QueryRowContext(ctx, `SELECT last_sync_at, last_success_at, feeds FROM sync_status WHERE id = 1`).
+		Scan(&lastSyncAt, &lastSuccessAt, &feeds)
+	if err != nil {
+		return status, err
+	}
+
+	if err = json.Unmarshal([]byte(feeds), &status.Feeds); err != nil {
+		return status, err
+	}
+
+	status.LastSyncAt = time.Unix(0, lastSyncAt).UTC()
+	if lastSuccessAt.Valid {
+		status.LastSuccessAt = time.Unix(0, lastSuccessAt.Int64).UTC()
+	}
+
+	return status, nil
+}
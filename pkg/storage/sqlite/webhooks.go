@@ -0,0 +1,262 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"com.thanos/pkg/storage"
+)
+
+// WebhookRepository is a storage.WebhookRepo stored in an embedded sqlite database.
+// Deliveries are deleted by the Janitor ttl after they were created
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new sqlite webhook repository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+const webhookColumns = `id, url, secret, events, clubs, created_at`
+
+const deliveryColumns = `id, webhook_id, event_type, article_id, payload, status, attempts, created_at,
+	next_attempt_at, response_status, error`
+
+// CreateWebhook stores a new webhook, a storage.ErrConflict error when its id is taken
+func (r WebhookRepository) CreateWebhook(ctx context.Context, w storage.Webhook) (err error) {
+	defer func() { err = translateError("createWebhook", err) }()
+
+	events, err := json.Marshal(nonNil(w.Events))
+	if err != nil {
+		return err
+	}
+	clubs, err := json.Marshal(nonNil(w.Clubs))
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO webhooks (`+webhookColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		w.ID, w.URL, w.Secret, string(events), string(clubs), w.CreatedAt.UnixNano(),
+	)
+
+	return err
+}
+
+// GetWebhooks returns every webhook ordered by creation
+func (r WebhookRepository) GetWebhooks(ctx context.Context) (webhooks []storage.Webhook, err error) {
+	defer func() { err = translateError("getWebhooks", err) }()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks = []storage.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// GetWebhook returns a webhook, a storage.ErrNotFound error when it doesn't exist
+func (r WebhookRepository) GetWebhook(ctx context.Context, id string) (w storage.Webhook, err error) {
+	defer func() { err = translateError("getWebhook", err) }()
+
+	return scanWebhook(r.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id))
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries, a storage.ErrNotFound error when it doesn't exist
+func (r WebhookRepository) DeleteWebhook(ctx context.Context, id string) (err error) {
+	defer func() { err = translateError("deleteWebhook", err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if deleted, err := res.RowsAffected(); err != nil || deleted == 0 {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddDeliveries queues the deliveries
+func (r WebhookRepository) AddDeliveries(ctx context.Context, deliveries []storage.Delivery) (err error) {
+	defer func() { err = translateError("addDeliveries", err) }()
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, d := range deliveries {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO webhook_deliveries (`+deliveryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			d.ID, d.WebhookID, d.EventType, d.ArticleID, d.Payload, string(d.Status), d.Attempts,
+			d.CreatedAt.UnixNano(), d.NextAttemptAt.UnixNano(), d.ResponseStatus, d.Error,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ClaimDeliveries returns up to limit pending deliveries due at now, oldest first, and postpones their next
+// attempt by lease so that no other dispatcher claims them while they're attempted
+func (r WebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) (deliveries []storage.Delivery, err error) {
+	defer func() { err = translateError("claimDeliveries", err) }()
+
+	// transactions take the write lock immediately, so no other process claims the same deliveries
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at LIMIT ?`,
+		string(storage.DeliveryPending), now.UnixNano(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries = []storage.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	leased := now.Add(lease)
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = leased.UTC()
+		if _, err = tx.ExecContext(ctx, `UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id = ?`,
+			leased.UnixNano(), deliveries[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return deliveries, tx.Commit()
+}
+
+// UpdateDelivery stores the outcome of an attempted delivery
+func (r WebhookRepository) UpdateDelivery(ctx context.Context, d storage.Delivery) (err error) {
+	defer func() { err = translateError("updateDelivery", err) }()
+
+	_, err = r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, error = ?
+		WHERE id = ?`,
+		string(d.Status), d.Attempts, d.NextAttemptAt.UnixNano(), d.ResponseStatus, d.Error, d.ID,
+	)
+
+	return err
+}
+
+// GetDeliveries returns the latest limit deliveries of a webhook, newest first
+func (r WebhookRepository) GetDeliveries(ctx context.Context, webhookID string, limit int) (deliveries []storage.Delivery, err error) {
+	defer func() { err = translateError("getDeliveries", err) }()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE webhook_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		webhookID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries = []storage.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func scanWebhook(row scanner) (storage.Webhook, error) {
+	var w storage.Webhook
+	var events, clubs string
+	var createdAt int64
+
+	if err := row.Scan(&w.ID, &w.URL, &w.Secret, &events, &clubs, &createdAt); err != nil {
+		return w, err
+	}
+	if err := json.Unmarshal([]byte(events), &w.Events); err != nil {
+		return w, err
+	}
+	if err := json.Unmarshal([]byte(clubs), &w.Clubs); err != nil {
+		return w, err
+	}
+	w.CreatedAt = time.Unix(0, createdAt).UTC()
+
+	return w, nil
+}
+
+func scanDelivery(row scanner) (storage.Delivery, error) {
+	var d storage.Delivery
+	var status string
+	var createdAt, nextAttemptAt int64
+
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.ArticleID, &d.Payload, &status, &d.Attempts,
+		&createdAt, &nextAttemptAt, &d.ResponseStatus, &d.Error)
+	if err != nil {
+		return d, err
+	}
+	d.Status = storage.DeliveryStatus(status)
+	d.CreatedAt = time.Unix(0, createdAt).UTC()
+	d.NextAttemptAt = time.Unix(0, nextAttemptAt).UTC()
+
+	return d, nil
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
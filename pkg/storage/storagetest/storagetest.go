@@ -0,0 +1,777 @@
+// Package storagetest is a conformance test suite for the storage backends. Every backend must pass it
+// so that they can be swapped for one another.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+)
+
+// TestDBRepo runs the conformance tests of a storage.DBRepo. The repository may already hold articles,
+// every test stores its own under a unique club and source. Its TTL must be between 10 minutes and a day
+func TestDBRepo(t *testing.T, repo storage.DBRepo) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo storage.DBRepo, prefix string)
+	}{
+		{"GetArticleByID", testGetArticleByID},
+		{"BulkInsertUpserts", testBulkInsertUpserts},
+		{"GetNewsPage", testGetNewsPage},
+		{"GetNewsPageFilter", testGetNewsPageFilter},
+		{"StreamNews", testStreamNews},
+		{"Fingerprints", testFingerprints},
+		{"WithdrawArticles", testWithdrawArticles},
+		{"UpdatedArticles", testUpdatedArticles},
+		{"SearchNews", testSearchNews},
+		{"ArticlesOfClubs", testArticlesOfClubs},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, repo, uniquePrefix())
+		})
+	}
+}
+
+// TestRevisionRepo runs the conformance tests of a storage.RevisionRepo
+func TestRevisionRepo(t *testing.T, repo storage.RevisionRepo) {
+	id := uniquePrefix()
+	ctx := context.Background()
+
+	first, second := Article(id, id, time.Now()), Article(id, id, time.Now())
+	first.Revision, second.Revision = 1, 2
+	second.Data.Title = "changed"
+
+	if err := repo.AddRevisions(ctx, []news.NewsArticle{second, first}); err != nil {
+		t.Fatal(err)
+	}
+
+	// storing an existing revision again is a no-op
+	first.Data.Title = "overwritten"
+	if err := repo.AddRevisions(ctx, []news.NewsArticle{first}); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := repo.GetRevisions(ctx, id, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[1].Revision != 2 {
+		t.Fatalf("expected revisions 1 and 2 in order, got %+v", revisions)
+	}
+
+	if revisions[0].Data.Title == "overwritten" || revisions[1].Data.Title != "changed" {
+		t.Fatalf("revisions should never be overwritten, got %+v", revisions)
+	}
+
+	// article ids are only unique within a club
+	other := Article(id, id+"-other", time.Now())
+	other.Revision = 1
+	if err = repo.AddRevisions(ctx, []news.NewsArticle{other}); err != nil {
+		t.Fatal(err)
+	}
+
+	if revisions, err = repo.GetRevisions(ctx, id+"-other", id); err != nil || len(revisions) != 1 || revisions[0].Club != id+"-other" {
+		t.Fatalf("expected the revision of the other club, got %+v (%v)", revisions, err)
+	}
+
+	if revisions, err = repo.GetRevisions(ctx, id, id); err != nil || len(revisions) != 2 {
+		t.Fatalf("expected the revisions of the club to be left as is, got %+v (%v)", revisions, err)
+	}
+
+	if revisions, err = repo.GetRevisions(ctx, "", "does-not-exist"); err != nil || len(revisions) != 0 {
+		t.Fatalf("expected no revisions, got %+v (%v)", revisions, err)
+	}
+}
+
+// TestSyncStatusRepo runs the conformance tests of a storage.SyncStatusRepo. The repository may already
+// hold the status of a previous sync
+func TestSyncStatusRepo(t *testing.T, repo storage.SyncStatusRepo) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	success := storage.SyncStatus{LastSyncAt: now, LastSuccessAt: now, Feeds: map[string]string{"club": "closed"}}
+	if err := repo.SetSyncStatus(ctx, success); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed sync keeps the time of the last successful one
+	failure := storage.SyncStatus{LastSyncAt: now.Add(time.Minute), Feeds: map[string]string{"club": "open"}}
+	if err := repo.SetSyncStatus(ctx, failure); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := repo.GetSyncStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := storage.SyncStatus{LastSyncAt: failure.LastSyncAt, LastSuccessAt: now, Feeds: failure.Feeds}
+	if !status.LastSyncAt.Equal(expected.LastSyncAt) || !status.LastSuccessAt.Equal(expected.LastSuccessAt) ||
+		fmt.Sprint(status.Feeds) != fmt.Sprint(expected.Feeds) {
+		t.Fatalf("expected sync status %+v, got %+v", expected, status)
+	}
+}
+
+// TestWebhookRepo runs the conformance tests of a storage.WebhookRepo. The repository may already hold
+// webhooks and deliveries, but none may be due before 2000. Its TTL must be longer than a minute
+func TestWebhookRepo(t *testing.T, repo storage.WebhookRepo) {
+	ctx := context.Background()
+	id := uniquePrefix()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	webhook := storage.Webhook{
+		ID:        id,
+		URL:       "https://partner.example/hooks",
+		Secret:    "secret",
+		Events:    []string{"article.created"},
+		Clubs:     []string{},
+		CreatedAt: now,
+	}
+	if err := repo.CreateWebhook(ctx, webhook); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateWebhook(ctx, webhook); !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("expected a storage.ErrConflict error for a duplicate webhook, got %v", err)
+	}
+
+	stored, err := repo.GetWebhook(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.URL != webhook.URL || stored.Secret != webhook.Secret || fmt.Sprint(stored.Events) != fmt.Sprint(webhook.Events) ||
+		len(stored.Clubs) != 0 || !stored.CreatedAt.Equal(now) {
+		t.Fatalf("expected webhook %+v, got %+v", webhook, stored)
+	}
+
+	webhooks, err := repo.GetWebhooks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasWebhook(webhooks, id) {
+		t.Fatalf("expected webhook %s to be listed, got %+v", id, webhooks)
+	}
+
+	// deliveries due long ago are claimed before any other
+	due := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	deliveries := make([]storage.Delivery, 3)
+	for i := range deliveries {
+		deliveries[i] = storage.Delivery{
+			ID:            fmt.Sprintf("%s-%d", id, i),
+			WebhookID:     id,
+			EventType:     "article.created",
+			ArticleID:     id,
+			Payload:       `{}`,
+			Status:        storage.DeliveryPending,
+			CreatedAt:     now.Add(time.Duration(i) * time.Second),
+			NextAttemptAt: due.Add(time.Duration(i) * time.Second),
+		}
+	}
+	// not due yet
+	deliveries[2].NextAttemptAt = now.Add(time.Hour)
+	if err = repo.AddDeliveries(ctx, deliveries); err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := repo.ClaimDeliveries(ctx, due.Add(time.Minute), time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != deliveries[0].ID || claimed[0].Payload != `{}` {
+		t.Fatalf("expected the first delivery to be claimed, got %+v", claimed)
+	}
+
+	// claimed deliveries are leased
+	claimed, err = repo.ClaimDeliveries(ctx, due.Add(time.Minute), time.Minute, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != deliveries[1].ID {
+		t.Fatalf("expected only the second delivery to be claimed, got %+v", claimed)
+	}
+
+	failed := claimed[0]
+	failed.Status, failed.Attempts, failed.ResponseStatus, failed.Error = storage.DeliveryDead, 5, 500, "internal error"
+	if err = repo.UpdateDelivery(ctx, failed); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := repo.GetDeliveries(ctx, id, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 || log[0].ID != deliveries[2].ID || log[1].ID != deliveries[1].ID {
+		t.Fatalf("expected the latest 2 deliveries, newest first, got %+v", log)
+	}
+	if log[1].Status != storage.DeliveryDead || log[1].Attempts != 5 || log[1].ResponseStatus != 500 || log[1].Error != "internal error" {
+		t.Fatalf("expected the outcome of the delivery to be stored, got %+v", log[1])
+	}
+
+	if err = repo.DeleteWebhook(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.GetWebhook(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected a storage.ErrNotFound error for a deleted webhook, got %v", err)
+	}
+	if err = repo.DeleteWebhook(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected a storage.ErrNotFound error for a deleted webhook, got %v", err)
+	}
+	if log, err = repo.GetDeliveries(ctx, id, 10); err != nil || len(log) != 0 {
+		t.Fatalf("expected the deliveries to be deleted along with their webhook, got %+v (%v)", log, err)
+	}
+}
+
+func hasWebhook(webhooks []storage.Webhook, id string) bool {
+	for _, w := range webhooks {
+		if w.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Article returns an article of the given club published at the given time
+func Article(id, club string, published time.Time) news.NewsArticle {
+	return news.NewsArticle{
+		Data: news.Data{
+			Id:        id,
+			TeamId:    "t94",
+			Title:     "Article " + id,
+			Type:      []string{"Club News"},
+			Content:   "<p>Content of article " + id + "</p>",
+			Url:       "https://club.example/news/" + id,
+			Published: published.UTC().Format(news.DateTimeFormat),
+		},
+		Status:         "success",
+		LastUpdateDate: published.UTC().Format(news.DateTimeFormat),
+		Club:           club,
+		Source:         club,
+	}
+}
+
+func testGetArticleByID(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	id := prefix + "-1"
+
+	a := Article(id, prefix, time.Now())
+	a.ContentHash = a.Data.Hash()
+	insert(t, repo, a)
+
+	res, err := repo.GetArticleByID(ctx, "", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ArticleID != id || res.Club != prefix || res.Data.Title != "Article "+id {
+		t.Fatalf("unexpected article: %+v", res)
+	}
+	if res.LastUpdateDate != a.LastUpdateDate || res.ContentHash != a.ContentHash {
+		t.Fatalf("expected the version of the article, got %s and %s", res.LastUpdateDate, res.ContentHash)
+	}
+
+	if _, err = repo.GetArticleByID(ctx, prefix, id); err != nil {
+		t.Fatalf("expected to find the article of its club, got: %v", err)
+	}
+
+	if _, err = repo.GetArticleByID(ctx, prefix+"-other", id); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected a not found error for another club, got: %v", err)
+	}
+
+	if _, err = repo.GetArticleByID(ctx, "", prefix+"-missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected a not found error, got: %v", err)
+	}
+}
+
+func testBulkInsertUpserts(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	id := prefix + "-1"
+
+	a := Article(id, prefix, time.Now())
+	insert(t, repo, a)
+
+	a.Data.Title = "changed"
+	a.Revision = 2
+	insert(t, repo, a)
+
+	page, err := repo.GetNewsPage(ctx, storage.PageQuery{Club: prefix, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.TotalItems != 1 || len(page.Results) != 1 || page.Results[0].Data.Title != "changed" {
+		t.Fatalf("expected the article to be updated in place, got %+v", page)
+	}
+
+	invalid := Article(prefix+"-2", prefix, time.Now())
+	invalid.Data.Published = "yesterday"
+	if _, err = repo.BulkInsert(context.Background(), []news.NewsArticle{invalid}); !errors.Is(err, storage.ErrInvalidQuery) {
+		t.Fatalf("expected an invalid query error for an article without a valid publish date, got: %v", err)
+	}
+}
+
+func testGetNewsPage(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	// a and b share their publish time and are ordered by -articleID
+	insert(t, repo,
+		Article(prefix+"-c", prefix, now.Add(-1*time.Minute)),
+		Article(prefix+"-a", prefix, now.Add(-2*time.Minute)),
+		Article(prefix+"-b", prefix, now.Add(-2*time.Minute)),
+		Article(prefix+"-d", prefix, now.Add(-3*time.Minute)),
+		Article(prefix+"-e", prefix, now.Add(-4*time.Minute)),
+		// expired
+		Article(prefix+"-f", prefix, now.Add(-48*time.Hour)),
+	)
+
+	expected := []string{prefix + "-c", prefix + "-b", prefix + "-a", prefix + "-d", prefix + "-e"}
+
+	var ids []string
+	var last storage.Page
+	q := storage.PageQuery{Club: prefix, Limit: 2}
+	for {
+		page, err := repo.GetNewsPage(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.TotalItems != int64(len(expected)) {
+			t.Fatalf("expected %d articles in total, got %d", len(expected), page.TotalItems)
+		}
+		if q.Cursor != nil && page.Prev == nil {
+			t.Fatal("expected a previous page cursor")
+		}
+
+		for _, r := range page.Results {
+			ids = append(ids, r.ArticleID)
+		}
+
+		last = page
+		if page.Next == nil {
+			break
+		}
+		q.Cursor = page.Next
+	}
+
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Fatalf("expected articles %v, got %v", expected, ids)
+	}
+
+	// paging backward from the last page returns the page before it
+	page, err := repo.GetNewsPage(ctx, storage.PageQuery{Club: prefix, Limit: 2, Cursor: last.Prev})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Results) != 2 || page.Results[0].ArticleID != expected[2] || page.Results[1].ArticleID != expected[3] {
+		t.Fatalf("expected the previous page, got %+v", page.Results)
+	}
+	if page.Next == nil || page.Prev == nil {
+		t.Fatalf("expected both page cursors, got %+v", page)
+	}
+}
+
+func testGetNewsPageFilter(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	withVideo := Article(prefix+"-video", prefix, now.Add(-1*time.Minute))
+	withVideo.Data.Type = []string{"Video"}
+	withVideo.Data.VideoUrl = "https://club.example/video.mp4"
+	withVideo.Data.OptaMatchId = "g2300001"
+
+	withGallery := Article(prefix+"-gallery", prefix, now.Add(-5*time.Minute))
+	withGallery.Data.Type = []string{"Galleries", "Club News"}
+	withGallery.Data.GalleryUrls = []string{"https://club.example/a.jpg"}
+	withGallery.Data.TeamId = "t1"
+
+	insert(t, repo, withVideo, withGallery)
+
+	yes, no := true, false
+	from, to := now.Add(-3*time.Minute), now
+
+	testCases := []struct {
+		description string
+		filter      storage.Filter
+		expected    string
+	}{
+		{"should filter by type", storage.Filter{Types: []string{"Club News", "Players"}}, withGallery.Data.Id},
+		{"should filter by video", storage.Filter{HasVideo: &yes}, withVideo.Data.Id},
+		{"should filter by missing video", storage.Filter{HasVideo: &no}, withGallery.Data.Id},
+		{"should filter by gallery", storage.Filter{HasGallery: &yes}, withGallery.Data.Id},
+		{"should filter by missing gallery", storage.Filter{HasGallery: &no}, withVideo.Data.Id},
+		{"should filter by match", storage.Filter{OptaMatchId: "g2300001"}, withVideo.Data.Id},
+		{"should filter by team", storage.Filter{TeamId: "t1"}, withGallery.Data.Id},
+		{"should filter by publish time", storage.Filter{From: &from, To: &to}, withVideo.Data.Id},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			page, err := repo.GetNewsPage(ctx, storage.PageQuery{Club: prefix, Limit: 10, Filter: tc.filter})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if page.TotalItems != 1 || len(page.Results) != 1 || page.Results[0].ArticleID != tc.expected {
+				t.Fatalf("expected only %s, got %+v", tc.expected, page.Results)
+			}
+		})
+	}
+}
+
+func testStreamNews(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	insert(t, repo,
+		Article(prefix+"-c", prefix, now.Add(-1*time.Minute)),
+		Article(prefix+"-a", prefix, now.Add(-2*time.Minute)),
+		Article(prefix+"-b", prefix, now.Add(-2*time.Minute)),
+		Article(prefix+"-d", prefix, now.Add(-3*time.Minute)),
+		// expired
+		Article(prefix+"-e", prefix, now.Add(-48*time.Hour)),
+	)
+
+	stream := func(q storage.PageQuery) []string {
+		t.Helper()
+
+		var ids []string
+		if err := repo.StreamNews(ctx, q, func(res storage.Result) error {
+			ids = append(ids, res.ArticleID)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		return ids
+	}
+
+	all := stream(storage.PageQuery{Club: prefix})
+	if fmt.Sprint(all) != fmt.Sprint([]string{prefix + "-c", prefix + "-b", prefix + "-a", prefix + "-d"}) {
+		t.Fatalf("expected every live article, got %v", all)
+	}
+
+	cursor := &storage.Cursor{PublishedAt: now.Add(-2 * time.Minute).UTC(), ArticleID: prefix + "-b"}
+	following := stream(storage.PageQuery{Club: prefix, Cursor: cursor, Limit: 1})
+	if fmt.Sprint(following) != fmt.Sprint([]string{prefix + "-a"}) {
+		t.Fatalf("expected the article following the cursor, got %v", following)
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err := repo.StreamNews(ctx, storage.PageQuery{Club: prefix}, func(storage.Result) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Fatalf("expected streaming to stop at the first error, got %v after %d calls", err, calls)
+	}
+}
+
+func testFingerprints(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	id := prefix + "-1"
+
+	a := Article(id, prefix, time.Now())
+	a.ContentHash = a.Data.Hash()
+	a.Revision = 3
+	insert(t, repo, a)
+
+	if err := repo.SetLastUpdateDates(ctx, prefix, map[string]string{id: "2000-01-01 00:00:00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprints, err := repo.GetFingerprints(ctx, prefix, []string{id, prefix + "-missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := storage.Fingerprint{LastUpdateDate: "2000-01-01 00:00:00", ContentHash: a.ContentHash, Revision: 3}
+	if len(fingerprints) != 1 || fmt.Sprint(fingerprints[id]) != fmt.Sprint(expected) {
+		t.Fatalf("expected a single fingerprint %+v, got %+v", expected, fingerprints)
+	}
+}
+
+func testWithdrawArticles(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	kept, withdrawn := Article(prefix+"-1", prefix, now.Add(-time.Minute)), Article(prefix+"-2", prefix, now.Add(-time.Minute))
+	old := Article(prefix+"-3", prefix, now.Add(-5*time.Minute))
+	insert(t, repo, kept, withdrawn, old)
+
+	ids, err := repo.GetSourceArticleIDs(ctx, prefix, prefix, now.Add(-2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(ids)
+	if fmt.Sprint(ids) != fmt.Sprint([]string{prefix + "-1", prefix + "-2"}) {
+		t.Fatalf("expected the articles of the source published in the window, got %v", ids)
+	}
+
+	for i := 0; i < 2; i++ {
+		ids, err := repo.WithdrawArticles(ctx, prefix, []string{withdrawn.Data.Id, prefix + "-missing"}, news.WithdrawnDeleted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// withdrawing an article again is a no-op
+		expected := []string{withdrawn.Data.Id}
+		if i > 0 {
+			expected = []string{}
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(expected) {
+			t.Fatalf("expected the newly withdrawn articles %v, got %v", expected, ids)
+		}
+	}
+
+	res, err := repo.GetArticleByID(ctx, "", withdrawn.Data.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Withdrawn == nil || res.Withdrawn.Reason != news.WithdrawnDeleted || res.Withdrawn.At.IsZero() {
+		t.Fatalf("expected the article to be withdrawn, got %+v", res.Withdrawn)
+	}
+
+	fingerprints, err := repo.GetFingerprints(ctx, prefix, []string{withdrawn.Data.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fingerprints[withdrawn.Data.Id].Withdrawn == nil {
+		t.Fatal("expected the fingerprint of a withdrawn article to be withdrawn")
+	}
+
+	if ids, err = repo.GetSourceArticleIDs(ctx, prefix, prefix, now.Add(-2*time.Minute)); err != nil || len(ids) != 1 {
+		t.Fatalf("expected withdrawn articles to be left out, got %v (%v)", ids, err)
+	}
+
+	page, err := repo.GetNewsPage(ctx, storage.PageQuery{Club: prefix, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalItems != 2 {
+		t.Fatalf("expected withdrawn articles to be hidden, got %d articles", page.TotalItems)
+	}
+
+	page, err = repo.GetNewsPage(ctx, storage.PageQuery{Club: prefix, Limit: 10, IncludeWithdrawn: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalItems != 3 {
+		t.Fatalf("expected withdrawn articles to be included, got %d articles", page.TotalItems)
+	}
+
+	// articles that show up again are restored
+	insert(t, repo, withdrawn)
+
+	if res, err = repo.GetArticleByID(ctx, "", withdrawn.Data.Id); err != nil || res.Withdrawn != nil {
+		t.Fatalf("expected the article to be restored, got %+v (%v)", res.Withdrawn, err)
+	}
+}
+
+func testUpdatedArticles(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	since := time.Now().Add(-time.Second)
+
+	created, withdrawn := Article(prefix+"-1", prefix, time.Now()), Article(prefix+"-2", prefix, time.Now())
+	created.Revision, withdrawn.Revision = 1, 1
+	insert(t, repo, created, withdrawn, Article(prefix+"-3", prefix, time.Now().Add(-48*time.Hour)))
+	if _, err := repo.WithdrawArticles(ctx, prefix, []string{withdrawn.Data.Id}, news.WithdrawnDeleted); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := func(since time.Time) []storage.UpdatedArticle {
+		t.Helper()
+
+		all, err := repo.GetUpdatedArticles(ctx, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the repository may hold the articles of other tests
+		var updated []storage.UpdatedArticle
+		for _, u := range all {
+			if u.Club == prefix {
+				updated = append(updated, u)
+			}
+		}
+
+		return updated
+	}
+
+	got := updated(since)
+	if len(got) != 2 || got[0].ArticleID != created.Data.Id || got[0].Revision != 1 || got[0].Withdrawn != nil {
+		t.Fatalf("expected the live articles written since, oldest write first, got %+v", got)
+	}
+	if got[1].ArticleID != withdrawn.Data.Id || got[1].Withdrawn == nil || got[1].UpdatedAt.Before(got[0].UpdatedAt) {
+		t.Fatalf("expected the withdrawal to be the latest write, got %+v", got[1])
+	}
+
+	created.Revision = 2
+	insert(t, repo, created)
+
+	// writes at the same time as since are included
+	rewritten := false
+	for _, u := range updated(got[1].UpdatedAt) {
+		rewritten = rewritten || u.ArticleID == created.Data.Id && u.Revision == 2
+	}
+	if !rewritten {
+		t.Fatal("expected the rewritten article to be written since the withdrawal")
+	}
+
+	if got = updated(time.Now().Add(time.Hour)); len(got) != 0 {
+		t.Fatalf("expected no articles written after now, got %+v", got)
+	}
+}
+
+func testSearchNews(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	word, other := randomWord(), randomWord()
+
+	inTitle := Article(prefix+"-title", prefix, now.Add(-3*time.Minute))
+	inTitle.Data.Title = "The " + word + " returns"
+
+	inContent := Article(prefix+"-content", prefix, now.Add(-time.Minute))
+	inContent.Data.Content = "<p>Tickets for the " + word + " " + other + " are on sale</p>"
+
+	excluded := Article(prefix+"-excluded", prefix, now.Add(-2*time.Minute))
+	excluded.Data.Content = "<p>The " + word + " is sold out</p>"
+
+	insert(t, repo, inTitle, inContent, excluded)
+
+	// Backends weigh matches differently, only the order of title and content matches is certain
+	testCases := []struct {
+		description string
+		text        string
+		expected    []string
+	}{
+		{"should sort by relevance", word + " -" + other, []string{inTitle.Data.Id, excluded.Data.Id}},
+		{"should leave out negated words", word + " -sold", []string{inTitle.Data.Id, inContent.Data.Id}},
+		{"should match phrases", `"` + word + " " + other + `"`, []string{inContent.Data.Id}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var ids []string
+			q := storage.SearchQuery{PageQuery: storage.PageQuery{Club: prefix, Limit: 1}, Text: tc.text}
+
+			for {
+				page, err := repo.SearchNews(ctx, q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if page.TotalItems != int64(len(tc.expected)) {
+					t.Fatalf("expected %d matching articles, got %d", len(tc.expected), page.TotalItems)
+				}
+
+				for _, r := range page.Results {
+					if r.Score <= 0 {
+						t.Fatalf("expected a relevance score, got %v", r.Score)
+					}
+					ids = append(ids, r.ArticleID)
+				}
+
+				if page.Next == nil {
+					break
+				}
+				q.Cursor = page.Next
+			}
+
+			if fmt.Sprint(ids) != fmt.Sprint(tc.expected) {
+				t.Fatalf("expected articles %v, got %v", tc.expected, ids)
+			}
+		})
+	}
+}
+
+// testArticlesOfClubs stores articles of two clubs sharing an id, article ids are only unique within a club
+func testArticlesOfClubs(t *testing.T, repo storage.DBRepo, prefix string) {
+	ctx := context.Background()
+	id, club, other := prefix+"-1", prefix, prefix+"-other"
+
+	a, b := Article(id, club, time.Now().Add(-time.Minute)), Article(id, other, time.Now())
+	b.Data.Title = "Other " + id
+	a.ContentHash, b.ContentHash = a.Data.Hash(), b.Data.Hash()
+	insert(t, repo, a, b)
+
+	for _, c := range []news.NewsArticle{a, b} {
+		res, err := repo.GetArticleByID(ctx, c.Club, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Club != c.Club || res.Data.Title != c.Data.Title {
+			t.Fatalf("expected the article of %s, got %+v", c.Club, res)
+		}
+
+		fingerprints, err := repo.GetFingerprints(ctx, c.Club, []string{id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fingerprints[id].ContentHash != c.ContentHash {
+			t.Fatalf("expected the fingerprint of the article of %s, got %+v", c.Club, fingerprints)
+		}
+	}
+
+	// without a club the latest published article is returned
+	res, err := repo.GetArticleByID(ctx, "", id)
+	if err != nil || res.Club != other {
+		t.Fatalf("expected the latest published article, got %+v (%v)", res, err)
+	}
+
+	if err = repo.SetLastUpdateDates(ctx, club, map[string]string{id: "2000-01-01 00:00:00"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := repo.WithdrawArticles(ctx, club, []string{id}, news.WithdrawnDeleted); err != nil || len(ids) != 1 {
+		t.Fatalf("expected a single withdrawn article, got %v (%v)", ids, err)
+	}
+
+	fingerprints, err := repo.GetFingerprints(ctx, other, []string{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp := fingerprints[id]; fp.Withdrawn != nil || fp.LastUpdateDate != b.LastUpdateDate {
+		t.Fatalf("expected the article of the other club to be left as is, got %+v", fp)
+	}
+
+	if ids, err := repo.GetSourceArticleIDs(ctx, other, other, time.Now().Add(-time.Hour)); err != nil || len(ids) != 1 {
+		t.Fatalf("expected the article of the other club's source, got %v (%v)", ids, err)
+	}
+}
+
+func insert(t *testing.T, repo storage.DBRepo, articles ...news.NewsArticle) {
+	t.Helper()
+
+	if _, err := repo.BulkInsert(context.Background(), articles); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// uniquePrefix returns a prefix that is unique across test runs, since persistent backends keep
+// the articles of previous runs
+func uniquePrefix() string {
+	return fmt.Sprintf("storagetest-%x-%x", time.Now().UnixNano(), rand.Int31())
+}
+
+// randomWord returns a word that no other stored article contains
+func randomWord() string {
+	letters := []rune("abcdefghijklmnopqrstuvwxyz")
+
+	b := make([]rune, 12)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+
+	return "x" + string(b)
+}
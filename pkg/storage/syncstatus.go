@@ -0,0 +1,13 @@
+package storage
+
+import "time"
+
+// SyncStatus is the outcome of the latest sync of the news articles, stored so that the api can
+// report on the fetcher's health
+type SyncStatus struct {
+	// LastSyncAt is when the latest sync ended and LastSuccessAt when the latest sync of every source succeeded
+	LastSyncAt    time.Time `bson:"lastSyncAt"`
+	LastSuccessAt time.Time `bson:"lastSuccessAt"`
+	// Feeds is the circuit breaker state of every upstream feed called by the syncer
+	Feeds map[string]string `bson:"feeds"`
+}
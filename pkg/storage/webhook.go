@@ -0,0 +1,58 @@
+package storage
+
+import "time"
+
+// Webhook is the subscription of a partner endpoint to the events of the stored articles
+type Webhook struct {
+	ID  string `json:"id" bson:"_id"`
+	URL string `json:"url" bson:"url"`
+	// Secret signs the payloads delivered to the webhook
+	Secret string `json:"-" bson:"secret"`
+	// Events and Clubs restrict the deliveries to the events of the given types and clubs, empty means all
+	Events    []string  `json:"events" bson:"events"`
+	Clubs     []string  `json:"clubs" bson:"clubs"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// Matches reports whether an event of the given type and club is delivered to the webhook
+func (w Webhook) Matches(eventType, club string) bool {
+	return (len(w.Events) == 0 || contains(w.Events, eventType)) && (len(w.Clubs) == 0 || contains(w.Clubs, club))
+}
+
+// DeliveryStatus is the state of a delivery in the queue
+type DeliveryStatus string
+
+// Delivery statuses
+const (
+	// DeliveryPending deliveries are attempted at their NextAttemptAt
+	DeliveryPending DeliveryStatus = "pending"
+	DeliveryDone    DeliveryStatus = "delivered"
+	// DeliveryDead deliveries failed every attempt, they're never attempted again
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Delivery is the delivery of the payload of an event to a webhook, queued until it succeeds or dies
+type Delivery struct {
+	ID        string `json:"id" bson:"_id"`
+	WebhookID string `json:"webhookId" bson:"webhookID"`
+	EventType string `json:"eventType" bson:"eventType"`
+	ArticleID string `json:"articleId" bson:"articleID"`
+	// Payload is the JSON body delivered to the webhook
+	Payload       string         `json:"-" bson:"payload"`
+	Status        DeliveryStatus `json:"status" bson:"status"`
+	Attempts      int            `json:"attempts" bson:"attempts"`
+	CreatedAt     time.Time      `json:"createdAt" bson:"createdAt"`
+	NextAttemptAt time.Time      `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	// ResponseStatus and Error describe the outcome of the latest attempt
+	ResponseStatus int    `json:"responseStatus,omitempty" bson:"responseStatus"`
+	Error          string `json:"error,omitempty" bson:"error"`
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
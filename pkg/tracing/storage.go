@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DBRepo returns a repository tracing every operation of repo in a span, driver is the storage driver of repo
+func DBRepo(driver string, repo storage.DBRepo) storage.DBRepo {
+	return &dbRepo{repo: repo, driver: driver}
+}
+
+// RevisionRepo returns a repository tracing every operation of repo in a span, driver is the storage driver of repo
+func RevisionRepo(driver string, repo storage.RevisionRepo) storage.RevisionRepo {
+	return &revisionRepo{repo: repo, driver: driver}
+}
+
+// startOperation starts the client span of a storage operation
+func startOperation(ctx context.Context, driver, operation string) (context.Context, trace.Span) {
+	return Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemKey.String(driver), semconv.DBOperationKey.String(operation)),
+	)
+}
+
+type dbRepo struct {
+	repo   storage.DBRepo
+	driver string
+}
+
+func (r *dbRepo) GetArticleByID(ctx context.Context, club, id string) (res storage.Result, err error) {
+	ctx, span := startOperation(ctx, r.driver, "getArticleByID")
+	defer func() { End(span, err) }()
+	return r.repo.GetArticleByID(ctx, club, id)
+}
+
+func (r *dbRepo) GetNewsPage(ctx context.Context, q storage.PageQuery) (page storage.Page, err error) {
+	ctx, span := startOperation(ctx, r.driver, "getNewsPage")
+	defer func() { End(span, err) }()
+	return r.repo.GetNewsPage(ctx, q)
+}
+
+func (r *dbRepo) StreamNews(ctx context.Context, q storage.PageQuery, fn func(storage.Result) error) (err error) {
+	ctx, span := startOperation(ctx, r.driver, "streamNews")
+	defer func() { End(span, err) }()
+	return r.repo.StreamNews(ctx, q, fn)
+}
+
+func (r *dbRepo) SearchNews(ctx context.Context, q storage.SearchQuery) (page storage.SearchPage, err error) {
+	ctx, span := startOperation(ctx, r.driver, "searchNews")
+	defer func() { End(span, err) }()
+	return r.repo.SearchNews(ctx, q)
+}
+
+func (r *dbRepo) GetFingerprints(ctx context.Context, club string, ids []string) (fps map[string]storage.Fingerprint, err error) {
+	ctx, span := startOperation(ctx, r.driver, "getFingerprints")
+	defer func() { End(span, err) }()
+	return r.repo.GetFingerprints(ctx, club, ids)
+}
+
+func (r *dbRepo) SetLastUpdateDates(ctx context.Context, club string, lastUpdateDates map[string]string) (err error) {
+	ctx, span := startOperation(ctx, r.driver, "setLastUpdateDates")
+	defer func() { End(span, err) }()
+	return r.repo.SetLastUpdateDates(ctx, club, lastUpdateDates)
+}
+
+func (r *dbRepo) GetSourceArticleIDs(ctx context.Context, club, source string, publishedAfter time.Time) (ids []string, err error) {
+	ctx, span := startOperation(ctx, r.driver, "getSourceArticleIDs")
+	defer func() { End(span, err) }()
+	return r.repo.GetSourceArticleIDs(ctx, club, source, publishedAfter)
+}
+
+func (r *dbRepo) WithdrawArticles(ctx context.Context, club string, ids []string, reason string) (withdrawn []string, err error) {
+	ctx, span := startOperation(ctx, r.driver, "withdrawArticles")
+	defer func() { End(span, err) }()
+	return r.repo.WithdrawArticles(ctx, club, ids, reason)
+}
+
+func (r *dbRepo) BulkInsert(ctx context.Context, newsArticles []news.NewsArticle) (res *storage.BulkInsertResult, err error) {
+	ctx, span := startOperation(ctx, r.driver, "bulkInsert")
+	defer func() { End(span, err) }()
+	return r.repo.BulkInsert(ctx, newsArticles)
+}
+
+func (r *dbRepo) GetUpdatedArticles(ctx context.Context, since time.Time) (updated []storage.UpdatedArticle, err error) {
+	ctx, span := startOperation(ctx, r.driver, "getUpdatedArticles")
+	defer func() { End(span, err) }()
+	return r.repo.GetUpdatedArticles(ctx, since)
+}
+
+type revisionRepo struct {
+	repo   storage.RevisionRepo
+	driver string
+}
+
+func (r *revisionRepo) AddRevisions(ctx context.Context, newsArticles []news.NewsArticle) (err error) {
+	ctx, span := startOperation(ctx, r.driver, "addRevisions")
+	defer func() { End(span, err) }()
+	return r.repo.AddRevisions(ctx, newsArticles)
+}
+
+func (r *revisionRepo) GetRevisions(ctx context.Context, club, articleID string) (revisions []storage.Revision, err error) {
+	ctx, span := startOperation(ctx, r.driver, "getRevisions")
+	defer func() { End(span, err) }()
+	return r.repo.GetRevisions(ctx, club, articleID)
+}
@@ -0,0 +1,129 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/storage/memory"
+	"com.thanos/pkg/tracing"
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestStartHandler(t *testing.T) {
+	recorder := record(t)
+
+	r := chi.NewRouter()
+	r.Get("/article/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.StartHandler(r, "news")
+		tracing.EndHandler(span, http.StatusNotFound, nil)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/article/1", nil)
+	req.Header.Set("traceparent", traceparent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "GET /article/{id}" || span.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("unexpected span %s of kind %s", span.Name(), span.SpanKind())
+	}
+	if span.Parent().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || !span.Parent().IsRemote() {
+		t.Fatalf("expected the span to continue the trace of the request, got parent %+v", span.Parent())
+	}
+	if span.Status().Code != codes.Unset {
+		t.Fatalf("expected client errors not to fail a server span, got %v", span.Status())
+	}
+}
+
+func TestTransport(t *testing.T) {
+	recorder := record(t)
+
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := (&http.Client{Transport: tracing.Transport(http.DefaultTransport)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if req.Header.Get("traceparent") != "" {
+		t.Fatal("expected the request not to be modified")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	client := spans[0]
+	expected := "00-" + client.SpanContext().TraceID().String() + "-" + client.SpanContext().SpanID().String() + "-01"
+	if received != expected {
+		t.Fatalf("expected traceparent %s, got %s", expected, received)
+	}
+	if client.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("expected the client span to be a child of the span of the request context")
+	}
+	if client.Status().Code != codes.Error {
+		t.Fatalf("expected a failed client span, got %v", client.Status())
+	}
+}
+
+func TestDBRepo(t *testing.T) {
+	recorder := record(t)
+
+	repo := tracing.DBRepo("memory", memory.NewRepository(time.Hour))
+	if _, err := repo.GetArticleByID(context.Background(), "", "missing"); err == nil {
+		t.Fatal("expected a not found error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "storage.getArticleByID" || spans[0].Status().Code != codes.Error || len(spans[0].Events()) != 1 {
+		t.Fatalf("expected a failed storage span with its error recorded, got %s %v", spans[0].Name(), spans[0].Status())
+	}
+}
+
+// record registers a tracer provider recording every span, for the duration of the test
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	if _, err := tracing.Setup(config.Tracing{Exporter: tracing.ExporterNone}, config.APP{}); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
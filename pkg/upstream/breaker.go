@@ -0,0 +1,94 @@
+package upstream
+
+import (
+	"sync"
+	"time"
+)
+
+// State of a circuit breaker
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half-open"
+)
+
+// breaker stops calls to a feed after a number of consecutive failures. Once open it lets a single
+// trial call through after the open duration, closing again if the trial succeeds
+type breaker struct {
+	mu           sync.Mutex
+	threshold    int
+	openDuration time.Duration
+	failures     int
+	state        State
+	openedAt     time.Time
+	trialRunning bool
+}
+
+func newBreaker(threshold int, openDuration time.Duration) *breaker {
+	return &breaker{
+		threshold:    threshold,
+		openDuration: openDuration,
+		state:        StateClosed,
+	}
+}
+
+// allow reports whether a call may go through
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if now.Sub(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.trialRunning = true
+		return true
+	case StateHalfOpen:
+		if b.trialRunning {
+			return false
+		}
+		b.trialRunning = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record the outcome of a call that was allowed through
+func (b *breaker) record(success bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialRunning = false
+
+	if success {
+		b.failures = 0
+		b.state = StateClosed
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = now
+	}
+}
+
+// release a call that was allowed through without recording an outcome, e.g. when it got cancelled
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialRunning = false
+}
+
+func (b *breaker) current() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
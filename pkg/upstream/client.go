@@ -0,0 +1,219 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Client performs requests to upstream news feeds. Failed requests are retried with capped, jittered
+// exponential backoff. Every feed has its own circuit breaker and rate limiter, the latter also honoring
+// the Retry-After header of 429 and 503 responses
+type Client struct {
+	httpClient *http.Client
+	cfg        config.Upstream
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	limiters map[string]*limiter
+}
+
+// NewClient creates a new upstream Client
+func NewClient(cfg config.Upstream) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = cfg.DialTimeout
+	transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+
+	return &Client{
+		httpClient: &http.Client{
+			Transport: tracing.Transport(transport),
+			Timeout:   cfg.Timeout,
+		},
+		cfg:      cfg,
+		breakers: make(map[string]*breaker),
+		limiters: make(map[string]*limiter),
+	}
+}
+
+// Get requests the uri on behalf of a feed and decodes the response body with the given decode func.
+// Errors are always of type *Error
+func (c *Client) Get(ctx context.Context, feed, uri string, decode func(io.Reader) error) (err error) {
+	ctx, span := tracing.Start(ctx, "upstream.Get", trace.WithAttributes(attribute.String("upstream.feed", feed)))
+	defer func() { tracing.End(span, err) }()
+
+	b, l := c.feed(feed)
+	upErr := &Error{Feed: feed, URL: uri}
+	defer func() { span.SetAttributes(attribute.Int("upstream.attempts", upErr.Attempts)) }()
+
+	if !b.allow(time.Now()) {
+		upErr.Err = ErrCircuitOpen
+		return upErr
+	}
+
+	for {
+		upErr.Attempts++
+
+		if err := l.wait(ctx); err != nil {
+			// Cancellation says nothing about the health of the feed
+			b.release()
+			upErr.Err = err
+			return upErr
+		}
+
+		retryAfter, retry, err := c.do(ctx, uri, decode, upErr)
+		if err == nil {
+			b.record(true, time.Now())
+			return nil
+		}
+		upErr.Err = err
+
+		if retryAfter > 0 {
+			l.pause(time.Now().Add(retryAfter))
+		}
+
+		delay := c.backoff(upErr.Attempts)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		if ctx.Err() != nil {
+			b.release()
+			return upErr
+		}
+
+		if !retry || upErr.Attempts > c.cfg.MaxRetries || delay > c.cfg.RetryMaxDelay {
+			// Only failures that point at an unhealthy feed count towards opening the breaker
+			b.record(!retry, time.Now())
+			return upErr
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			b.release()
+			return upErr
+		}
+	}
+}
+
+// States returns the circuit breaker state of every feed that has been called
+func (c *Client) States() map[string]State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	states := make(map[string]State, len(c.breakers))
+	for feed, b := range c.breakers {
+		states[feed] = b.current()
+	}
+
+	return states
+}
+
+// do performs a single attempt. It returns how long the feed asked us to back off, whether the
+// request may be retried and the error of the attempt
+func (c *Client) do(ctx context.Context, uri string, decode func(io.Reader) error, upErr *Error) (time.Duration, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("could not create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		upErr.StatusCode = 0
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+
+	upErr.StatusCode = resp.StatusCode
+
+	if resp.StatusCode != http.StatusOK {
+		// Drain the body so that the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+		return retryAfter(resp), retryable(resp.StatusCode), fmt.Errorf("%w: %d", ErrStatus, resp.StatusCode)
+	}
+
+	if err = decode(resp.Body); err != nil {
+		return 0, false, fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+
+	return 0, false, nil
+}
+
+// backoff returns the full jitter exponential backoff delay of an attempt
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.cfg.RetryMaxDelay
+	if shift := attempt - 1; shift < 32 {
+		if exp := c.cfg.RetryBaseDelay << uint(shift); exp > 0 && exp < d {
+			d = exp
+		}
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+func (c *Client) feed(name string) (*breaker, *limiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[name]
+	if !ok {
+		b = newBreaker(c.cfg.BreakerFailureThreshold, c.cfg.BreakerOpenDuration)
+		c.breakers[name] = b
+	}
+
+	l, ok := c.limiters[name]
+	if !ok {
+		l = newLimiter(c.cfg.RateLimit, c.cfg.RateBurst, time.Now())
+		c.limiters[name] = l
+	}
+
+	return b, l
+}
+
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests ||
+		statusCode >= http.StatusInternalServerError
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or an http date
+func retryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
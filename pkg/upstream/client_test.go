@@ -0,0 +1,137 @@
+package upstream_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/upstream"
+)
+
+func newConfig() config.Upstream {
+	return config.Upstream{
+		Timeout:                 time.Second,
+		MaxRetries:              3,
+		RetryBaseDelay:          time.Millisecond,
+		RetryMaxDelay:           10 * time.Millisecond,
+		BreakerFailureThreshold: 2,
+		BreakerOpenDuration:     time.Minute,
+	}
+}
+
+func discard(r io.Reader) error {
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func TestClient_GetRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := upstream.NewClient(newConfig())
+
+	if err := c.Get(context.Background(), "feed", srv.URL, discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+
+	if c.States()["feed"] != upstream.StateClosed {
+		t.Fatalf("expected a closed circuit, got %s", c.States()["feed"])
+	}
+}
+
+func TestClient_GetDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	err := upstream.NewClient(newConfig()).Get(context.Background(), "feed", srv.URL, discard)
+
+	var upErr *upstream.Error
+	if !errors.As(err, &upErr) || !errors.Is(err, upstream.ErrStatus) {
+		t.Fatalf("expected a status error, got %v", err)
+	}
+
+	if upErr.StatusCode != http.StatusNotFound || calls != 1 {
+		t.Fatalf("expected a single 404 attempt, got status %d after %d attempts", upErr.StatusCode, calls)
+	}
+}
+
+func TestClient_GetOpensCircuit(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cfg := newConfig()
+	cfg.MaxRetries = 0
+	c := upstream.NewClient(cfg)
+
+	for i := 0; i < 2; i++ {
+		if err := c.Get(context.Background(), "feed", srv.URL, discard); !errors.Is(err, upstream.ErrStatus) {
+			t.Fatalf("expected a status error, got %v", err)
+		}
+	}
+
+	if err := c.Get(context.Background(), "feed", srv.URL, discard); !errors.Is(err, upstream.ErrCircuitOpen) {
+		t.Fatalf("expected an open circuit error, got %v", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected the open circuit to stop calls to the feed, got %d calls", calls)
+	}
+
+	if c.States()["feed"] != upstream.StateOpen {
+		t.Fatalf("expected an open circuit, got %s", c.States()["feed"])
+	}
+}
+
+func TestClient_GetHonorsRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := upstream.NewClient(newConfig())
+
+	// Retry-After exceeds the max retry delay so the request fails without retrying
+	if err := c.Get(context.Background(), "feed", srv.URL, discard); !errors.Is(err, upstream.ErrStatus) {
+		t.Fatalf("expected a status error, got %v", err)
+	}
+
+	// and the feed is paused until then
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := c.Get(ctx, "feed", srv.URL, discard); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the paused feed to wait for the retry after, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected a single call, got %d", calls)
+	}
+}
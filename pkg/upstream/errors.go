@@ -0,0 +1,34 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCircuitOpen is returned without calling the feed while its circuit breaker is open
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrStatus is returned when the feed responds with a non 200 status
+	ErrStatus = errors.New("unexpected response status")
+	// ErrDecode is returned when the response body of the feed can not be decoded
+	ErrDecode = errors.New("could not decode response")
+)
+
+// Error describes a failed upstream request. Use errors.Is with the sentinel errors of this
+// package to find out why it failed
+type Error struct {
+	Feed string
+	URL  string
+	// StatusCode of the last response, 0 when no response was received
+	StatusCode int
+	Attempts   int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("upstream %s request to %s failed after %d attempt(s): %v", e.Feed, e.URL, e.Attempts, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
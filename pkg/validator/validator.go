@@ -4,6 +4,7 @@ import (
 	"errors"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
@@ -39,8 +40,39 @@ func New() (*Validator, error) {
 		return nil, err
 	}
 
+	if err := registerDate(v, trans); err != nil {
+		return nil, err
+	}
+
 	return &Validator{
 		Validate:   v,
 		Translator: trans,
 	}, nil
 }
+
+// registerDate registers the date tag, validating either a day or an RFC 3339 date time
+func registerDate(v *validator.Validate, trans ut.Translator) error {
+	err := v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		s := fl.Field().String()
+		if _, err := time.Parse("2006-01-02", s); err == nil {
+			return true
+		}
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return v.RegisterTranslation(
+		"date",
+		trans,
+		func(ut ut.Translator) error {
+			return ut.Add("date", "{0} must be a date (YYYY-MM-DD) or an RFC 3339 date time", true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			t, _ := ut.T("date", fe.Field())
+			return t
+		},
+	)
+}
@@ -0,0 +1,330 @@
+// Package webhooks delivers the events of the stored articles to the webhooks partners subscribed.
+// Deliveries are queued in the storage so that they're retried until they succeed, even across restarts
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/tracing"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Headers of the deliveries
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// batchSize is the number of deliveries attempted concurrently
+const batchSize = 20
+
+// ErrForbiddenAddress fails the deliveries to the network of the dispatcher, which would let the subscribers
+// of webhooks reach the services it can reach
+var ErrForbiddenAddress = errors.New("webhooks can't be delivered to loopback, private, link-local or unspecified addresses")
+
+// Payload is the body delivered to a webhook
+type Payload struct {
+	DeliveryID string      `json:"deliveryId"`
+	Type       events.Type `json:"type"`
+	events.Event
+}
+
+// Sign returns the signature of a payload delivered at the given unix time, the hex encoded HMAC-SHA256
+// of the timestamp and the payload joined by a dot, keyed by the secret of the webhook
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher queues a delivery of every published event to every webhook subscribed to it, and attempts the
+// due deliveries of the queue. Failed attempts are retried with exponential backoff until the delivery dies
+type Dispatcher struct {
+	repo   storage.WebhookRepo
+	client *http.Client
+	cfg    config.Webhooks
+	log    *logger.Logger
+	now    func() time.Time
+}
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(repo storage.WebhookRepo, cfg config.Webhooks, l *logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: tracing.Transport(transport(cfg)),
+			// a redirect could leak the payload to another endpoint than the subscribed one
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		cfg: cfg,
+		log: l,
+		now: time.Now,
+	}
+}
+
+// transport returns the transport of the deliveries. Unless private networks are allowed, the addresses every
+// connection dials are checked once resolved, so that a webhook host resolving to a forbidden address is rejected too
+func transport(cfg config.Webhooks) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.AllowPrivateNetworks {
+		return t
+	}
+
+	// a proxy would be dialed instead of the webhook, the webhook's address would then never be checked
+	t.Proxy = nil
+	t.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   dialControl,
+	}).DialContext
+
+	return t
+}
+
+// dialControl rejects the connections to forbidden addresses
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || Forbidden(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+
+	return nil
+}
+
+// Forbidden reports whether an ip is a loopback, private, link-local or unspecified address, which webhooks
+// aren't delivered to
+func Forbidden(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// Publish queues the deliveries of the events. Events are published by the sync, which a queue that can't
+// be written to must not fail, so errors are only logged
+func (d *Dispatcher) Publish(evs ...events.Event) {
+	if len(evs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.Timeout)
+	defer cancel()
+
+	if err := d.enqueue(ctx, evs); err != nil {
+		d.log.WithError(err).Errorf("could not queue the webhook deliveries of %d events", len(evs))
+	}
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, evs []events.Event) error {
+	webhooks, err := d.repo.GetWebhooks(ctx)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	now := d.now().UTC()
+
+	var deliveries []storage.Delivery
+	for _, e := range evs {
+		if e.At.IsZero() {
+			e.At = now
+		}
+
+		for _, w := range webhooks {
+			if !w.Matches(string(e.Type), e.Club) {
+				continue
+			}
+
+			delivery := storage.Delivery{
+				ID:            uuid.NewString(),
+				WebhookID:     w.ID,
+				EventType:     string(e.Type),
+				ArticleID:     e.ArticleID,
+				Status:        storage.DeliveryPending,
+				CreatedAt:     now,
+				NextAttemptAt: now,
+			}
+
+			payload, err := json.Marshal(Payload{DeliveryID: delivery.ID, Type: e.Type, Event: e})
+			if err != nil {
+				return err
+			}
+			delivery.Payload = string(payload)
+
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	return d.repo.AddDeliveries(ctx, deliveries)
+}
+
+// Run attempts the due deliveries on every poll interval until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil {
+			d.log.WithError(err).Error("could not dispatch the due webhook deliveries")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch attempts every due delivery and returns how many were attempted
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	// a claimed delivery isn't claimed again before its attempt timed out
+	lease := 2 * d.cfg.Timeout
+
+	attempted := 0
+	for ctx.Err() == nil {
+		deliveries, err := d.repo.ClaimDeliveries(ctx, d.now().UTC(), lease, batchSize)
+		if err != nil {
+			return attempted, err
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Add(1)
+			go func(delivery storage.Delivery) {
+				defer wg.Done()
+				d.attempt(ctx, delivery)
+			}(deliveries[i])
+		}
+		wg.Wait()
+
+		attempted += len(deliveries)
+		if len(deliveries) < batchSize {
+			break
+		}
+	}
+
+	return attempted, nil
+}
+
+// attempt delivers the payload of a delivery to its webhook and stores the outcome
+func (d *Dispatcher) attempt(ctx context.Context, delivery storage.Delivery) {
+	ctx, span := tracing.Start(ctx, "webhooks.attempt")
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	log := d.log.WithContext(ctx).WithFields(logrus.Fields{
+		"webhook.id":  delivery.WebhookID,
+		"delivery.id": delivery.ID,
+		"event.type":  delivery.EventType,
+	})
+
+	w, err := d.repo.GetWebhook(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		// the webhook was deleted after the delivery was claimed
+		delivery.Status, delivery.Error = storage.DeliveryDead, "webhook deleted"
+		d.update(ctx, log, delivery)
+		return
+	case err != nil:
+		// the delivery is claimed again once its lease expired
+		log.WithError(err).Warn("could not retrieve the webhook of a delivery")
+		return
+	}
+
+	delivery.Attempts++
+	delivery.ResponseStatus, err = d.post(ctx, w, delivery)
+	if ctx.Err() != nil {
+		// attempts interrupted by a shutdown don't count, the delivery is claimed again once its lease expired
+		return
+	}
+	delivery.Error = ""
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = storage.DeliveryDone
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status = storage.DeliveryDead
+		log.WithError(err).Errorf("webhook delivery died after %d attempts", delivery.Attempts)
+	default:
+		delivery.NextAttemptAt = d.now().UTC().Add(d.backoff(delivery.Attempts))
+		log.WithError(err).Warnf("webhook delivery attempt %d failed, retrying at %s", delivery.Attempts, delivery.NextAttemptAt)
+	}
+
+	d.update(ctx, log, delivery)
+}
+
+// update stores the outcome of an attempt. A delivery whose outcome couldn't be stored is attempted again
+// once its lease expired
+func (d *Dispatcher) update(ctx context.Context, log *logrus.Entry, delivery storage.Delivery) {
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.WithError(err).Error("could not store the outcome of a webhook delivery")
+	}
+}
+
+// post sends the signed payload to the webhook, any response but a 2xx fails the attempt
+func (d *Dispatcher) post(ctx context.Context, w storage.Webhook, delivery storage.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(w.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay after a failed attempt, doubling from the base delay on every attempt
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.RetryBaseDelay
+	for i := 1; i < attempts && delay < d.cfg.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.RetryMaxDelay {
+		delay = d.cfg.RetryMaxDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,248 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"com.thanos/pkg/config"
+	"com.thanos/pkg/events"
+	"com.thanos/pkg/logger"
+	"com.thanos/pkg/news"
+	"com.thanos/pkg/storage"
+	"com.thanos/pkg/storage/memory"
+	"com.thanos/pkg/webhooks"
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	testCases := []struct {
+		description      string
+		status           int
+		webhook          storage.Webhook
+		cfg              config.Webhooks
+		dispatches       int
+		expectedRequests int
+		expectedStatus   storage.DeliveryStatus
+		expectedAttempts int
+	}{
+		{
+			description:      "should deliver the signed payload once",
+			status:           http.StatusNoContent,
+			webhook:          storage.Webhook{Events: []string{"article.created"}, Clubs: []string{"brentford"}},
+			cfg:              config.Webhooks{Timeout: time.Second, MaxAttempts: 3, AllowPrivateNetworks: true},
+			dispatches:       2,
+			expectedRequests: 1,
+			expectedStatus:   storage.DeliveryDone,
+			expectedAttempts: 1,
+		},
+		{
+			description:      "should retry a failed delivery after the backoff",
+			status:           http.StatusInternalServerError,
+			cfg:              config.Webhooks{Timeout: time.Second, MaxAttempts: 3, AllowPrivateNetworks: true, RetryBaseDelay: time.Hour, RetryMaxDelay: time.Hour},
+			dispatches:       2,
+			expectedRequests: 1,
+			expectedStatus:   storage.DeliveryPending,
+			expectedAttempts: 1,
+		},
+		{
+			description:      "should give up on a delivery after the max attempts",
+			status:           http.StatusBadGateway,
+			cfg:              config.Webhooks{Timeout: time.Second, MaxAttempts: 3, AllowPrivateNetworks: true},
+			dispatches:       4,
+			expectedRequests: 3,
+			expectedStatus:   storage.DeliveryDead,
+			expectedAttempts: 3,
+		},
+		{
+			description: "should not deliver the events of other clubs",
+			webhook:     storage.Webhook{Clubs: []string{"arsenal"}},
+			cfg:         config.Webhooks{Timeout: time.Second, MaxAttempts: 3, AllowPrivateNetworks: true},
+			dispatches:  1,
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := 0
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests++
+				mu.Unlock()
+
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				timestamp, err := strconv.ParseInt(r.Header.Get(webhooks.TimestampHeader), 10, 64)
+				if err != nil {
+					t.Errorf("invalid timestamp header: %v", err)
+				}
+				if r.Header.Get(webhooks.SignatureHeader) != webhooks.Sign("secret", timestamp, body) {
+					t.Errorf("invalid signature %q", r.Header.Get(webhooks.SignatureHeader))
+				}
+				if r.Header.Get(webhooks.EventHeader) != string(events.ArticleCreated) {
+					t.Errorf("unexpected event header %q", r.Header.Get(webhooks.EventHeader))
+				}
+
+				var payload webhooks.Payload
+				if err := json.Unmarshal(body, &payload); err != nil {
+					t.Error(err)
+				}
+				if payload.DeliveryID != r.Header.Get(webhooks.DeliveryHeader) || payload.ArticleID != "645150" || payload.Data == nil {
+					t.Errorf("unexpected payload: %s", body)
+				}
+
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			ctx := context.Background()
+			repo := memory.NewWebhookRepository(time.Hour)
+
+			webhook := tc.webhook
+			webhook.ID, webhook.URL, webhook.Secret = "webhook", srv.URL, "secret"
+			if err := repo.CreateWebhook(ctx, webhook); err != nil {
+				t.Fatal(err)
+			}
+
+			d := webhooks.NewDispatcher(repo, tc.cfg, log)
+			d.Publish(events.Event{
+				Type:      events.ArticleCreated,
+				ArticleID: "645150",
+				Club:      "brentford",
+				Data:      &news.Data{Id: "645150"},
+			})
+
+			for i := 0; i < tc.dispatches; i++ {
+				if _, err := d.Dispatch(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if requests != tc.expectedRequests {
+				t.Fatalf("expected %d requests, got %d", tc.expectedRequests, requests)
+			}
+
+			deliveries, err := repo.GetDeliveries(ctx, webhook.ID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectedRequests == 0 {
+				if len(deliveries) != 0 {
+					t.Fatalf("expected no deliveries, got %+v", deliveries)
+				}
+				return
+			}
+
+			if len(deliveries) != 1 {
+				t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+			}
+			if deliveries[0].Status != tc.expectedStatus || deliveries[0].Attempts != tc.expectedAttempts {
+				t.Fatalf("expected a %s delivery after %d attempts, got %+v", tc.expectedStatus, tc.expectedAttempts, deliveries[0])
+			}
+			if tc.status >= 300 && deliveries[0].ResponseStatus != tc.status {
+				t.Fatalf("expected the response status %d in the delivery log, got %d", tc.status, deliveries[0].ResponseStatus)
+			}
+		})
+	}
+}
+
+func TestDispatcher_DispatchForbiddenAddress(t *testing.T) {
+	testCases := []struct {
+		description string
+		host        string
+	}{
+		{
+			description: "should not deliver to a loopback address",
+			host:        "127.0.0.1",
+		},
+		{
+			description: "should not deliver to a host resolving to a loopback address",
+			host:        "localhost",
+		},
+	}
+
+	cfg, err := config.New("../../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLogger(cfg.Logger, logger.DisableOutput())
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			requests := make(chan struct{}, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests <- struct{}{}
+			}))
+			defer srv.Close()
+
+			u, err := url.Parse(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u.Host = net.JoinHostPort(tc.host, u.Port())
+
+			ctx := context.Background()
+			repo := memory.NewWebhookRepository(time.Hour)
+			if err := repo.CreateWebhook(ctx, storage.Webhook{ID: "webhook", URL: u.String(), Secret: "secret"}); err != nil {
+				t.Fatal(err)
+			}
+
+			d := webhooks.NewDispatcher(repo, config.Webhooks{Timeout: time.Second, MaxAttempts: 3, RetryBaseDelay: time.Hour, RetryMaxDelay: time.Hour}, log)
+			d.Publish(events.Event{Type: events.ArticleCreated, ArticleID: "645150", Club: "brentford"})
+
+			if _, err := d.Dispatch(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case <-requests:
+				t.Fatal("expected no request to the webhook")
+			default:
+			}
+
+			deliveries, err := repo.GetDeliveries(ctx, "webhook", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deliveries) != 1 || deliveries[0].Attempts != 1 || !strings.Contains(deliveries[0].Error, webhooks.ErrForbiddenAddress.Error()) {
+				t.Fatalf("expected a delivery failed for its forbidden address, got %+v", deliveries)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// printf '1656928800.{}' | openssl dgst -sha256 -hmac secret
+	expected := "sha256=fded10dbb6e5ef38a8b234954c0ec061be32b889bfd0e1e77a368e0926e7875f"
+
+	if signature := webhooks.Sign("secret", 1656928800, []byte("{}")); signature != expected {
+		t.Fatalf("expected the signature %q, got %q", expected, signature)
+	}
+}